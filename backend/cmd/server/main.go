@@ -1,13 +1,22 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log"
+	"os"
 
+	"valet-parking-backend/internal/anpr"
 	"valet-parking-backend/internal/config"
 	"valet-parking-backend/internal/db"
 	"valet-parking-backend/internal/handlers"
 	"valet-parking-backend/internal/middleware"
+	"valet-parking-backend/internal/migrate"
 	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/notify"
+	"valet-parking-backend/internal/payment"
+	"valet-parking-backend/internal/storage"
+	"valet-parking-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,19 +33,156 @@ func main() {
 	}
 	defer database.Close()
 
+	// "server migrate" applies pending migrations and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		applied, err := migrate.Run(database)
+		if err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		log.Printf("Applied %d migrations", applied)
+		return
+	}
+
+	if cfg.AutoMigrate {
+		if _, err := migrate.Run(database); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+	}
+
+	dataStore := store.NewMongo(database)
+
+	// Managers take their venue from here; nobody picks one at sign-up
+	for phone, venue := range cfg.ManagerVenues {
+		if venue == "" {
+			log.Fatalf("MANAGER_PHONES entry %s has no venue: use phone=Venue Name", phone)
+		}
+	}
+
+	// Set up media blob storage
+	var blobStore storage.BlobStore
+	switch cfg.MediaStore {
+	case "s3":
+		blobStore, err = storage.NewS3Store(cfg.S3Endpoint, cfg.S3Bucket, cfg.S3Region, cfg.S3AccessKey, cfg.S3SecretKey)
+	default:
+		blobStore, err = storage.NewLocalStore(cfg.MediaDir)
+	}
+	if err != nil {
+		log.Fatal("Failed to set up media storage:", err)
+	}
+
+	// Media URLs are handed to clients, so they get a key of their own
+	switch {
+	case cfg.MediaURLSecret == "" && cfg.Production():
+		log.Fatal("MEDIA_URL_SECRET is required in production")
+	case cfg.MediaURLSecret == "":
+		cfg.MediaURLSecret = randomSecret()
+		log.Println("MEDIA_URL_SECRET not set; media URLs will stop working when the server restarts")
+	case cfg.MediaURLSecret == cfg.JWTSecret:
+		log.Fatal("MEDIA_URL_SECRET must differ from JWT_SECRET")
+	}
+
+	// Set up plate recognition; without a provider valets type plates in
+	var recognizer anpr.PlateRecognizer
+	switch cfg.ANPRProvider {
+	case "off":
+	case "http":
+		if cfg.ANPRURL == "" {
+			log.Fatal("ANPR_PROVIDER=http requires ANPR_URL")
+		}
+		recognizer = anpr.NewHTTPRecognizer(cfg.ANPRURL, cfg.ANPRToken)
+	case "stub":
+		if cfg.Production() {
+			log.Fatal("The stub recognizer makes up plates: set ANPR_PROVIDER=http or off in production")
+		}
+		recognizer = anpr.StubRecognizer{}
+	default:
+		log.Fatalf("Unknown ANPR_PROVIDER %q", cfg.ANPRProvider)
+	}
+
+	// Set up SMS delivery; OTPs must reach a real gateway in production
+	var sms notify.Sender
+	switch cfg.SMSProvider {
+	case "http":
+		if cfg.SMSURL == "" {
+			log.Fatal("SMS_PROVIDER=http requires SMS_URL")
+		}
+		sms = notify.NewHTTPSender(cfg.SMSURL, cfg.SMSToken)
+	case "log":
+		if cfg.Production() {
+			log.Fatal("No SMS gateway configured: set SMS_PROVIDER=http in production")
+		}
+		sms = notify.LogSender{}
+	default:
+		log.Fatalf("Unknown SMS_PROVIDER %q", cfg.SMSProvider)
+	}
+
+	// Set up online tip payments; without a provider customers can only tip in cash
+	var payments payment.Provider
+	switch cfg.PaymentProvider {
+	case "off":
+	case "http":
+		if cfg.PaymentURL == "" || cfg.PaymentWebhookSecret == "" {
+			log.Fatal("PAYMENT_PROVIDER=http requires PAYMENT_URL and PAYMENT_WEBHOOK_SECRET")
+		}
+		payments = payment.NewHTTPProvider(cfg.PaymentURL, cfg.PaymentToken)
+	case "stub":
+		if cfg.Production() {
+			log.Fatal("The stub payment provider takes no money: set PAYMENT_PROVIDER=http or off in production")
+		}
+		payments = payment.StubProvider{}
+	default:
+		log.Fatalf("Unknown PAYMENT_PROVIDER %q", cfg.PaymentProvider)
+	}
+
+	r := setupRouter(cfg, dataStore, blobStore, recognizer, sms, payments)
+
+	// Start server
+	log.Printf("Server starting on port %s", cfg.ServerPort)
+	if err := r.Run(":" + cfg.ServerPort); err != nil {
+		log.Fatal("Failed to start server:", err)
+	}
+}
+
+// randomSecret returns a fresh 256-bit key, hex encoded
+func randomSecret() string {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal("Failed to generate a secret:", err)
+	}
+	return hex.EncodeToString(key)
+}
+
+// setupRouter builds the HTTP router with every handler wired to the given
+// store, media storage, plate recognizer, SMS sender and payment provider
+// (nil disables online tips)
+func setupRouter(cfg *config.Config, dataStore *store.Store, blobStore storage.BlobStore, recognizer anpr.PlateRecognizer, sms notify.Sender, payments payment.Provider) *gin.Engine {
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(database, cfg.JWTSecret)
-	vehicleHandler := handlers.NewVehicleHandler(database)
-	sessionHandler := handlers.NewSessionHandler(database)
+	authHandler := handlers.NewAuthHandler(dataStore, cfg.JWTSecret, cfg.ManagerVenues, sms, !cfg.Production())
+	vehicleHandler := handlers.NewVehicleHandler(dataStore, cfg.PlateCountry, recognizer, cfg.MaxUploadBytes)
+	sessionHandler := handlers.NewSessionHandler(dataStore, sms)
+	venueHandler := handlers.NewVenueHandler(dataStore)
+	keyTagHandler := handlers.NewKeyTagHandler(dataStore)
+	auditHandler := handlers.NewAuditHandler(dataStore)
+	reportHandler := handlers.NewReportHandler(dataStore)
+	ratingHandler := handlers.NewRatingHandler(dataStore)
+	alertHandler := handlers.NewAlertHandler(dataStore)
+	incidentHandler := handlers.NewIncidentHandler(dataStore)
+	tipHandler := handlers.NewTipHandler(dataStore, payments, cfg.PaymentWebhookSecret)
+	inspectionHandler := handlers.NewInspectionHandler(dataStore)
+	mediaHandler := handlers.NewMediaHandler(dataStore, blobStore, cfg.MediaURLSecret, cfg.MaxUploadBytes)
 
 	// Setup Gin router
 	r := gin.Default()
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(middleware.NoRoute)
+	r.NoMethod(middleware.NoMethod)
 
 	// CORS middleware
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept-Language, Idempotency-Key, If-Match, If-None-Match")
+		c.Header("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -44,24 +190,38 @@ func main() {
 		c.Next()
 	})
 
+	// Errors handlers attach without responding become problem documents
+	r.Use(middleware.ErrorMiddleware())
+
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Retried POSTs with an Idempotency-Key replay the first response
+	idempotency := middleware.IdempotencyMiddleware(dataStore.Idempotency(), cfg.IdempotencyTTL)
+
 	// API routes
 	api := r.Group("/api")
 	{
-		// Auth routes (no auth required)
+		// Auth routes (no auth required). Not idempotent: anonymous callers
+		// would share one key space, and verify-otp responses hold tokens.
 		auth := api.Group("/auth")
 		{
 			auth.POST("/send-otp", authHandler.SendOTP)
 			auth.POST("/verify-otp", authHandler.VerifyOTP)
 		}
 
+		// Payment provider callbacks (authorized by body signature)
+		api.POST("/payments/webhook", tipHandler.PaymentWebhook)
+
+		// Presigned media upload and download (authorized by URL signature)
+		api.PUT("/media/uploads/:id", mediaHandler.CompleteUpload)
+		api.GET("/media/files/:id/:variant", mediaHandler.ServeFile)
+
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+		protected.Use(middleware.AuthMiddleware(cfg.JWTSecret), idempotency)
 		{
 			// Profile route (any authenticated user)
 			protected.PUT("/auth/profile", authHandler.UpdateProfile)
@@ -72,10 +232,74 @@ func main() {
 			{
 				vehicles.POST("", vehicleHandler.AddVehicle)
 				vehicles.GET("", vehicleHandler.ListVehicles)
+				vehicles.GET("/shared", vehicleHandler.ListSharedVehicles)
+				vehicles.PUT("/:id", vehicleHandler.UpdateVehicle)
+				vehicles.DELETE("/:id", vehicleHandler.ArchiveVehicle)
+				vehicles.POST("/:id/restore", vehicleHandler.RestoreVehicle)
+				vehicles.POST("/:id/default", vehicleHandler.SetDefaultVehicle)
+				vehicles.POST("/:id/claim", vehicleHandler.ClaimOwnership)
+				vehicles.POST("/:id/shares", vehicleHandler.ShareVehicle)
+				vehicles.DELETE("/:id/shares/:phone", vehicleHandler.UnshareVehicle)
 			}
 
 			// Vehicle search (valet only)
 			protected.GET("/vehicles/search", middleware.RoleMiddleware(string(models.RoleValet)), vehicleHandler.GetVehicleByRegistration)
+			protected.GET("/vehicles/lookup", middleware.RoleMiddleware(string(models.RoleValet)), vehicleHandler.SearchVehicles)
+			protected.POST("/vehicles/recognize", middleware.RoleMiddleware(string(models.RoleValet)), vehicleHandler.RecognizePlate)
+
+			// Plate ownership disputes (valet only)
+			protected.GET("/vehicles/disputes", middleware.RoleMiddleware(string(models.RoleValet)), vehicleHandler.ListDisputes)
+			protected.POST("/vehicles/:id/verify-ownership", middleware.RoleMiddleware(string(models.RoleValet)), vehicleHandler.VerifyOwnership)
+
+			// Media routes (any authenticated user)
+			protected.POST("/media", mediaHandler.Upload)
+			protected.POST("/media/uploads", mediaHandler.CreateUpload)
+			protected.GET("/media/:id", mediaHandler.GetMedia)
+
+			// Venue settings and staff (valets can read settings, managers change them and assign valets)
+			venue := protected.Group("/venue")
+			{
+				venue.GET("/settings", middleware.RoleMiddleware(string(models.RoleValet), string(models.RoleManager)), venueHandler.GetSettings)
+				venue.PUT("/settings", middleware.RoleMiddleware(string(models.RoleManager)), venueHandler.UpdateSettings)
+				venue.GET("/audit-logs", middleware.RoleMiddleware(string(models.RoleManager)), auditHandler.ListAuditLogs)
+				venue.POST("/valets", middleware.RoleMiddleware(string(models.RoleManager)), venueHandler.AssignValet)
+				venue.DELETE("/valets/:id", middleware.RoleMiddleware(string(models.RoleManager)), venueHandler.UnassignValet)
+			}
+
+			// Key tag inventory (valet only)
+			keyTags := protected.Group("/key-tags")
+			keyTags.Use(middleware.RoleMiddleware(string(models.RoleValet)))
+			{
+				keyTags.GET("", keyTagHandler.ListKeyTags)
+				keyTags.POST("", keyTagHandler.CreateKeyTag)
+				keyTags.PUT("/:id", keyTagHandler.UpdateKeyTag)
+			}
+
+			// Venue reports (managers; valets can see their own shift report)
+			reports := protected.Group("/reports")
+			{
+				reports.GET("/rejections", middleware.RoleMiddleware(string(models.RoleManager)), reportHandler.RejectionMetrics)
+				reports.GET("/ratings", middleware.RoleMiddleware(string(models.RoleManager)), reportHandler.RatingMetrics)
+				reports.GET("/shift", middleware.RoleMiddleware(string(models.RoleValet), string(models.RoleManager)), reportHandler.ShiftReport)
+				reports.GET("/tips", middleware.RoleMiddleware(string(models.RoleValet), string(models.RoleManager)), reportHandler.TipPayouts)
+			}
+
+			// Incident triage (manager only)
+			incidents := protected.Group("/incidents")
+			incidents.Use(middleware.RoleMiddleware(string(models.RoleManager)))
+			{
+				incidents.GET("", incidentHandler.ListIncidents)
+				incidents.GET("/:id", incidentHandler.GetIncident)
+				incidents.PUT("/:id", incidentHandler.UpdateIncident)
+			}
+
+			// Manager alerts (manager only)
+			alerts := protected.Group("/alerts")
+			alerts.Use(middleware.RoleMiddleware(string(models.RoleManager)))
+			{
+				alerts.GET("", alertHandler.ListAlerts)
+				alerts.POST("/:id/acknowledge", alertHandler.AcknowledgeAlert)
+			}
 
 			// Session routes
 			sessions := protected.Group("/sessions")
@@ -98,6 +322,9 @@ func main() {
 				// Reject parking (customer only)
 				sessions.POST("/:id/reject", middleware.RoleMiddleware(string(models.RoleCustomer)), sessionHandler.RejectParking)
 
+				// Regenerate pickup OTP (customer only)
+				sessions.POST("/:id/regenerate-otp", middleware.RoleMiddleware(string(models.RoleCustomer)), sessionHandler.RegeneratePickupOTP)
+
 				// Cancel pickup (customer only)
 				sessions.POST("/:id/cancel-pickup", middleware.RoleMiddleware(string(models.RoleCustomer)), sessionHandler.CancelPickup)
 
@@ -107,9 +334,39 @@ func main() {
 				// Verify delivery (valet only)
 				sessions.POST("/:id/verify-delivery", middleware.RoleMiddleware(string(models.RoleValet)), sessionHandler.VerifyDelivery)
 
+				// Release without pickup OTP for lost ticket or dead phone (manager only)
+				sessions.POST("/:id/manual-release", middleware.RoleMiddleware(string(models.RoleManager)), sessionHandler.ManualRelease)
+				sessions.POST("/:id/release-fee", middleware.RoleMiddleware(string(models.RoleManager)), sessionHandler.CollectReleaseFee)
+
+				// Rate a delivered session (customer only), view its rating (any authenticated user)
+				sessions.POST("/:id/rating", middleware.RoleMiddleware(string(models.RoleCustomer)), ratingHandler.SubmitRating)
+				sessions.GET("/:id/rating", ratingHandler.GetRating)
+
+				// Tip on a delivered session: customers pay online, valets record cash
+				sessions.POST("/:id/tips", middleware.RoleMiddleware(string(models.RoleCustomer), string(models.RoleValet)), tipHandler.AddTip)
+				sessions.GET("/:id/tips", tipHandler.ListTips)
+
+				// File and list incidents (customers and valets file, anyone with access can list)
+				sessions.POST("/:id/incidents", middleware.RoleMiddleware(string(models.RoleCustomer), string(models.RoleValet)), incidentHandler.FileIncident)
+				sessions.GET("/:id/incidents", incidentHandler.ListSessionIncidents)
+
+				// Session timeline (any authenticated user)
+				sessions.GET("/:id/timeline", sessionHandler.GetTimeline)
+
 				// Update session status (valet only)
 				sessions.PUT("/:id/status", middleware.RoleMiddleware(string(models.RoleValet)), sessionHandler.UpdateStatus)
 
+				// Assign a key tag and record key custody (valet only)
+				sessions.POST("/:id/key", middleware.RoleMiddleware(string(models.RoleValet)), keyTagHandler.AssignKey)
+				sessions.POST("/:id/key/handoff", middleware.RoleMiddleware(string(models.RoleValet)), keyTagHandler.HandoffKey)
+
+				// Record vehicle inspection at check-in or check-out (valet only)
+				sessions.POST("/:id/inspections", middleware.RoleMiddleware(string(models.RoleValet)), inspectionHandler.CreateInspection)
+
+				// List and compare inspections (any authenticated user)
+				sessions.GET("/:id/inspections", inspectionHandler.ListInspections)
+				sessions.GET("/:id/inspections/compare", inspectionHandler.CompareInspections)
+
 				// Get pending pickups (valet only)
 				sessions.GET("/pending-pickups", middleware.RoleMiddleware(string(models.RoleValet)), sessionHandler.GetPendingPickups)
 
@@ -122,9 +379,5 @@ func main() {
 		}
 	}
 
-	// Start server
-	log.Printf("Server starting on port %s", cfg.ServerPort)
-	if err := r.Run(":" + cfg.ServerPort); err != nil {
-		log.Fatal("Failed to start server:", err)
-	}
+	return r
 }
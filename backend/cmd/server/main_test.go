@@ -0,0 +1,1753 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"valet-parking-backend/internal/anpr"
+	"valet-parking-backend/internal/config"
+	"valet-parking-backend/internal/notify"
+	"valet-parking-backend/internal/payment"
+	"valet-parking-backend/internal/storage"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testVenue         = "Grand Hotel"
+	managerPhone      = "+919000000001"
+	otherVenue        = "Other Hotel"
+	otherManagerPhone = "+919000000002"
+	webhookSecret     = "test-webhook-secret"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+}
+
+// testServer is the full router running against an in-memory store
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	sms    *smsOutbox
+}
+
+// smsOutbox records the text messages the server sends, by phone
+type smsOutbox struct {
+	mu   sync.Mutex
+	sent map[string][]string
+}
+
+func (o *smsOutbox) SendSMS(ctx context.Context, phone, text string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent[phone] = append(o.sent[phone], text)
+	return nil
+}
+
+// last returns the latest message sent to phone
+func (o *smsOutbox) last(phone string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if texts := o.sent[phone]; len(texts) > 0 {
+		return texts[len(texts)-1]
+	}
+	return ""
+}
+
+// testSetup is what newTestServer options can adjust before the router is built
+type testSetup struct {
+	cfg        *config.Config
+	payments   payment.Provider
+	recognizer anpr.PlateRecognizer
+}
+
+// newTestServer starts a server on the memory store with the stub payment
+// provider and recognizer
+func newTestServer(t *testing.T, options ...func(*testSetup)) *testServer {
+	t.Helper()
+
+	setup := &testSetup{
+		cfg: &config.Config{
+			JWTSecret:            "test-secret",
+			MediaURLSecret:       "test-media-secret",
+			ManagerVenues:        map[string]string{managerPhone: testVenue, otherManagerPhone: otherVenue},
+			PlateCountry:         "IN",
+			MaxUploadBytes:       1 << 20,
+			IdempotencyTTL:       time.Hour,
+			PaymentWebhookSecret: webhookSecret,
+		},
+		payments:   payment.StubProvider{},
+		recognizer: anpr.StubRecognizer{},
+	}
+	for _, option := range options {
+		option(setup)
+	}
+	blobStore, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("local media store: %v", err)
+	}
+
+	sms := &smsOutbox{sent: map[string][]string{}}
+	return &testServer{
+		t:      t,
+		router: setupRouter(setup.cfg, store.NewMemory(), blobStore, setup.recognizer, sms, setup.payments),
+		sms:    sms,
+	}
+}
+
+// do sends a request with an optional bearer token and JSON body
+func (s *testServer) do(method, path, token string, body any) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.doWithHeader(method, path, token, body, nil)
+}
+
+// doWithHeader is do with extra request headers
+func (s *testServer) doWithHeader(method, path, token string, body any, header http.Header) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("encode %s %s body: %v", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// call sends a request and fails the test unless it gets the wanted status,
+// returning the decoded JSON object
+func (s *testServer) call(method, path, token string, body any, want int) map[string]any {
+	s.t.Helper()
+
+	w := s.do(method, path, token, body)
+	if w.Code != want {
+		s.t.Fatalf("%s %s: got status %d, want %d: %s", method, path, w.Code, want, w.Body.String())
+	}
+
+	var out map[string]any
+	if strings.HasPrefix(strings.TrimSpace(w.Body.String()), "{") {
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			s.t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return out
+}
+
+// list is call for endpoints that return a JSON array
+func (s *testServer) list(method, path, token string, want int) []map[string]any {
+	s.t.Helper()
+
+	w := s.do(method, path, token, nil)
+	if w.Code != want {
+		s.t.Fatalf("%s %s: got status %d, want %d: %s", method, path, w.Code, want, w.Body.String())
+	}
+
+	var out []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		s.t.Fatalf("%s %s: decode response: %v", method, path, err)
+	}
+	return out
+}
+
+// page fetches one page of a paginated listing, returning its items and the
+// cursor for the next page ("" on the last page)
+func (s *testServer) page(path, token string) ([]map[string]any, string) {
+	s.t.Helper()
+
+	resp := s.call(http.MethodGet, path, token, nil, http.StatusOK)
+	raw, ok := resp["items"].([]any)
+	if !ok {
+		s.t.Fatalf("GET %s: response has no items: %v", path, resp)
+	}
+	items := make([]map[string]any, 0, len(raw))
+	for _, item := range raw {
+		items = append(items, item.(map[string]any))
+	}
+
+	cursor, _ := resp["next_cursor"].(string)
+	if hasMore := resp["has_more"] == true; hasMore != (cursor != "") {
+		s.t.Fatalf("GET %s: has_more %v with next_cursor %q", path, resp["has_more"], cursor)
+	}
+	return items, cursor
+}
+
+// sessionOf returns the session inside a {session, vehicle, ...} response
+func sessionOf(t *testing.T, resp map[string]any) map[string]any {
+	t.Helper()
+
+	session, ok := resp["session"].(map[string]any)
+	if !ok {
+		t.Fatalf("response has no session: %v", resp)
+	}
+	return session
+}
+
+// login signs in through send-otp and verify-otp, returning the token and user ID
+// login signs a user in. Valets are then added to venue by its manager;
+// managers already manage the venue configured for their phone.
+func (s *testServer) login(phone, role, name, venue string) (string, string) {
+	s.t.Helper()
+
+	sent := s.call(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": phone, "role": role}, http.StatusOK)
+	verified := s.call(http.MethodPost, "/api/auth/verify-otp", "", gin.H{
+		"phone": phone,
+		"otp":   sent["otp"],
+		"name":  name,
+	}, http.StatusOK)
+
+	user := verified["user"].(map[string]any)
+	if user["role"] != role {
+		s.t.Fatalf("login %s: got role %v, want %s", phone, user["role"], role)
+	}
+	if role == "manager" && user["venue_name"] != venue {
+		s.t.Fatalf("login %s: manages %v, want %s", phone, user["venue_name"], venue)
+	}
+	if role == "valet" && venue != "" {
+		managers := map[string]string{testVenue: managerPhone, otherVenue: otherManagerPhone}
+		manager, _ := s.login(managers[venue], "manager", "Manager", venue)
+		s.call(http.MethodPost, "/api/venue/valets", manager, gin.H{"phone": phone}, http.StatusOK)
+	}
+	return verified["token"].(string), user["id"].(string)
+}
+
+func TestFullValetJourney(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000001", "customer", "Asha", "")
+	valet, valetID := s.login("+919800000002", "valet", "Ravi", testVenue)
+
+	// Customer registers a car; the first one becomes the default
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "mh 12 ab 1234",
+		"make":                "Maruti",
+		"model":               "Swift",
+		"color":               "White",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	vehicleID := vehicle["id"].(string)
+	if vehicle["registration_number"] != "MH 12 AB 1234" || vehicle["is_default"] != true {
+		t.Fatalf("unexpected vehicle: %v", vehicle)
+	}
+
+	if vehicles := s.list(http.MethodGet, "/api/vehicles", customer, http.StatusOK); len(vehicles) != 1 {
+		t.Fatalf("got %d vehicles, want 1", len(vehicles))
+	}
+
+	// Valet finds the car at the kerb by its plate
+	found := s.call(http.MethodGet, "/api/vehicles/search?registration_number=MH12AB1234", valet, nil, http.StatusOK)
+	owner := found["owner"].(map[string]any)
+	if owner["id"] != customerID {
+		t.Fatalf("search found owner %v, want %s", owner["id"], customerID)
+	}
+
+	// Valet checks the car in; the customer has to accept it
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicleID,
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	sessionID := session["id"].(string)
+	if session["status"] != "pending" || session["venue_name"] != testVenue || session["valet_id"] != valetID {
+		t.Fatalf("unexpected session: %v", session)
+	}
+
+	// A car can only be checked in once
+	s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicleID,
+		"customer_id": customerID,
+	}, http.StatusConflict)
+
+	active := sessionOf(t, s.call(http.MethodGet, "/api/sessions/active", customer, nil, http.StatusOK))
+	if active["id"] != sessionID {
+		t.Fatalf("customer active session %v, want %s", active["id"], sessionID)
+	}
+
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/accept", customer, gin.H{}, http.StatusOK)
+
+	// Valet drives the car to a bay; the keys must be tagged before it counts as parked
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "parking_moving"}, http.StatusOK)
+	untagged := s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "parked"}, http.StatusConflict)
+	if untagged["code"] != "KEY_TAG_REQUIRED" {
+		t.Fatalf("parked without a key tag: %v", untagged)
+	}
+	s.tagKeys(valet, sessionID, "K7")
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "parked", "parking_spot": "B2"}, http.StatusOK)
+
+	parked := sessionOf(t, s.call(http.MethodGet, "/api/sessions/"+sessionID, customer, nil, http.StatusOK))
+	if parked["status"] != "parked" || parked["parking_spot"] != "B2" {
+		t.Fatalf("unexpected parked session: %v", parked)
+	}
+
+	// A parked car cannot be delivered before pickup is requested
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/verify-delivery", valet, gin.H{"otp": "000000"}, http.StatusBadRequest)
+
+	// Customer asks for the car back
+	pickup := s.call(http.MethodPost, "/api/sessions/"+sessionID+"/request-pickup", customer, nil, http.StatusOK)
+	otp := pickup["pickup_otp"].(string)
+
+	pending, _ := s.page("/api/sessions/pending-pickups", valet)
+	if len(pending) != 1 || sessionOf(t, pending[0])["id"] != sessionID {
+		t.Fatalf("unexpected pending pickups: %v", pending)
+	}
+	if u, ok := pending[0]["customer"].(map[string]any); !ok || u["id"] != customerID {
+		t.Fatalf("pending pickup customer %v, want %s", pending[0]["customer"], customerID)
+	}
+
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "moving"}, http.StatusOK)
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "available"}, http.StatusOK)
+
+	// Delivery needs the customer's OTP
+	wrong := "000000"
+	if otp == wrong {
+		wrong = "111111"
+	}
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/verify-delivery", valet, gin.H{"otp": wrong}, http.StatusUnauthorized)
+
+	// ...and the valet confirms the keys being handed over
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/verify-delivery", valet, gin.H{"otp": otp}, http.StatusBadRequest)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/verify-delivery", valet, gin.H{"otp": otp, "key_tag_number": "K8"}, http.StatusConflict)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/verify-delivery", valet, gin.H{"otp": otp, "key_tag_number": "k7"}, http.StatusOK)
+
+	delivered := sessionOf(t, s.call(http.MethodGet, "/api/sessions/"+sessionID, customer, nil, http.StatusOK))
+	if delivered["status"] != "delivered" || delivered["pickup_otp"] != nil {
+		t.Fatalf("unexpected delivered session: %v", delivered)
+	}
+
+	s.call(http.MethodGet, "/api/sessions/active", customer, nil, http.StatusNotFound)
+	s.call(http.MethodGet, "/api/sessions/active", valet, nil, http.StatusNotFound)
+
+	for _, token := range []string{customer, valet} {
+		history, _ := s.page("/api/sessions/history", token)
+		if len(history) != 1 || sessionOf(t, history[0])["id"] != sessionID {
+			t.Fatalf("unexpected history: %v", history)
+		}
+		if v, ok := history[0]["vehicle"].(map[string]any); !ok || v["id"] != vehicleID {
+			t.Fatalf("history vehicle %v, want %s", history[0]["vehicle"], vehicleID)
+		}
+		if u, ok := history[0]["valet"].(map[string]any); !ok || u["id"] != valetID {
+			t.Fatalf("history valet %v, want %s", history[0]["valet"], valetID)
+		}
+	}
+}
+
+func TestVenueWithoutKeyTags(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000111", "customer", "Asha", "")
+	valet, _ := s.login("+919800000112", "valet", "Ravi", testVenue)
+	manager, _ := s.login(managerPhone, "manager", "Meera", testVenue)
+
+	settings := s.call(http.MethodGet, "/api/venue/settings", manager, nil, http.StatusOK)
+	if settings["require_key_tag"] != true {
+		t.Fatalf("key tags not required by default: %v", settings)
+	}
+	s.call(http.MethodPut, "/api/venue/settings", manager, gin.H{"require_key_tag": false}, http.StatusOK)
+
+	// Venues that opt out park and deliver cars without a key tag
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "MH12NK0001",
+		"make":                "Maruti",
+		"model":               "Swift",
+		"color":               "White",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	path := "/api/sessions/" + session["id"].(string)
+	s.call(http.MethodPost, path+"/accept", customer, gin.H{}, http.StatusOK)
+	s.call(http.MethodPut, path+"/status", valet, gin.H{"status": "parked"}, http.StatusOK)
+	pickup := s.call(http.MethodPost, path+"/request-pickup", customer, nil, http.StatusOK)
+	s.call(http.MethodPost, path+"/verify-delivery", valet, gin.H{"otp": pickup["pickup_otp"]}, http.StatusOK)
+}
+
+// tagKeys adds a key tag to the valet's venue and puts the session's keys on it
+func (s *testServer) tagKeys(valet, sessionID, number string) {
+	s.t.Helper()
+
+	s.call(http.MethodPost, "/api/key-tags", valet, gin.H{"number": number, "location": "Board A"}, http.StatusCreated)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/key", valet, gin.H{"number": number}, http.StatusOK)
+}
+
+// deliveredSession checks a new car in for the customer and hands it back,
+// returning the session ID. The keys are tagged with the registration number.
+func (s *testServer) deliveredSession(customer, customerID, valet, registration string) string {
+	s.t.Helper()
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": registration,
+		"make":                "Hyundai",
+		"model":               "Creta",
+		"color":               "Blue",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	sessionID := session["id"].(string)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/accept", customer, gin.H{}, http.StatusOK)
+	s.tagKeys(valet, sessionID, registration)
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "parked"}, http.StatusOK)
+	pickup := s.call(http.MethodPost, "/api/sessions/"+sessionID+"/request-pickup", customer, nil, http.StatusOK)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/verify-delivery", valet, gin.H{
+		"otp":            pickup["pickup_otp"],
+		"key_tag_number": registration,
+	}, http.StatusOK)
+	return sessionID
+}
+
+// webhook posts a payment confirmation signed with secret
+func (s *testServer) webhook(secret string, confirmation payment.Confirmation, want int) map[string]any {
+	s.t.Helper()
+
+	body, err := json.Marshal(confirmation)
+	if err != nil {
+		s.t.Fatalf("encode confirmation: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/payments/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Payment-Signature", payment.Sign([]byte(secret), body))
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	var resp map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != want {
+		s.t.Fatalf("payment webhook: got status %d, want %d: %s", w.Code, want, w.Body.String())
+	}
+	return resp
+}
+
+func TestTips(t *testing.T) {
+	s := newTestServer(t, func(setup *testSetup) {
+		setup.payments = payment.StubProvider{Status: payment.StatusPending}
+	})
+
+	customer, customerID := s.login("+919800000071", "customer", "Asha", "")
+	valet, _ := s.login("+919800000072", "valet", "Ravi", testVenue)
+	manager, _ := s.login(managerPhone, "manager", "Meera", testVenue)
+	sessionID := s.deliveredSession(customer, customerID, valet, "MH12TP0001")
+	tipsPath := "/api/sessions/" + sessionID + "/tips"
+
+	// A valet records cash once per session, and managers can see it in the audit log
+	s.call(http.MethodPost, tipsPath, valet, gin.H{"amount": 5000}, http.StatusCreated)
+	s.call(http.MethodPost, tipsPath, valet, gin.H{"amount": 5000}, http.StatusConflict)
+	audit := s.list(http.MethodGet, "/api/venue/audit-logs?action=tip.cash_recorded", manager, http.StatusOK)
+	if len(audit) != 1 {
+		t.Fatalf("unexpected audit log: %v", audit)
+	}
+
+	// Online tips wait for the provider to confirm them
+	tip := s.call(http.MethodPost, tipsPath, customer, gin.H{"amount": 10000}, http.StatusCreated)
+	if tip["status"] != "pending" {
+		t.Fatalf("online tip status %v, want pending", tip["status"])
+	}
+	tipID := tip["id"].(string)
+
+	captured := payment.Confirmation{Reference: tipID, ProviderRef: "pay_1", Status: payment.StatusCaptured}
+	s.webhook("wrong-secret", captured, http.StatusUnauthorized)
+	s.webhook(webhookSecret, payment.Confirmation{Reference: primitive.NewObjectID().Hex(), Status: payment.StatusCaptured}, http.StatusNotFound)
+
+	settled := s.webhook(webhookSecret, captured, http.StatusOK)
+	if settled["status"] != "captured" || settled["payment_ref"] != "pay_1" {
+		t.Fatalf("unexpected settled tip: %v", settled)
+	}
+
+	// Redelivery is harmless; a contradicting confirmation is refused
+	s.webhook(webhookSecret, captured, http.StatusOK)
+	s.webhook(webhookSecret, payment.Confirmation{Reference: tipID, Status: payment.StatusFailed}, http.StatusConflict)
+
+	tips := s.list(http.MethodGet, tipsPath, customer, http.StatusOK)
+	if len(tips) != 2 || tips[1]["status"] != "captured" {
+		t.Fatalf("unexpected tips: %v", tips)
+	}
+}
+
+// failingPayments answers every charge with err
+type failingPayments struct{ err error }
+
+func (p failingPayments) Charge(ctx context.Context, charge payment.Charge) (payment.Result, error) {
+	return payment.Result{}, p.err
+}
+
+func TestTipChargeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		err    error
+		want   int
+		status string
+	}{
+		{"declined", payment.ErrDeclined, http.StatusPaymentRequired, "failed"},
+		{"outcome unknown", errors.New("gateway timeout"), http.StatusAccepted, "pending"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t, func(setup *testSetup) { setup.payments = failingPayments{tc.err} })
+
+			customer, customerID := s.login("+919800000076", "customer", "Asha", "")
+			valet, _ := s.login("+919800000077", "valet", "Ravi", testVenue)
+			tipsPath := "/api/sessions/" + s.deliveredSession(customer, customerID, valet, "MH12TP0003") + "/tips"
+
+			s.call(http.MethodPost, tipsPath, customer, gin.H{"amount": 10000}, tc.want)
+			tips := s.list(http.MethodGet, tipsPath, customer, http.StatusOK)
+			if len(tips) != 1 || tips[0]["status"] != tc.status {
+				t.Fatalf("unexpected tips: %v", tips)
+			}
+			if tc.status != "pending" {
+				return
+			}
+
+			// The webhook still settles a tip whose charge went unanswered
+			settled := s.webhook(webhookSecret, payment.Confirmation{Reference: tips[0]["id"].(string), ProviderRef: "pay_2", Status: payment.StatusCaptured}, http.StatusOK)
+			if settled["status"] != "captured" {
+				t.Fatalf("unexpected settled tip: %v", settled)
+			}
+		})
+	}
+}
+
+func TestOnlineTipsNeedAPaymentProvider(t *testing.T) {
+	s := newTestServer(t, func(setup *testSetup) { setup.payments = nil })
+
+	customer, customerID := s.login("+919800000073", "customer", "Asha", "")
+	valet, _ := s.login("+919800000074", "valet", "Ravi", testVenue)
+	sessionID := s.deliveredSession(customer, customerID, valet, "MH12TP0002")
+
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/tips", customer, gin.H{"amount": 10000}, http.StatusServiceUnavailable)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/tips", valet, gin.H{"amount": 5000}, http.StatusCreated)
+}
+
+// recognize posts a kerbside photo to the plate recognition endpoint, with
+// an Idempotency-Key that uploads ignore
+func (s *testServer) recognize(token string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("photo", "kerb.jpg")
+	if err != nil {
+		s.t.Fatalf("photo form: %v", err)
+	}
+	part.Write([]byte("not really a photo"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/vehicles/recognize", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", "kerb-1")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPlateRecognitionNeedsAProvider(t *testing.T) {
+	s := newTestServer(t)
+	valet, _ := s.login("+919800000075", "valet", "Ravi", testVenue)
+	for range 2 {
+		w := s.recognize(valet)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"candidates"`) || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Fatalf("recognize with the stub: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	s = newTestServer(t, func(setup *testSetup) { setup.recognizer = nil })
+	valet, _ = s.login("+919800000075", "valet", "Ravi", testVenue)
+	w := s.recognize(valet)
+	var problem map[string]any
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	if w.Code != http.StatusServiceUnavailable || problem["code"] != "RECOGNITION_UNAVAILABLE" {
+		t.Fatalf("recognize without a provider: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// uploadPhoto uploads a small PNG as media of kind and returns its ID
+func (s *testServer) uploadPhoto(token, kind string) string {
+	s.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("kind", kind)
+	part, err := form.CreateFormFile("file", "photo.png")
+	if err != nil {
+		s.t.Fatalf("photo form: %v", err)
+	}
+	if err := png.Encode(part, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		s.t.Fatalf("encode photo: %v", err)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/media", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	var uploaded map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &uploaded); err != nil || w.Code != http.StatusCreated {
+		s.t.Fatalf("upload photo: %d %s", w.Code, w.Body.String())
+	}
+	return uploaded["media"].(map[string]any)["id"].(string)
+}
+
+func TestStaffSeeOnlyTheirVenuesMedia(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000086", "customer", "Asha", "")
+	valet, _ := s.login("+919800000087", "valet", "Ravi", testVenue)
+	manager, _ := s.login(managerPhone, "manager", "Meera", testVenue)
+	elsewhere, _ := s.login("+919800000088", "valet", "Sunil", otherVenue)
+	otherManager, _ := s.login(otherManagerPhone, "manager", "Nina", otherVenue)
+
+	photo := s.uploadPhoto(customer, "vehicle")
+	photoPath := "/api/media/" + photo
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "MH12MD0001",
+		"make":                "Hyundai",
+		"model":               "Creta",
+		"color":               "Blue",
+		"vehicle_type":        "car",
+		"photos":              []string{photo},
+	}, http.StatusCreated)
+
+	// Staff see a car's photos only once it has been parked at their venue
+	s.call(http.MethodGet, photoPath, customer, nil, http.StatusOK)
+	s.call(http.MethodGet, photoPath, valet, nil, http.StatusNotFound)
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	s.call(http.MethodGet, photoPath, valet, nil, http.StatusOK)
+	s.call(http.MethodGet, photoPath, manager, nil, http.StatusOK)
+	s.call(http.MethodGet, photoPath, elsewhere, nil, http.StatusNotFound)
+	s.call(http.MethodGet, photoPath, otherManager, nil, http.StatusNotFound)
+
+	// Incident photos follow the session they were filed on
+	s.call(http.MethodPost, "/api/sessions/"+session["id"].(string)+"/accept", customer, gin.H{}, http.StatusOK)
+	evidence := s.uploadPhoto(customer, "incident")
+	s.call(http.MethodPost, "/api/sessions/"+session["id"].(string)+"/incidents", customer, gin.H{
+		"type":        "damage",
+		"severity":    "low",
+		"description": "Scratch on the rear bumper",
+		"photos":      []string{evidence},
+	}, http.StatusCreated)
+	s.call(http.MethodGet, "/api/media/"+evidence, manager, nil, http.StatusOK)
+	s.call(http.MethodGet, "/api/media/"+evidence, otherManager, nil, http.StatusNotFound)
+}
+
+func TestSharedVehiclePickupOTP(t *testing.T) {
+	s := newTestServer(t)
+
+	owner, ownerID := s.login("+919800000081", "customer", "Asha", "")
+	viewer, _ := s.login("+919800000082", "customer", "Kiran", "")
+	driver, _ := s.login("+919800000083", "customer", "Dev", "")
+	valet, _ := s.login("+919800000084", "valet", "Ravi", testVenue)
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", owner, gin.H{
+		"registration_number": "MH12SH0001",
+		"make":                "Tata",
+		"model":               "Nexon",
+		"color":               "Red",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	vehicleID := vehicle["id"].(string)
+
+	// Shares are stored in E.164 however the number was typed
+	shared := s.call(http.MethodPost, "/api/vehicles/"+vehicleID+"/shares", owner, gin.H{"phone": "98000 00082"}, http.StatusOK)
+	if shares := shared["shared_with"].([]any); shares[0].(map[string]any)["phone"] != "+919800000082" {
+		t.Fatalf("share phone %v, want +919800000082", shares[0])
+	}
+	s.call(http.MethodPost, "/api/vehicles/"+vehicleID+"/shares", owner, gin.H{"phone": "+91-98000-00083", "permissions": []string{"request_pickup"}}, http.StatusOK)
+	s.call(http.MethodPost, "/api/vehicles/"+vehicleID+"/shares", owner, gin.H{"phone": "098000 00081"}, http.StatusBadRequest)
+	s.call(http.MethodPost, "/api/vehicles/"+vehicleID+"/shares", owner, gin.H{"phone": "not a phone"}, http.StatusBadRequest)
+	if list := s.list(http.MethodGet, "/api/vehicles/shared", viewer, http.StatusOK); len(list) != 1 {
+		t.Fatalf("viewer sees %d shared vehicles, want 1", len(list))
+	}
+
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{"vehicle_id": vehicleID, "customer_id": ownerID}, http.StatusCreated)
+	sessionID := session["id"].(string)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/accept", owner, gin.H{}, http.StatusOK)
+	s.tagKeys(valet, sessionID, "S1")
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "parked"}, http.StatusOK)
+	otp := s.call(http.MethodPost, "/api/sessions/"+sessionID+"/request-pickup", owner, nil, http.StatusOK)["pickup_otp"]
+
+	// Only the owner and shares allowed to collect the car see the OTP
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  any
+	}{
+		{"owner", owner, otp},
+		{"pickup share", driver, otp},
+		{"view-only share", viewer, nil},
+		{"valet", valet, nil},
+	} {
+		if got := sessionOf(t, s.call(http.MethodGet, "/api/sessions/"+sessionID, tc.token, nil, http.StatusOK))["pickup_otp"]; got != tc.want {
+			t.Errorf("%s: session pickup_otp %v, want %v", tc.name, got, tc.want)
+		}
+		if got := sessionOf(t, s.call(http.MethodGet, "/api/sessions/active", tc.token, nil, http.StatusOK))["pickup_otp"]; got != tc.want {
+			t.Errorf("%s: active session pickup_otp %v, want %v", tc.name, got, tc.want)
+		}
+		if got := sessionOf(t, s.call(http.MethodGet, "/api/sessions/"+sessionID+"/timeline", tc.token, nil, http.StatusOK))["pickup_otp"]; got != tc.want {
+			t.Errorf("%s: timeline pickup_otp %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	pending, _ := s.page("/api/sessions/pending-pickups", valet)
+	if len(pending) != 1 || sessionOf(t, pending[0])["pickup_otp"] != nil {
+		t.Fatalf("pending pickups show the OTP: %v", pending)
+	}
+
+	// Unsharing matches the stored number however it is typed
+	s.call(http.MethodDelete, "/api/vehicles/"+vehicleID+"/shares/09800000082", owner, nil, http.StatusOK)
+	s.call(http.MethodGet, "/api/sessions/"+sessionID, viewer, nil, http.StatusNotFound)
+}
+
+func TestOwnershipVerification(t *testing.T) {
+	s := newTestServer(t)
+
+	owner, ownerID := s.login("+919800000091", "customer", "Asha", "")
+	rival, _ := s.login("+919800000092", "customer", "Kiran", "")
+	valet, _ := s.login("+919800000093", "valet", "Ravi", testVenue)
+	elsewhere, _ := s.login("+919800000094", "valet", "Sunil", otherVenue)
+
+	car := gin.H{"registration_number": "MH12OW0001", "make": "Kia", "model": "Seltos", "color": "Black", "vehicle_type": "car"}
+	vehicleID := s.call(http.MethodPost, "/api/vehicles", owner, car, http.StatusCreated)["id"].(string)
+	s.call(http.MethodPost, "/api/vehicles", rival, car, http.StatusCreated)
+
+	// The certificate number is checked on the server and never echoed back
+	claimed := s.call(http.MethodPost, "/api/vehicles/"+vehicleID+"/claim", owner, gin.H{"document_number": "RC-1234"}, http.StatusOK)
+	if _, ok := claimed["ownership"].(map[string]any)["document_number"]; ok {
+		t.Fatalf("claim response shows the document number: %v", claimed)
+	}
+	disputes := s.list(http.MethodGet, "/api/vehicles/disputes", valet, http.StatusOK)
+	if len(disputes) != 1 || strings.Contains(fmt.Sprint(disputes), "RC-1234") {
+		t.Fatalf("disputes leak the document number or are missing: %v", disputes)
+	}
+
+	// Only a valet with the car checked in at their venue can verify it
+	verify := "/api/vehicles/" + vehicleID + "/verify-ownership"
+	s.call(http.MethodPost, verify, valet, gin.H{"document_number": "RC-1234"}, http.StatusNotFound)
+	s.call(http.MethodPost, "/api/sessions", valet, gin.H{"vehicle_id": vehicleID, "customer_id": ownerID}, http.StatusCreated)
+	s.call(http.MethodPost, verify, elsewhere, gin.H{"document_number": "RC-1234"}, http.StatusNotFound)
+	s.call(http.MethodPost, verify, valet, gin.H{"document_number": "RC-9999"}, http.StatusConflict)
+
+	verified := s.call(http.MethodPost, verify, valet, gin.H{"document_number": "RC-1234"}, http.StatusOK)
+	if verified["rejected_claims"] != float64(1) || strings.Contains(fmt.Sprint(verified), "RC-1234") {
+		t.Fatalf("unexpected verification: %v", verified)
+	}
+}
+
+func TestManualReleaseFee(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000081", "customer", "Asha", "")
+	valet, _ := s.login("+919800000082", "valet", "Ravi", testVenue)
+	manager, _ := s.login(managerPhone, "manager", "Meera", testVenue)
+	s.call(http.MethodPut, "/api/venue/settings", manager, gin.H{"lost_ticket_fee": 20000, "currency": "INR"}, http.StatusOK)
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "MH12LT0001",
+		"make":                "Honda",
+		"model":               "City",
+		"color":               "Grey",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	sessionID := session["id"].(string)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/accept", customer, gin.H{}, http.StatusOK)
+	s.tagKeys(valet, sessionID, "L1")
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "parked"}, http.StatusOK)
+
+	release := gin.H{
+		"key_tag_number":      "L1",
+		"registration_number": "MH 12 LT 0001",
+		"id_document_type":    "driving_licence",
+		"id_document_number":  "MH1420110012345",
+		"id_holder_name":      "Asha",
+		"reason":              "Customer lost the ticket and phone",
+		"fee_paid":            true,
+	}
+	releasePath := "/api/sessions/" + sessionID + "/manual-release"
+	s.call(http.MethodPost, releasePath, manager, release, http.StatusBadRequest)
+
+	// Released with the fee still owed; the audit entry lands with the release
+	release["fee_paid"] = false
+	release["charge_fee"] = true
+	released := s.call(http.MethodPost, releasePath, manager, release, http.StatusOK)
+	if r := released["manual_release"].(map[string]any); r["fee_status"] != "due" || r["fee_amount"] != float64(20000) {
+		t.Fatalf("unexpected manual release: %v", r)
+	}
+	audit := s.list(http.MethodGet, "/api/venue/audit-logs?action=session.manual_release", manager, http.StatusOK)
+	if len(audit) != 1 || audit[0]["session_id"] != sessionID {
+		t.Fatalf("unexpected audit log: %v", audit)
+	}
+
+	// The fee is collected once
+	feePath := "/api/sessions/" + sessionID + "/release-fee"
+	paid := s.call(http.MethodPost, feePath, manager, nil, http.StatusOK)
+	if r := paid["manual_release"].(map[string]any); r["fee_status"] != "paid" || r["fee_paid_at"] == nil {
+		t.Fatalf("unexpected paid release: %v", r)
+	}
+	s.call(http.MethodPost, feePath, manager, nil, http.StatusConflict)
+	audit = s.list(http.MethodGet, "/api/venue/audit-logs?action=session.release_fee_collected", manager, http.StatusOK)
+	if len(audit) != 1 {
+		t.Fatalf("unexpected fee audit log: %v", audit)
+	}
+
+	// Sessions released without a fee have nothing to collect
+	other := s.deliveredSession(customer, customerID, valet, "MH12LT0002")
+	s.call(http.MethodPost, "/api/sessions/"+other+"/release-fee", manager, nil, http.StatusConflict)
+}
+
+func TestProfileKeepsVenue(t *testing.T) {
+	s := newTestServer(t)
+
+	valet, _ := s.login("+919800000083", "valet", "Ravi", testVenue)
+
+	s.call(http.MethodPut, "/api/auth/profile", valet, gin.H{"name": "Ravi", "venue_name": otherVenue}, http.StatusForbidden)
+	profile := s.call(http.MethodPut, "/api/auth/profile", valet, gin.H{"name": "Ravi K", "venue_name": testVenue}, http.StatusOK)
+	if user := profile["user"].(map[string]any); user["name"] != "Ravi K" || user["venue_name"] != testVenue {
+		t.Fatalf("unexpected profile: %v", user)
+	}
+}
+
+func TestManagersAssignValetVenues(t *testing.T) {
+	s := newTestServer(t)
+
+	// Sign-up ignores any venue the client asks for
+	sent := s.call(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": "+919800000085", "role": "valet"}, http.StatusOK)
+	verified := s.call(http.MethodPost, "/api/auth/verify-otp", "", gin.H{
+		"phone":      "+919800000085",
+		"otp":        sent["otp"],
+		"name":       "Ravi",
+		"venue_name": testVenue,
+	}, http.StatusOK)
+	valet := verified["token"].(string)
+	valetID := verified["user"].(map[string]any)["id"].(string)
+	if venue, ok := verified["user"].(map[string]any)["venue_name"]; ok {
+		t.Fatalf("new valet got venue %v", venue)
+	}
+	s.call(http.MethodGet, "/api/venue/settings", valet, nil, http.StatusBadRequest)
+
+	// Managers manage the venue configured for their phone
+	manager, _ := s.login(managerPhone, "manager", "Meera", testVenue)
+	other, _ := s.login(otherManagerPhone, "manager", "Nina", otherVenue)
+
+	s.call(http.MethodPost, "/api/venue/valets", manager, gin.H{"phone": "+919800000099"}, http.StatusNotFound)
+	assigned := s.call(http.MethodPost, "/api/venue/valets", manager, gin.H{"phone": "+919800000085"}, http.StatusOK)
+	if assigned["valet"].(map[string]any)["venue_name"] != testVenue {
+		t.Fatalf("unexpected assignment: %v", assigned)
+	}
+	settings := s.call(http.MethodGet, "/api/venue/settings", valet, nil, http.StatusOK)
+	if settings["name"] != testVenue {
+		t.Fatalf("valet sees venue %v", settings["name"])
+	}
+
+	// Another venue can neither take the valet nor let them go
+	taken := s.call(http.MethodPost, "/api/venue/valets", other, gin.H{"phone": "+919800000085"}, http.StatusConflict)
+	if taken["code"] != "VALET_AT_OTHER_VENUE" {
+		t.Fatalf("unexpected error: %v", taken)
+	}
+	s.call(http.MethodDelete, "/api/venue/valets/"+valetID, other, nil, http.StatusNotFound)
+
+	audit := s.list(http.MethodGet, "/api/venue/audit-logs?action=venue.valet_assigned", manager, http.StatusOK)
+	if len(audit) != 1 || audit[0]["details"].(map[string]any)["valet_id"] != valetID {
+		t.Fatalf("unexpected audit log: %v", audit)
+	}
+
+	// Once let go, the valet can join the other venue
+	s.call(http.MethodDelete, "/api/venue/valets/"+valetID, manager, nil, http.StatusOK)
+	s.call(http.MethodGet, "/api/venue/settings", valet, nil, http.StatusBadRequest)
+	s.call(http.MethodPost, "/api/venue/valets", other, gin.H{"phone": "+919800000085"}, http.StatusOK)
+}
+
+func TestShiftReportHidesRaters(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000091", "customer", "Asha", "")
+	valet, valetID := s.login("+919800000092", "valet", "Ravi", testVenue)
+	manager, _ := s.login(managerPhone, "manager", "Meera", testVenue)
+	sessionID := s.deliveredSession(customer, customerID, valet, "MH12SR0001")
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/rating", customer, gin.H{
+		"score":   1,
+		"tags":    []string{"rude"},
+		"comment": "Scratched the bumper",
+	}, http.StatusCreated)
+
+	lowRating := func(report map[string]any) map[string]any {
+		t.Helper()
+		low := report["ratings"].(map[string]any)["low"].([]any)
+		if len(low) != 1 {
+			t.Fatalf("got %d low ratings, want 1: %v", len(low), report)
+		}
+		return low[0].(map[string]any)
+	}
+
+	own := lowRating(s.call(http.MethodGet, "/api/reports/shift", valet, nil, http.StatusOK))
+	if own["score"] != float64(1) || own["created_at"] == nil || len(own["tags"].([]any)) != 1 {
+		t.Fatalf("unexpected rating for valet: %v", own)
+	}
+	if _, ok := own["customer_id"]; ok {
+		t.Fatalf("valet sees who rated them: %v", own)
+	}
+	if _, ok := own["comment"]; ok {
+		t.Fatalf("valet sees the rating comment: %v", own)
+	}
+
+	full := lowRating(s.call(http.MethodGet, "/api/reports/shift?valet_id="+valetID, manager, nil, http.StatusOK))
+	if full["customer_id"] != customerID || full["comment"] != "Scratched the bumper" {
+		t.Fatalf("unexpected rating for manager: %v", full)
+	}
+
+	report := s.call(http.MethodGet, "/api/reports/ratings", manager, nil, http.StatusOK)
+	if valets := report["valets"].([]any); len(valets) != 1 || valets[0].(map[string]any)["valet_name"] != "Ravi" {
+		t.Fatalf("unexpected rating report: %v", report)
+	}
+}
+
+func TestVehicleLookup(t *testing.T) {
+	s := newTestServer(t)
+
+	regular, regularID := s.login("+919800000101", "customer", "Asha", "")
+	newcomer, _ := s.login("+919800000102", "customer", "Kiran", "")
+	valet, _ := s.login("+919800000103", "valet", "Ravi", testVenue)
+
+	s.deliveredSession(regular, regularID, valet, "MH12LK4321")
+	s.call(http.MethodPost, "/api/vehicles", newcomer, gin.H{
+		"registration_number": "MH12LK9321", "make": "Tata", "model": "Punch", "color": "Grey", "vehicle_type": "car",
+	}, http.StatusCreated)
+
+	// Partial plates only search customers who have used the venue
+	partial := s.call(http.MethodGet, "/api/vehicles/lookup?plate=321", valet, nil, http.StatusOK)
+	results := partial["results"].([]any)
+	if len(results) != 1 || partial["total"] != float64(1) {
+		t.Fatalf("partial lookup: %v", partial)
+	}
+	if r := results[0].(map[string]any); r["match"] != "suffix" || r["visits"] != float64(1) || r["score"] != float64(70+22) {
+		t.Fatalf("unexpected partial match: %v", r)
+	}
+
+	// A full plate finds a first-time customer, and only that exact plate
+	exact := s.call(http.MethodGet, "/api/vehicles/lookup?plate=mh12lk9321", valet, nil, http.StatusOK)
+	results = exact["results"].([]any)
+	if len(results) != 1 || results[0].(map[string]any)["match"] != "exact" {
+		t.Fatalf("exact lookup: %v", exact)
+	}
+}
+
+func TestSessionListPagination(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000041", "customer", "Asha", "")
+	valet, _ := s.login("+919800000042", "valet", "Ravi", testVenue)
+
+	// Check in five cars so listings span several pages
+	var sessions []map[string]any
+	for _, reg := range []string{"MH12AB1001", "MH12AB1002", "MH12AB1003", "MH12AB1004", "MH12AB1005"} {
+		vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+			"registration_number": reg,
+			"make":                "Tata",
+			"model":               "Nexon",
+			"color":               "Blue",
+			"vehicle_type":        "car",
+		}, http.StatusCreated)
+		sessions = append(sessions, s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+			"vehicle_id":  vehicle["id"],
+			"customer_id": customerID,
+		}, http.StatusCreated))
+	}
+
+	// Walking the cursor visits every session exactly once
+	seen := map[string]bool{}
+	path := "/api/sessions/active-all?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		items, cursor := s.page(path, valet)
+		if len(items) > 2 {
+			t.Fatalf("got %d items, want at most 2", len(items))
+		}
+		for _, item := range items {
+			id := sessionOf(t, item)["id"].(string)
+			if seen[id] {
+				t.Fatalf("session %s returned twice", id)
+			}
+			seen[id] = true
+		}
+		if cursor == "" {
+			break
+		}
+		path = "/api/sessions/active-all?limit=2&cursor=" + cursor
+	}
+	if len(seen) != len(sessions) {
+		t.Fatalf("paged through %d sessions, want %d", len(seen), len(sessions))
+	}
+
+	// Filters narrow the listing
+	target := sessions[2]
+	items, cursor := s.page("/api/sessions/active-all?ticket="+target["ticket_number"].(string), valet)
+	if len(items) != 1 || sessionOf(t, items[0])["id"] != target["id"] || cursor != "" {
+		t.Fatalf("ticket filter returned %v", items)
+	}
+	items, _ = s.page("/api/sessions/active-all?vehicle_id="+target["vehicle_id"].(string), valet)
+	if len(items) != 1 || sessionOf(t, items[0])["id"] != target["id"] {
+		t.Fatalf("vehicle filter returned %v", items)
+	}
+	if items, _ = s.page("/api/sessions/active-all?status=parked", valet); len(items) != 0 {
+		t.Fatalf("status filter returned %d sessions, want 0", len(items))
+	}
+	if items, _ = s.page("/api/sessions/active-all?venue=Elsewhere", valet); len(items) != 0 {
+		t.Fatalf("venue filter returned %d sessions, want 0", len(items))
+	}
+	if items, _ = s.page("/api/sessions/pending-pickups?status=delivered", valet); len(items) != 0 {
+		t.Fatalf("out-of-scope status returned %d sessions, want 0", len(items))
+	}
+
+	// Valets only see the sessions of their own venue
+	elsewhere, _ := s.login("+919800000043", "valet", "Sunil", otherVenue)
+	if items, _ = s.page("/api/sessions/active-all", elsewhere); len(items) != 0 {
+		t.Fatalf("valet at another venue sees %d active sessions, want 0", len(items))
+	}
+	if items, _ = s.page("/api/sessions/active-all?venue="+url.QueryEscape(testVenue), elsewhere); len(items) != 0 {
+		t.Fatalf("valet at another venue sees %d active sessions by naming the venue, want 0", len(items))
+	}
+	if items, _ = s.page("/api/sessions/pending-pickups", elsewhere); len(items) != 0 {
+		t.Fatalf("valet at another venue sees %d pending pickups, want 0", len(items))
+	}
+
+	s.call(http.MethodGet, "/api/sessions/active-all?cursor=bogus", valet, nil, http.StatusBadRequest)
+	s.call(http.MethodGet, "/api/sessions/active-all?status=lost", valet, nil, http.StatusBadRequest)
+	s.call(http.MethodGet, "/api/sessions/history?from=yesterday", valet, nil, http.StatusBadRequest)
+	for _, limit := range []string{"0", "101", "-1", "ten"} {
+		s.call(http.MethodGet, "/api/sessions/active-all?limit="+limit, valet, nil, http.StatusBadRequest)
+		s.call(http.MethodGet, "/api/vehicles/lookup?plate=MH12&limit="+limit, valet, nil, http.StatusBadRequest)
+	}
+	s.call(http.MethodGet, "/api/vehicles/lookup?plate=MH12&limit=50", valet, nil, http.StatusOK)
+	if items, _ = s.page("/api/sessions/active-all?limit=100", valet); len(items) != len(sessions) {
+		t.Fatalf("largest page has %d sessions, want %d", len(items), len(sessions))
+	}
+}
+
+// race sends the same request from n goroutines at once and returns the
+// responses in no particular order
+func (s *testServer) race(n int, method, path, token string, body any) []*httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var (
+		start     sync.WaitGroup
+		done      sync.WaitGroup
+		responses = make([]*httptest.ResponseRecorder, n)
+	)
+	start.Add(1)
+	for i := range responses {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			start.Wait()
+			responses[i] = s.do(method, path, token, body)
+		}()
+	}
+	start.Done()
+	done.Wait()
+	return responses
+}
+
+// statusCounts tallies responses by status code
+func statusCounts(responses []*httptest.ResponseRecorder) map[int]int {
+	counts := map[int]int{}
+	for _, w := range responses {
+		counts[w.Code]++
+	}
+	return counts
+}
+
+func TestConcurrentCheckInsCreateOneSession(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000051", "customer", "Asha", "")
+	valet, _ := s.login("+919800000052", "valet", "Ravi", testVenue)
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "KA01AB1234",
+		"make":                "Honda",
+		"model":               "City",
+		"color":               "Grey",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+
+	responses := s.race(20, http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	})
+	if counts := statusCounts(responses); counts[http.StatusCreated] != 1 || counts[http.StatusConflict] != len(responses)-1 {
+		t.Fatalf("concurrent check-ins got statuses %v, want one %d and the rest %d", counts, http.StatusCreated, http.StatusConflict)
+	}
+
+	if items, _ := s.page("/api/sessions/active-all", valet); len(items) != 1 {
+		t.Fatalf("got %d active sessions, want 1", len(items))
+	}
+}
+
+func TestConcurrentPickupAndDeliveryApplyOnce(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000061", "customer", "Asha", "")
+	valet, _ := s.login("+919800000062", "valet", "Ravi", testVenue)
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "KA01AB5678",
+		"make":                "Honda",
+		"model":               "City",
+		"color":               "Grey",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	sessionID := session["id"].(string)
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/accept", customer, gin.H{}, http.StatusOK)
+	s.tagKeys(valet, sessionID, "C1")
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "parked"}, http.StatusOK)
+
+	// Only one pickup request wins, so only one OTP is ever issued
+	var otp string
+	for _, w := range s.race(20, http.MethodPost, "/api/sessions/"+sessionID+"/request-pickup", customer, nil) {
+		switch w.Code {
+		case http.StatusOK:
+			if otp != "" {
+				t.Fatal("more than one pickup request succeeded")
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode pickup response: %v", err)
+			}
+			otp = resp["pickup_otp"].(string)
+		case http.StatusNotFound, http.StatusConflict:
+		default:
+			t.Fatalf("pickup request got status %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if otp == "" {
+		t.Fatal("no pickup request succeeded")
+	}
+
+	// Only one valet hands the car back
+	delivered := 0
+	for _, w := range s.race(20, http.MethodPost, "/api/sessions/"+sessionID+"/verify-delivery", valet, gin.H{"otp": otp, "key_tag_number": "C1"}) {
+		switch w.Code {
+		case http.StatusOK:
+			delivered++
+		case http.StatusBadRequest, http.StatusConflict:
+		default:
+			t.Fatalf("delivery got status %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if delivered != 1 {
+		t.Fatalf("%d deliveries succeeded, want 1", delivered)
+	}
+
+	// The vehicle is free for a new check-in once delivered
+	s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+}
+
+func TestSessionVersionPreconditions(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000071", "customer", "Asha", "")
+	valet, _ := s.login("+919800000072", "valet", "Ravi", testVenue)
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "DL01AB1234",
+		"make":                "Hyundai",
+		"model":               "Creta",
+		"color":               "Red",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	sessionID := session["id"].(string)
+	path := "/api/sessions/" + sessionID
+	if session["version"] != float64(1) {
+		t.Fatalf("new session has version %v, want 1", session["version"])
+	}
+
+	w := s.do(http.MethodGet, path, valet, nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag != `"1"` {
+		t.Fatalf("GET session: status %d, ETag %q", w.Code, etag)
+	}
+	// If-None-Match compares weakly and may list several tags
+	for header, want := range map[string]int{
+		etag:         http.StatusNotModified,
+		`W/"1"`:      http.StatusNotModified,
+		`"0", W/"1"`: http.StatusNotModified,
+		"*":          http.StatusNotModified,
+		`"2"`:        http.StatusOK,
+		"latest":     http.StatusOK,
+	} {
+		if w := s.doWithHeader(http.MethodGet, path, valet, nil, http.Header{"If-None-Match": {header}}); w.Code != want {
+			t.Fatalf("GET with If-None-Match %s: got status %d, want %d", header, w.Code, want)
+		}
+	}
+
+	// Accepting bumps the version, so the valet's copy is now stale
+	w = s.doWithHeader(http.MethodPost, path+"/accept", customer, gin.H{}, http.Header{"If-Match": {etag}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("accept: status %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	// Tagging the keys is conditional too, and bumps the version again
+	s.call(http.MethodPost, "/api/key-tags", valet, gin.H{"number": "V1", "location": "Board A"}, http.StatusCreated)
+	w = s.doWithHeader(http.MethodPost, path+"/key", valet, gin.H{"number": "V1"}, http.Header{"If-Match": {etag}})
+	if w.Code != http.StatusPreconditionFailed || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("key with stale If-Match: status %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	w = s.doWithHeader(http.MethodPost, path+"/key", valet, gin.H{"number": "V1"}, http.Header{"If-Match": {`"2"`}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("key with current If-Match: status %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	stale := http.Header{"If-Match": {etag}}
+	w = s.doWithHeader(http.MethodPut, path+"/status", valet, gin.H{"status": "parked"}, stale)
+	if w.Code != http.StatusPreconditionFailed || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("status with stale If-Match: status %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	if w := s.doWithHeader(http.MethodPut, path+"/status", valet, gin.H{"status": "parked"}, http.Header{"If-Match": {"latest"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("status with malformed If-Match: got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	if w := s.doWithHeader(http.MethodPut, path+"/status", valet, gin.H{"status": "parked"}, http.Header{"If-Match": {`"3", latest`}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("status with a malformed If-Match list: got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	// If-Match compares strongly, so a weak tag never matches, even the current version
+	for _, header := range []string{`W/"3"`, `"1", W/"3"`, `"abc"`} {
+		w = s.doWithHeader(http.MethodPut, path+"/status", valet, gin.H{"status": "parked"}, http.Header{"If-Match": {header}})
+		if w.Code != http.StatusPreconditionFailed || w.Header().Get("ETag") != `"3"` {
+			t.Fatalf("status with If-Match %s: status %d, ETag %q: %s", header, w.Code, w.Header().Get("ETag"), w.Body.String())
+		}
+	}
+
+	// Any strong tag in a list may match
+	w = s.doWithHeader(http.MethodPut, path+"/status", valet, gin.H{"status": "parked"}, http.Header{"If-Match": {`"1", "3"`}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"4"` {
+		t.Fatalf("status with current If-Match: status %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	// Key handoffs are checked the same way
+	handoff := gin.H{"location": "Box 4"}
+	w = s.doWithHeader(http.MethodPost, path+"/key/handoff", valet, handoff, http.Header{"If-Match": {`"3"`}})
+	if w.Code != http.StatusPreconditionFailed || w.Header().Get("ETag") != `"4"` {
+		t.Fatalf("handoff with stale If-Match: status %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	w = s.doWithHeader(http.MethodPost, path+"/key/handoff", valet, handoff, http.Header{"If-Match": {`"4"`}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"5"` {
+		t.Fatalf("handoff with current If-Match: status %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	// Once the customer cancels (and the keys go back), a valet acting on the
+	// parked view gets a conflict
+	s.call(http.MethodPost, path+"/cancel", customer, nil, http.StatusOK)
+	conflict := s.call(http.MethodPut, path+"/status", valet, gin.H{"status": "parked"}, http.StatusConflict)
+	if conflict["code"] != "INVALID_TRANSITION" || conflict["session_status"] != "cancelled" || conflict["version"] != float64(7) {
+		t.Fatalf("unexpected conflict response: %v", conflict)
+	}
+}
+
+func TestIdempotentRetries(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000081", "customer", "Asha", "")
+	valet, _ := s.login("+919800000082", "valet", "Ravi", testVenue)
+	otherValet, _ := s.login("+919800000083", "valet", "Kiran", testVenue)
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "TN01AB1234",
+		"make":                "Kia",
+		"model":               "Seltos",
+		"color":               "Black",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	checkIn := gin.H{"vehicle_id": vehicle["id"], "customer_id": customerID}
+	key := http.Header{"Idempotency-Key": {"check-in-1"}}
+
+	// A retried check-in replays the first response instead of conflicting
+	first := s.doWithHeader(http.MethodPost, "/api/sessions", valet, checkIn, key)
+	retry := s.doWithHeader(http.MethodPost, "/api/sessions", valet, checkIn, key)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated {
+		t.Fatalf("check-in and retry got %d and %d, want %d", first.Code, retry.Code, http.StatusCreated)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry was not replayed: %s", retry.Body.String())
+	}
+	if retry.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Fatalf("replayed ETag %q, want %q", retry.Header().Get("ETag"), first.Header().Get("ETag"))
+	}
+	if items, _ := s.page("/api/sessions/active-all", valet); len(items) != 1 {
+		t.Fatalf("got %d active sessions, want 1", len(items))
+	}
+
+	// Keys are per user, and one key cannot cover a different request
+	if w := s.doWithHeader(http.MethodPost, "/api/sessions", otherValet, checkIn, key); w.Code != http.StatusConflict || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("other valet with the same key got %d: %s", w.Code, w.Body.String())
+	}
+	if w := s.doWithHeader(http.MethodPost, "/api/sessions", valet, gin.H{"vehicle_id": vehicle["id"]}, key); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with a different body got %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+
+	var session map[string]any
+	if err := json.Unmarshal(first.Body.Bytes(), &session); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+	path := "/api/sessions/" + session["id"].(string)
+	s.call(http.MethodPost, path+"/accept", customer, gin.H{}, http.StatusOK)
+	s.tagKeys(valet, session["id"].(string), "I1")
+	s.call(http.MethodPut, path+"/status", valet, gin.H{"status": "parked"}, http.StatusOK)
+
+	// A retried pickup request is replayed rather than issuing a new OTP or
+	// failing, but the OTP is not kept for replays; the session still has it
+	pickupKey := http.Header{"Idempotency-Key": {"pickup-1"}}
+	first = s.doWithHeader(http.MethodPost, path+"/request-pickup", customer, nil, pickupKey)
+	retry = s.doWithHeader(http.MethodPost, path+"/request-pickup", customer, nil, pickupKey)
+	if first.Code != http.StatusOK || retry.Code != http.StatusOK || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("pickup and retry got %d and %d: %s / %s", first.Code, retry.Code, first.Body.String(), retry.Body.String())
+	}
+	var pickup, replayed map[string]any
+	json.Unmarshal(first.Body.Bytes(), &pickup)
+	json.Unmarshal(retry.Body.Bytes(), &replayed)
+	if _, ok := replayed["pickup_otp"]; ok || replayed["expires_at"] != pickup["expires_at"] {
+		t.Fatalf("replayed pickup %v, want %v without the OTP", replayed, pickup)
+	}
+	if otp := sessionOf(t, s.call(http.MethodGet, path, customer, nil, http.StatusOK))["pickup_otp"]; otp != pickup["pickup_otp"] {
+		t.Fatalf("session pickup OTP %v, want %v", otp, pickup["pickup_otp"])
+	}
+
+	// Without a key the request runs again
+	s.call(http.MethodPost, path+"/request-pickup", customer, nil, http.StatusConflict)
+
+	// Bodies are only buffered up to a cap
+	huge := gin.H{"vehicle_id": vehicle["id"], "customer_id": customerID, "notes": strings.Repeat("x", 100<<10)}
+	if w := s.doWithHeader(http.MethodPost, "/api/sessions", valet, huge, http.Header{"Idempotency-Key": {"huge-1"}}); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized body with a key got %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	// Sign-in is never replayed: anonymous callers would share keys, and responses hold tokens
+	otpKey := http.Header{"Idempotency-Key": {"otp-1"}}
+	for range 2 {
+		w := s.doWithHeader(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": "+919800000084", "role": "customer"}, otpKey)
+		if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Fatalf("send-otp with a key: status %d, replayed %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+		}
+	}
+}
+
+func TestCustomerRejectsWrongCheckIn(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, customerID := s.login("+919800000011", "customer", "Asha", "")
+	stranger, _ := s.login("+919800000012", "customer", "Kiran", "")
+	valet, _ := s.login("+919800000013", "valet", "Ravi", testVenue)
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "KA01MN4321",
+		"make":                "Honda",
+		"model":               "City",
+		"color":               "Grey",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	sessionID := session["id"].(string)
+
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/inspections", valet, gin.H{
+		"stage":          "check_in",
+		"damage_markers": []gin.H{{"zone": "front_bumper", "type": "scratch", "x": 0.2, "y": 0.4}},
+	}, http.StatusCreated)
+
+	// Another customer cannot act on the session, nor see its inspection
+	if resp := s.call(http.MethodPost, "/api/sessions/"+sessionID+"/accept", stranger, gin.H{}, http.StatusNotFound); resp["inspection"] != nil {
+		t.Fatalf("stranger was shown the inspection: %v", resp)
+	}
+	if resp := s.call(http.MethodPost, "/api/sessions/"+sessionID+"/accept", customer, gin.H{}, http.StatusBadRequest); resp["inspection"] == nil {
+		t.Fatalf("owner was not shown the inspection: %v", resp)
+	}
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/reject", stranger, gin.H{"reason": "not_my_vehicle"}, http.StatusNotFound)
+
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/reject", customer, gin.H{"reason": "not_my_vehicle"}, http.StatusOK)
+
+	// The rejected session is closed, so accepting or parking it acts on an outdated view
+	s.call(http.MethodPost, "/api/sessions/"+sessionID+"/accept", customer, gin.H{}, http.StatusConflict)
+	s.call(http.MethodPut, "/api/sessions/"+sessionID+"/status", valet, gin.H{"status": "parked"}, http.StatusConflict)
+
+	rejected := sessionOf(t, s.call(http.MethodGet, "/api/sessions/"+sessionID, customer, nil, http.StatusOK))
+	if rejected["status"] != "rejected" {
+		t.Fatalf("got status %v, want rejected", rejected["status"])
+	}
+}
+
+func TestLoginFailures(t *testing.T) {
+	s := newTestServer(t)
+
+	s.call(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": "+919800000021", "role": "admin"}, http.StatusBadRequest)
+	s.call(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": "+919800000021", "role": "manager"}, http.StatusForbidden)
+
+	sent := s.call(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": "+919800000021", "role": "customer"}, http.StatusOK)
+	wrong := "000000"
+	if sent["otp"] == wrong {
+		wrong = "111111"
+	}
+	s.call(http.MethodPost, "/api/auth/verify-otp", "", gin.H{"phone": "+919800000021", "otp": wrong}, http.StatusUnauthorized)
+
+	// An OTP can only be used once
+	s.call(http.MethodPost, "/api/auth/verify-otp", "", gin.H{"phone": "+919800000021", "otp": sent["otp"]}, http.StatusOK)
+	s.call(http.MethodPost, "/api/auth/verify-otp", "", gin.H{"phone": "+919800000021", "otp": sent["otp"]}, http.StatusUnauthorized)
+
+	// Pre-approved phones can sign in as a manager
+	s.login(managerPhone, "manager", "Meera", testVenue)
+
+	s.call(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": "not a phone", "role": "customer"}, http.StatusBadRequest)
+}
+
+func TestPhoneSpellingsShareAnAccount(t *testing.T) {
+	s := newTestServer(t)
+
+	// However the number is typed, it signs in to the same account
+	customer, customerID := s.login("98000 00022", "customer", "Asha", "")
+	_, againID := s.login("+91-98000-00022", "customer", "Asha", "")
+	if againID != customerID {
+		t.Fatalf("second spelling signed in as %s, want %s", againID, customerID)
+	}
+	s.call(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": "090000 00001", "role": "manager"}, http.StatusOK)
+
+	// and valets find the customer's cars by any spelling of it
+	valet, _ := s.login("+919800000023", "valet", "Ravi", testVenue)
+	s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "MH12PH0001", "make": "Tata", "model": "Nexon", "color": "Red", "vehicle_type": "car",
+	}, http.StatusCreated)
+	found := s.call(http.MethodGet, "/api/vehicles/lookup?phone="+url.QueryEscape("098000 00022"), valet, nil, http.StatusOK)
+	if results := found["results"].([]any); len(results) != 1 {
+		t.Fatalf("phone lookup: %v", found)
+	}
+	s.call(http.MethodGet, "/api/vehicles/lookup?phone=abc", valet, nil, http.StatusBadRequest)
+}
+
+func TestProductionKeepsOTPsOutOfResponses(t *testing.T) {
+	s := newTestServer(t, func(setup *testSetup) { setup.cfg.Env = "production" })
+
+	sent := s.call(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": "+919800000022", "role": "customer"}, http.StatusOK)
+	if _, ok := sent["otp"]; ok {
+		t.Fatalf("production send-otp returned the OTP: %v", sent)
+	}
+	if s.sms.last("+919800000022") == "" {
+		t.Fatal("OTP was not texted")
+	}
+
+	if got := notify.Redact("Your code is 482913. Ticket 12"); got != "Your code is ******. Ticket 12" {
+		t.Fatalf("Redact = %q", got)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	s := newTestServer(t)
+
+	customer, _ := s.login("+919800000031", "customer", "Asha", "")
+
+	// Validation failures name the rejected fields instead of leaking the validator's message
+	w := s.do(http.MethodPost, "/api/auth/send-otp", "", gin.H{"role": "customer"})
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("send-otp without phone: status %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var problem map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	fields, _ := problem["fields"].([]any)
+	if problem["code"] != "VALIDATION_FAILED" || problem["status"] != float64(http.StatusBadRequest) ||
+		len(fields) != 1 || fields[0].(map[string]any)["field"] != "phone" ||
+		strings.Contains(w.Body.String(), "Field validation") {
+		t.Fatalf("unexpected validation problem: %s", w.Body.String())
+	}
+
+	missing := "/api/sessions/" + primitive.NewObjectID().Hex()
+	notFound := s.call(http.MethodGet, missing, customer, nil, http.StatusNotFound)
+	if notFound["code"] != "SESSION_NOT_FOUND" || notFound["title"] != "Session not found" ||
+		notFound["type"] != "urn:valet-parking:error:SESSION_NOT_FOUND" || notFound["instance"] != missing {
+		t.Fatalf("unexpected not found problem: %v", notFound)
+	}
+
+	// Titles follow Accept-Language, falling back to English
+	w = s.doWithHeader(http.MethodGet, missing, customer, nil, http.Header{"Accept-Language": {"fr, hi-IN;q=0.8, en;q=0.5"}})
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Language") != "hi" || !strings.Contains(w.Body.String(), "पार्किंग सत्र नहीं मिला") {
+		t.Fatalf("Hindi not found: status %d, Content-Language %q: %s", w.Code, w.Header().Get("Content-Language"), w.Body.String())
+	}
+
+	if resp := s.call(http.MethodGet, "/api/no-such-route", "", nil, http.StatusNotFound); resp["code"] != "ROUTE_NOT_FOUND" {
+		t.Fatalf("unknown route: %v", resp)
+	}
+	if resp := s.call(http.MethodGet, "/api/vehicles", "", nil, http.StatusUnauthorized); resp["code"] != "AUTH_REQUIRED" {
+		t.Fatalf("missing token: %v", resp)
+	}
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	s := newTestServer(t)
+
+	// The sign-in SMS follows Accept-Language before the user has a preference
+	const customerPhone = "+919800000041"
+	w := s.doWithHeader(http.MethodPost, "/api/auth/send-otp", "", gin.H{"phone": customerPhone, "role": "customer"},
+		http.Header{"Accept-Language": {"mr-IN"}})
+	var sent map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &sent); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("send-otp: status %d: %s", w.Code, w.Body.String())
+	}
+	if sms := s.sms.last(customerPhone); !strings.Contains(sms, sent["otp"].(string)) || !strings.Contains(sms, "साइन-इन कोड आहे") {
+		t.Fatalf("unexpected sign-in SMS: %q", sms)
+	}
+	if sent["message"] != "ओटीपी यशस्वीरित्या पाठवला" {
+		t.Fatalf("unexpected send-otp message: %v", sent["message"])
+	}
+
+	customer, customerID := s.login(customerPhone, "customer", "Asha", "")
+	valet, _ := s.login("+919800000042", "valet", "Ravi", testVenue)
+
+	s.call(http.MethodPut, "/api/auth/profile", customer, gin.H{"name": "Asha", "preferred_language": "fr"}, http.StatusBadRequest)
+	profile := s.call(http.MethodPut, "/api/auth/profile", customer, gin.H{"name": "Asha", "preferred_language": "hi"}, http.StatusOK)
+	if profile["message"] != "प्रोफ़ाइल सफलतापूर्वक अपडेट हुई" || profile["user"].(map[string]any)["preferred_language"] != "hi" {
+		t.Fatalf("unexpected profile response: %v", profile)
+	}
+
+	// The fresh token carries the preference, which beats Accept-Language
+	customer = profile["token"].(string)
+	w = s.doWithHeader(http.MethodGet, "/api/sessions/"+primitive.NewObjectID().Hex(), customer, nil, http.Header{"Accept-Language": {"en"}})
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Language") != "hi" {
+		t.Fatalf("not found with preference: status %d, Content-Language %q: %s", w.Code, w.Header().Get("Content-Language"), w.Body.String())
+	}
+
+	vehicle := s.call(http.MethodPost, "/api/vehicles", customer, gin.H{
+		"registration_number": "MH12CD5678",
+		"make":                "Tata",
+		"model":               "Nexon",
+		"color":               "Blue",
+		"vehicle_type":        "car",
+	}, http.StatusCreated)
+	session := s.call(http.MethodPost, "/api/sessions", valet, gin.H{
+		"vehicle_id":  vehicle["id"],
+		"customer_id": customerID,
+	}, http.StatusCreated)
+	path := "/api/sessions/" + session["id"].(string)
+	s.call(http.MethodPost, path+"/accept", customer, gin.H{}, http.StatusOK)
+	s.tagKeys(valet, session["id"].(string), "H1")
+	s.call(http.MethodPut, path+"/status", valet, gin.H{"status": "parked", "parking_spot": "A1"}, http.StatusOK)
+
+	// The pickup OTP is texted in the customer's language, whoever triggers it
+	pickup := s.call(http.MethodPost, path+"/request-pickup", customer, nil, http.StatusOK)
+	if sms := s.sms.last(customerPhone); !strings.Contains(sms, pickup["pickup_otp"].(string)) || !strings.Contains(sms, testVenue+" पर आपकी गाड़ी") {
+		t.Fatalf("unexpected pickup SMS: %q", sms)
+	}
+	s.call(http.MethodPost, path+"/verify-delivery", valet, gin.H{"otp": pickup["pickup_otp"], "key_tag_number": "H1"}, http.StatusOK)
+	if sms := s.sms.last(customerPhone); !strings.Contains(sms, "धन्यवाद") {
+		t.Fatalf("unexpected delivery SMS: %q", sms)
+	}
+}
+
+// routeRoles lists the roles allowed on every authenticated route. An empty
+// list means any signed-in user.
+var routeRoles = map[string][]string{
+	"PUT /api/auth/profile": nil,
+
+	"POST /api/vehicles":                        {"customer"},
+	"GET /api/vehicles":                         {"customer"},
+	"GET /api/vehicles/shared":                  {"customer"},
+	"PUT /api/vehicles/:id":                     {"customer"},
+	"DELETE /api/vehicles/:id":                  {"customer"},
+	"POST /api/vehicles/:id/restore":            {"customer"},
+	"POST /api/vehicles/:id/default":            {"customer"},
+	"POST /api/vehicles/:id/claim":              {"customer"},
+	"POST /api/vehicles/:id/shares":             {"customer"},
+	"DELETE /api/vehicles/:id/shares/:phone":    {"customer"},
+	"GET /api/vehicles/search":                  {"valet"},
+	"GET /api/vehicles/lookup":                  {"valet"},
+	"POST /api/vehicles/recognize":              {"valet"},
+	"GET /api/vehicles/disputes":                {"valet"},
+	"POST /api/vehicles/:id/verify-ownership":   {"valet"},
+	"POST /api/media":                           nil,
+	"POST /api/media/uploads":                   nil,
+	"GET /api/media/:id":                        nil,
+	"GET /api/venue/settings":                   {"valet", "manager"},
+	"PUT /api/venue/settings":                   {"manager"},
+	"GET /api/venue/audit-logs":                 {"manager"},
+	"POST /api/venue/valets":                    {"manager"},
+	"DELETE /api/venue/valets/:id":              {"manager"},
+	"GET /api/key-tags":                         {"valet"},
+	"POST /api/key-tags":                        {"valet"},
+	"PUT /api/key-tags/:id":                     {"valet"},
+	"GET /api/reports/rejections":               {"manager"},
+	"GET /api/reports/ratings":                  {"manager"},
+	"GET /api/reports/shift":                    {"valet", "manager"},
+	"GET /api/reports/tips":                     {"valet", "manager"},
+	"GET /api/incidents":                        {"manager"},
+	"GET /api/incidents/:id":                    {"manager"},
+	"PUT /api/incidents/:id":                    {"manager"},
+	"GET /api/alerts":                           {"manager"},
+	"POST /api/alerts/:id/acknowledge":          {"manager"},
+	"POST /api/sessions":                        {"valet"},
+	"GET /api/sessions/active":                  nil,
+	"GET /api/sessions/:id":                     nil,
+	"POST /api/sessions/:id/request-pickup":     {"customer"},
+	"POST /api/sessions/:id/accept":             {"customer"},
+	"POST /api/sessions/:id/reject":             {"customer"},
+	"POST /api/sessions/:id/regenerate-otp":     {"customer"},
+	"POST /api/sessions/:id/cancel-pickup":      {"customer"},
+	"POST /api/sessions/:id/cancel":             {"customer"},
+	"POST /api/sessions/:id/verify-delivery":    {"valet"},
+	"POST /api/sessions/:id/manual-release":     {"manager"},
+	"POST /api/sessions/:id/release-fee":        {"manager"},
+	"POST /api/sessions/:id/rating":             {"customer"},
+	"GET /api/sessions/:id/rating":              nil,
+	"POST /api/sessions/:id/tips":               {"customer", "valet"},
+	"GET /api/sessions/:id/tips":                nil,
+	"POST /api/sessions/:id/incidents":          {"customer", "valet"},
+	"GET /api/sessions/:id/incidents":           nil,
+	"GET /api/sessions/:id/timeline":            nil,
+	"PUT /api/sessions/:id/status":              {"valet"},
+	"POST /api/sessions/:id/key":                {"valet"},
+	"POST /api/sessions/:id/key/handoff":        {"valet"},
+	"POST /api/sessions/:id/inspections":        {"valet"},
+	"GET /api/sessions/:id/inspections":         nil,
+	"GET /api/sessions/:id/inspections/compare": nil,
+	"GET /api/sessions/pending-pickups":         {"valet"},
+	"GET /api/sessions/active-all":              {"valet"},
+	"GET /api/sessions/history":                 nil,
+}
+
+// publicRoutes need no bearer token. Signed media URLs and payment webhooks
+// are refused without a valid signature instead.
+var publicRoutes = map[string]int{
+	"POST /api/payments/webhook":        http.StatusUnauthorized,
+	"GET /health":                       http.StatusOK,
+	"POST /api/auth/send-otp":           http.StatusBadRequest,
+	"POST /api/auth/verify-otp":         http.StatusBadRequest,
+	"PUT /api/media/uploads/:id":        http.StatusForbidden,
+	"GET /api/media/files/:id/:variant": http.StatusForbidden,
+}
+
+// concretePath fills in route parameters with well-formed values
+func concretePath(route string) string {
+	replacer := strings.NewReplacer(
+		":id", primitive.NewObjectID().Hex(),
+		":phone", "+919800000099",
+		":variant", "full",
+	)
+	return replacer.Replace(route)
+}
+
+func TestRouteAuthorization(t *testing.T) {
+	s := newTestServer(t)
+
+	tokens := map[string]string{}
+	tokens["customer"], _ = s.login("+919800000031", "customer", "Asha", "")
+	tokens["valet"], _ = s.login("+919800000032", "valet", "Ravi", testVenue)
+	tokens["manager"], _ = s.login(managerPhone, "manager", "Meera", testVenue)
+
+	for _, route := range s.router.Routes() {
+		key := route.Method + " " + route.Path
+		path := concretePath(route.Path)
+
+		if want, ok := publicRoutes[key]; ok {
+			t.Run(key, func(t *testing.T) {
+				s.t = t
+				s.call(route.Method, path, "", nil, want)
+			})
+			continue
+		}
+
+		allowed, ok := routeRoles[key]
+		if !ok {
+			t.Errorf("%s is not listed in routeRoles; add it with the roles allowed to call it", key)
+			continue
+		}
+
+		t.Run(key, func(t *testing.T) {
+			s.t = t
+
+			s.call(route.Method, path, "", nil, http.StatusUnauthorized)
+			s.call(route.Method, path, "not-a-jwt", nil, http.StatusUnauthorized)
+
+			if len(allowed) == 0 {
+				return
+			}
+			for role, token := range tokens {
+				if contains(allowed, role) {
+					continue
+				}
+				s.call(route.Method, path, token, nil, http.StatusForbidden)
+			}
+		})
+	}
+
+	// Every listed route must still exist
+	registered := map[string]bool{}
+	for _, route := range s.router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for key := range routeRoles {
+		if !registered[key] {
+			t.Errorf("routeRoles lists %s, which is not registered", key)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
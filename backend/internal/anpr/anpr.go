@@ -0,0 +1,21 @@
+// Package anpr recognizes registration plates in photos.
+package anpr
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoPlate = errors.New("no plate found in image")
+
+// Candidate is one possible reading of a plate in the image
+type Candidate struct {
+	Plate      string  `json:"plate"`
+	Confidence float64 `json:"confidence"` // 0 to 1
+}
+
+// PlateRecognizer reads registration plates from an image. Candidates are
+// returned best first.
+type PlateRecognizer interface {
+	Recognize(ctx context.Context, image []byte) ([]Candidate, error)
+}
@@ -0,0 +1,117 @@
+package anpr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HTTPRecognizer calls an external ANPR service. It speaks the widely used
+// Plate Recognizer style API: the image is POSTed as the multipart "upload"
+// field with a "Token" authorization header, and the response lists results
+// with a plate, a score and alternative candidates.
+type HTTPRecognizer struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPRecognizer(url, token string) *HTTPRecognizer {
+	return &HTTPRecognizer{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type httpResponse struct {
+	Results []struct {
+		Plate      string  `json:"plate"`
+		Score      float64 `json:"score"`
+		Candidates []struct {
+			Plate string  `json:"plate"`
+			Score float64 `json:"score"`
+		} `json:"candidates"`
+	} `json:"results"`
+}
+
+func (r *HTTPRecognizer) Recognize(ctx context.Context, image []byte) ([]Candidate, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("upload", "snapshot.jpg")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(image); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if r.token != "" {
+		req.Header.Set("Authorization", "Token "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("anpr service returned %s: %s", resp.Status, msg)
+	}
+
+	var parsed httpResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("anpr service response: %w", err)
+	}
+
+	// Flatten every result and its alternatives, keeping the best score per plate
+	best := make(map[string]float64)
+	for _, result := range parsed.Results {
+		add(best, result.Plate, result.Score)
+		for _, alt := range result.Candidates {
+			add(best, alt.Plate, alt.Score)
+		}
+	}
+	if len(best) == 0 {
+		return nil, ErrNoPlate
+	}
+
+	candidates := make([]Candidate, 0, len(best))
+	for p, score := range best {
+		candidates = append(candidates, Candidate{Plate: p, Confidence: score})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Plate < candidates[j].Plate
+	})
+	return candidates, nil
+}
+
+func add(best map[string]float64, plate string, score float64) {
+	plate = strings.ToUpper(strings.TrimSpace(plate))
+	if plate == "" {
+		return
+	}
+	if score > best[plate] {
+		best[plate] = score
+	}
+}
@@ -0,0 +1,55 @@
+package anpr
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// StubRecognizer returns plausible Indian plates derived from a hash of the
+// image, so the same photo always yields the same candidates. Use it for
+// development and tests where no ANPR service is available. Plates listed in
+// Fixed are returned as-is instead, for scripted demos.
+type StubRecognizer struct {
+	Fixed []string
+}
+
+var stubStates = []string{"MH", "KA", "DL", "TN", "GJ", "UP", "WB", "TS"}
+
+func (s StubRecognizer) Recognize(ctx context.Context, image []byte) ([]Candidate, error) {
+	if len(image) == 0 {
+		return nil, ErrNoPlate
+	}
+
+	if len(s.Fixed) > 0 {
+		candidates := make([]Candidate, 0, len(s.Fixed))
+		for i, p := range s.Fixed {
+			candidates = append(candidates, Candidate{Plate: p, Confidence: 0.95 - 0.1*float64(i)})
+		}
+		return candidates, nil
+	}
+
+	sum := sha256.Sum256(image)
+	state := stubStates[int(sum[0])%len(stubStates)]
+	district := int(sum[1])%99 + 1
+	series := string(rune('A'+sum[2]%26)) + string(rune('A'+sum[3]%26))
+	number := (int(sum[4])<<8 | int(sum[5])) % 10000
+
+	top := fmt.Sprintf("%s%02d%s%04d", state, district, series, number)
+
+	// Runner-up readings mimic common OCR confusions
+	return []Candidate{
+		{Plate: top, Confidence: 0.91},
+		{Plate: confuse(top), Confidence: 0.62},
+		{Plate: top[:len(top)-1] + fmt.Sprint((number+1)%10), Confidence: 0.35},
+	}, nil
+}
+
+func confuse(plate string) string {
+	r := strings.NewReplacer("0", "O", "8", "B", "1", "I", "5", "S")
+	if confused := r.Replace(plate[4:]); confused != plate[4:] {
+		return plate[:4] + confused
+	}
+	return plate[:4] + strings.NewReplacer("O", "0", "B", "8", "I", "1", "S", "5").Replace(plate[4:])
+}
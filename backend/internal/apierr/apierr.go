@@ -0,0 +1,177 @@
+// Package apierr is the API's error model. Every error response is an RFC
+// 7807 problem document carrying a stable machine-readable code, a title in
+// the caller's language and, where useful, a more specific English detail.
+package apierr
+
+import (
+	"valet-parking-backend/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code identifies a kind of error. Codes are part of the API contract:
+// clients branch on them, so they never change once published.
+type Code string
+
+const (
+	InvalidRequest      Code = "INVALID_REQUEST"
+	ValidationFailed    Code = "VALIDATION_FAILED"
+	InvalidID           Code = "INVALID_ID"
+	InvalidRegistration Code = "INVALID_REGISTRATION"
+	InvalidUpload       Code = "INVALID_UPLOAD"
+	AuthRequired        Code = "AUTH_REQUIRED"
+	TokenInvalid        Code = "TOKEN_INVALID"
+	Forbidden           Code = "FORBIDDEN"
+	NotAManager         Code = "NOT_A_MANAGER"
+	VenueNotAssigned    Code = "VENUE_NOT_ASSIGNED"
+	OTPInvalid          Code = "OTP_INVALID"
+	OTPExpired          Code = "OTP_EXPIRED"
+	RouteNotFound       Code = "ROUTE_NOT_FOUND"
+	MethodNotAllowed    Code = "METHOD_NOT_ALLOWED"
+	Internal            Code = "INTERNAL"
+
+	SessionNotFound           Code = "SESSION_NOT_FOUND"
+	NoActiveSession           Code = "NO_ACTIVE_SESSION"
+	ActiveSessionExists       Code = "ACTIVE_SESSION_EXISTS"
+	InvalidTransition         Code = "INVALID_TRANSITION"
+	PickupNotRequested        Code = "PICKUP_NOT_REQUESTED"
+	PreconditionFailed        Code = "PRECONDITION_FAILED"
+	ConcurrentUpdate          Code = "CONCURRENT_UPDATE"
+	InspectionNotAcknowledged Code = "INSPECTION_NOT_ACKNOWLEDGED"
+	InspectionNotFound        Code = "INSPECTION_NOT_FOUND"
+	InspectionExists          Code = "INSPECTION_EXISTS"
+	AlreadyRated              Code = "ALREADY_RATED"
+	RatingNotFound            Code = "RATING_NOT_FOUND"
+	LostTicketFeeNotSet       Code = "LOST_TICKET_FEE_NOT_SET"
+	ReleaseFeeNotDue          Code = "RELEASE_FEE_NOT_DUE"
+
+	VehicleNotFound          Code = "VEHICLE_NOT_FOUND"
+	VehicleAlreadyRegistered Code = "VEHICLE_ALREADY_REGISTERED"
+	VehicleArchived          Code = "VEHICLE_ARCHIVED"
+	VehicleInSession         Code = "VEHICLE_IN_SESSION"
+	RegistrationMismatch     Code = "REGISTRATION_MISMATCH"
+	ShareNotFound            Code = "SHARE_NOT_FOUND"
+	OwnershipAlreadyVerified Code = "OWNERSHIP_ALREADY_VERIFIED"
+	OwnershipRejected        Code = "OWNERSHIP_REJECTED"
+	DocumentMismatch         Code = "DOCUMENT_MISMATCH"
+	UserNotFound             Code = "USER_NOT_FOUND"
+	ValetNotFound            Code = "VALET_NOT_FOUND"
+	ValetAtOtherVenue        Code = "VALET_AT_OTHER_VENUE"
+
+	KeyTagNotFound        Code = "KEY_TAG_NOT_FOUND"
+	KeyTagExists          Code = "KEY_TAG_EXISTS"
+	KeyTagUnavailable     Code = "KEY_TAG_UNAVAILABLE"
+	KeyTagInUse           Code = "KEY_TAG_IN_USE"
+	KeyTagAlreadyAssigned Code = "KEY_TAG_ALREADY_ASSIGNED"
+	KeyTagMismatch        Code = "KEY_TAG_MISMATCH"
+	KeyReturnRequired     Code = "KEY_RETURN_REQUIRED"
+	NoKeyTag              Code = "NO_KEY_TAG"
+	KeyTagRequired        Code = "KEY_TAG_REQUIRED"
+
+	MediaNotFound          Code = "MEDIA_NOT_FOUND"
+	UploadNotFound         Code = "UPLOAD_NOT_FOUND"
+	SignedURLInvalid       Code = "SIGNED_URL_INVALID"
+	FileTooLarge           Code = "FILE_TOO_LARGE"
+	RequestTooLarge        Code = "REQUEST_TOO_LARGE"
+	UnsupportedMediaType   Code = "UNSUPPORTED_MEDIA_TYPE"
+	PlateNotFound          Code = "PLATE_NOT_FOUND"
+	RecognitionUnavailable Code = "RECOGNITION_UNAVAILABLE"
+
+	IncidentNotFound     Code = "INCIDENT_NOT_FOUND"
+	IncidentWindowClosed Code = "INCIDENT_WINDOW_CLOSED"
+	AlertNotFound        Code = "ALERT_NOT_FOUND"
+	PaymentDeclined      Code = "PAYMENT_DECLINED"
+	PaymentUnavailable   Code = "PAYMENT_UNAVAILABLE"
+	SignatureInvalid     Code = "SIGNATURE_INVALID"
+	TipNotFound          Code = "TIP_NOT_FOUND"
+	CashTipExists        Code = "CASH_TIP_EXISTS"
+
+	IdempotencyKeyInUse  Code = "IDEMPOTENCY_KEY_IN_USE"
+	IdempotencyKeyReused Code = "IDEMPOTENCY_KEY_REUSED"
+)
+
+// ContentType is the media type of problem documents
+const ContentType = "application/problem+json"
+
+// typePrefix turns a code into the problem type URI
+const typePrefix = "urn:valet-parking:error:"
+
+// Error is an API error: the HTTP status, the code, an optional English
+// detail specific to this occurrence and extra members for the problem
+// document
+type Error struct {
+	Status int
+	Code   Code
+	Detail string
+	Extra  map[string]any
+}
+
+// New returns an error with the given status, code and detail
+func New(status int, code Code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return string(e.Code) + ": " + e.Detail
+	}
+	return string(e.Code)
+}
+
+// With adds a member to the problem document
+func (e *Error) With(key string, value any) *Error {
+	if e.Extra == nil {
+		e.Extra = map[string]any{}
+	}
+	e.Extra[key] = value
+	return e
+}
+
+// Abort responds with a problem document and stops the handler chain
+func Abort(c *gin.Context, status int, code Code, detail string) {
+	AbortWith(c, New(status, code, detail))
+}
+
+// AbortWith responds with err and stops the handler chain. The response is
+// written straight away so middleware that records responses sees it.
+func AbortWith(c *gin.Context, err *Error) {
+	_ = c.Error(err)
+	Render(c, err)
+	c.Abort()
+}
+
+// Render writes err as a problem document in the caller's language. The
+// legacy "error" member carries the detail, or the title without one, for
+// clients that predate problem documents.
+func Render(c *gin.Context, err *Error) {
+	lang := Language(c)
+	title := i18n.Message(lang, string(err.Code))
+
+	body := gin.H{}
+	for key, value := range err.Extra {
+		body[key] = value
+	}
+	body["type"] = typePrefix + string(err.Code)
+	body["title"] = title
+	body["status"] = err.Status
+	body["code"] = err.Code
+	body["instance"] = c.Request.URL.Path
+	body["error"] = title
+	// A detail that only repeats the English title adds nothing
+	if err.Detail != "" && err.Detail != i18n.Message(i18n.English, string(err.Code)) {
+		body["detail"] = err.Detail
+		if lang == i18n.English {
+			body["error"] = err.Detail
+		}
+	}
+
+	c.Header("Content-Type", ContentType)
+	c.Header("Content-Language", string(lang))
+	c.JSON(err.Status, body)
+}
+
+// Language is the language to answer the request in: the signed-in user's
+// preferred language, or else the one negotiated from Accept-Language
+func Language(c *gin.Context) i18n.Lang {
+	return i18n.Resolve(c.GetString("lang"), c.GetHeader("Accept-Language"))
+}
@@ -0,0 +1,60 @@
+package apierr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report fields by the names clients send, not the Go struct field names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// FieldError is one rejected field in a validation problem
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// BindError responds to a request body that failed to bind. Validation
+// failures list the rejected fields and rules instead of the validator's
+// internal message.
+func BindError(c *gin.Context, err error) {
+	var invalid validator.ValidationErrors
+	var mistyped *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &invalid):
+		fields := make([]FieldError, 0, len(invalid))
+		names := make([]string, 0, len(invalid))
+		for _, fe := range invalid {
+			fields = append(fields, FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param()})
+			names = append(names, fe.Field())
+		}
+		AbortWith(c, New(http.StatusBadRequest, ValidationFailed,
+			"Invalid or missing: "+strings.Join(names, ", ")).With("fields", fields))
+	case errors.As(err, &mistyped):
+		AbortWith(c, New(http.StatusBadRequest, ValidationFailed,
+			"Wrong type for: "+mistyped.Field).With("fields", []FieldError{{Field: mistyped.Field, Rule: "type", Param: mistyped.Type.String()}}))
+	default:
+		Abort(c, http.StatusBadRequest, InvalidRequest, "Request body must be valid JSON")
+	}
+}
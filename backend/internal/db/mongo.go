@@ -59,3 +59,47 @@ func (m *MongoDB) Sessions() *mongo.Collection {
 func (m *MongoDB) OTPs() *mongo.Collection {
 	return m.Database.Collection("otps")
 }
+
+func (m *MongoDB) PlateDisputes() *mongo.Collection {
+	return m.Database.Collection("plate_disputes")
+}
+
+func (m *MongoDB) Venues() *mongo.Collection {
+	return m.Database.Collection("venues")
+}
+
+func (m *MongoDB) Inspections() *mongo.Collection {
+	return m.Database.Collection("inspections")
+}
+
+func (m *MongoDB) Media() *mongo.Collection {
+	return m.Database.Collection("media")
+}
+
+func (m *MongoDB) KeyTags() *mongo.Collection {
+	return m.Database.Collection("key_tags")
+}
+
+func (m *MongoDB) AuditLogs() *mongo.Collection {
+	return m.Database.Collection("audit_logs")
+}
+
+func (m *MongoDB) Ratings() *mongo.Collection {
+	return m.Database.Collection("ratings")
+}
+
+func (m *MongoDB) Alerts() *mongo.Collection {
+	return m.Database.Collection("alerts")
+}
+
+func (m *MongoDB) Tips() *mongo.Collection {
+	return m.Database.Collection("tips")
+}
+
+func (m *MongoDB) Incidents() *mongo.Collection {
+	return m.Database.Collection("incidents")
+}
+
+func (m *MongoDB) IdempotencyKeys() *mongo.Collection {
+	return m.Database.Collection("idempotency_keys")
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/i18n"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AlertHandler struct {
+	db *store.Store
+}
+
+func NewAlertHandler(database *store.Store) *AlertHandler {
+	return &AlertHandler{db: database}
+}
+
+// alertMessage renders an alert's message template in lang
+func alertMessage(lang i18n.Lang, alert models.Alert) string {
+	return i18n.Format(lang, "ALERT_"+strings.ToUpper(string(alert.Type)), alert.Params)
+}
+
+// raiseAlert queues an alert for the venue's managers. Failures are logged so
+// they never fail the request that triggered the alert.
+func raiseAlert(ctx context.Context, database *store.Store, alert models.Alert) {
+	alert.ID = primitive.NewObjectID()
+	alert.Message = alertMessage(i18n.English, alert)
+	alert.CreatedAt = time.Now()
+	if err := database.Alerts().Insert(ctx, alert); err != nil {
+		log.Printf("alerts: failed to raise %s alert for %s: %v", alert.Type, alert.VenueName, err)
+	}
+}
+
+// ListAlerts returns alerts for the caller's venue, open ones only unless ?all=true (manager only)
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	filter := store.AlertFilter{
+		VenueName: venueName,
+		Type:      models.AlertType(c.Query("type")),
+		OpenOnly:  c.Query("all") != "true",
+	}
+
+	alerts, err := h.db.Alerts().Find(ctx, filter, 100)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch alerts")
+		return
+	}
+
+	if alerts == nil {
+		alerts = []models.Alert{}
+	}
+
+	// Alerts raised before messages were templated only have their English text
+	lang := apierr.Language(c)
+	for i := range alerts {
+		if alerts[i].Params != nil {
+			alerts[i].Message = alertMessage(lang, alerts[i])
+		}
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// AcknowledgeAlert marks an alert as handled (manager only)
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	alertObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid alert ID")
+		return
+	}
+
+	managerID, _ := c.Get("user_id")
+	managerObjID, _ := primitive.ObjectIDFromHex(managerID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	found, err := h.db.Alerts().Acknowledge(ctx, alertObjID, venueName, managerObjID, time.Now())
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to acknowledge alert")
+		return
+	}
+	if !found {
+		apierr.Abort(c, http.StatusNotFound, apierr.AlertNotFound, "Alert not found or already acknowledged")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localized(c, "ALERT_ACKNOWLEDGED")})
+}
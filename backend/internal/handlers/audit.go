@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditHandler struct {
+	db *store.Store
+}
+
+func NewAuditHandler(database *store.Store) *AuditHandler {
+	return &AuditHandler{db: database}
+}
+
+// auditEntry builds an audit log entry on behalf of the calling user
+func auditEntry(c *gin.Context, action models.AuditAction, venueName string, sessionID *primitive.ObjectID, details map[string]interface{}) models.AuditLog {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	actorID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	return models.AuditLog{
+		ID:        primitive.NewObjectID(),
+		Action:    action,
+		ActorID:   actorID,
+		ActorRole: models.Role(role.(string)),
+		VenueName: venueName,
+		SessionID: sessionID,
+		Details:   details,
+		IP:        c.ClientIP(),
+		CreatedAt: time.Now(),
+	}
+}
+
+// recordAudit appends an entry to the audit log on behalf of the calling user.
+// Failures are logged rather than returned so they never undo the audited
+// action; actions that must not happen unaudited insert auditEntry inside
+// their transaction instead.
+func recordAudit(ctx context.Context, database *store.Store, c *gin.Context, action models.AuditAction, venueName string, sessionID *primitive.ObjectID, details map[string]interface{}) {
+	entry := auditEntry(c, action, venueName, sessionID, details)
+	if err := database.AuditLogs().Insert(ctx, entry); err != nil {
+		log.Printf("audit: failed to record %s by %s: %v", action, entry.ActorID.Hex(), err)
+	}
+}
+
+// ListAuditLogs returns the most recent audit entries for the caller's venue (manager only)
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	filter := store.AuditLogFilter{
+		VenueName: venueName,
+		Action:    models.AuditAction(c.Query("action")),
+	}
+	if sessionID := c.Query("session_id"); sessionID != "" {
+		sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+			return
+		}
+		filter.SessionID = sessionObjID
+	}
+
+	limit, ok := queryLimit(c, 50, 200)
+	if !ok {
+		return
+	}
+
+	entries, err := h.db.AuditLogs().Find(ctx, filter, int64(limit))
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch audit logs")
+		return
+	}
+
+	if entries == nil {
+		entries = []models.AuditLog{}
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -3,30 +3,46 @@ package handlers
 import (
 	"context"
 	"fmt"
-	"log"
 	"math/rand"
 	"net/http"
 	"time"
 
-	"valet-parking-backend/internal/db"
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/i18n"
 	"valet-parking-backend/internal/middleware"
 	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/notify"
+	"valet-parking-backend/internal/phone"
+	"valet-parking-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AuthHandler struct {
-	db        *db.MongoDB
-	jwtSecret string
+	db            *store.Store
+	jwtSecret     string
+	managerVenues map[string]string // Phone of each manager and the venue they manage
+	sms           notify.Sender
+	echoOTP       bool // Return the OTP in the response; never in production
 }
 
-func NewAuthHandler(database *db.MongoDB, jwtSecret string) *AuthHandler {
+func NewAuthHandler(database *store.Store, jwtSecret string, managerVenues map[string]string, sms notify.Sender, echoOTP bool) *AuthHandler {
+	// Configured phones are matched the way sign-ins are, in E.164 form
+	venues := make(map[string]string, len(managerVenues))
+	for p, venue := range managerVenues {
+		if normalized, err := phone.Normalize(p); err == nil {
+			p = normalized
+		}
+		venues[p] = venue
+	}
 	return &AuthHandler{
-		db:        database,
-		jwtSecret: jwtSecret,
+		db:            database,
+		jwtSecret:     jwtSecret,
+		managerVenues: venues,
+		sms:           sms,
+		echoOTP:       echoOTP,
 	}
 }
 
@@ -36,22 +52,33 @@ type SendOTPRequest struct {
 }
 
 type VerifyOTPRequest struct {
-	Phone     string `json:"phone" binding:"required"`
-	OTP       string `json:"otp" binding:"required"`
-	Name      string `json:"name"`
-	VenueName string `json:"venue_name"` // Only for valets
+	Phone    string `json:"phone" binding:"required"`
+	OTP      string `json:"otp" binding:"required"`
+	Name     string `json:"name"`
+	Language string `json:"preferred_language"`
 }
 
 func (h *AuthHandler) SendOTP(c *gin.Context) {
 	var req SendOTPRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierr.BindError(c, err)
+		return
+	}
+	if !normalizePhone(c, &req.Phone) {
 		return
 	}
 
 	// Validate role
-	if req.Role != string(models.RoleCustomer) && req.Role != string(models.RoleValet) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be 'customer' or 'valet'"})
+	switch models.Role(req.Role) {
+	case models.RoleCustomer, models.RoleValet:
+	case models.RoleManager:
+		// Managers can authorize overrides, so only pre-approved phones may sign in as one
+		if _, ok := h.managerVenues[req.Phone]; !ok {
+			apierr.Abort(c, http.StatusForbidden, apierr.NotAManager, "This phone number is not registered as a venue manager")
+			return
+		}
+	default:
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid role. Must be 'customer', 'valet' or 'manager'")
 		return
 	}
 
@@ -63,7 +90,7 @@ func (h *AuthHandler) SendOTP(c *gin.Context) {
 	defer cancel()
 
 	// Delete any existing OTP for this phone
-	_, _ = h.db.OTPs().DeleteMany(ctx, bson.M{"phone": req.Phone})
+	_ = h.db.OTPs().DeleteByPhone(ctx, req.Phone)
 
 	// Insert new OTP
 	otpDoc := models.OTPStore{
@@ -73,27 +100,35 @@ func (h *AuthHandler) SendOTP(c *gin.Context) {
 		ExpiresAt: time.Now().Add(5 * time.Minute),
 	}
 
-	_, err := h.db.OTPs().InsertOne(ctx, otpDoc)
+	err := h.db.OTPs().Insert(ctx, otpDoc)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to generate OTP")
 		return
 	}
 
-	// Log OTP to console (for MVP - no actual SMS)
-	log.Printf("========================================")
-	log.Printf("OTP for %s: %s", req.Phone, otp)
-	log.Printf("========================================")
+	// Text the OTP in the language the account prefers, or else the one the app asked for
+	user, _ := h.db.Users().FindOne(ctx, store.UserFilter{Phone: req.Phone, Role: models.Role(req.Role)})
+	lang := i18n.Resolve(user.PreferredLanguage, c.GetHeader("Accept-Language"))
+	sendSMS(ctx, h.sms, req.Phone, lang, "SMS_LOGIN_OTP", map[string]string{"otp": otp})
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "OTP sent successfully",
-		"otp":     otp, // Include OTP in response for MVP testing
-	})
+	response := gin.H{"message": localized(c, "OTP_SENT")}
+	if h.echoOTP {
+		response["otp"] = otp // Lets development clients sign in without a gateway
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 	var req VerifyOTPRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierr.BindError(c, err)
+		return
+	}
+	if !normalizePhone(c, &req.Phone) {
+		return
+	}
+
+	if !validLanguage(c, req.Language) {
 		return
 	}
 
@@ -101,83 +136,123 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 	defer cancel()
 
 	// Find OTP
-	var otpDoc models.OTPStore
-	err := h.db.OTPs().FindOne(ctx, bson.M{
-		"phone": req.Phone,
-		"otp":   req.OTP,
-	}).Decode(&otpDoc)
-
+	otpDoc, err := h.db.OTPs().FindByCode(ctx, req.Phone, req.OTP)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP"})
+		apierr.Abort(c, http.StatusUnauthorized, apierr.OTPInvalid, "Invalid OTP")
 		return
 	}
 
 	// Check if OTP expired
 	if time.Now().After(otpDoc.ExpiresAt) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP expired"})
+		apierr.Abort(c, http.StatusUnauthorized, apierr.OTPExpired, "OTP expired")
 		return
 	}
 
 	// Delete used OTP
-	_, _ = h.db.OTPs().DeleteOne(ctx, bson.M{"_id": otpDoc.ID})
+	_ = h.db.OTPs().Delete(ctx, otpDoc.ID)
 
 	// Find or create user
-	var user models.User
-	err = h.db.Users().FindOne(ctx, bson.M{
-		"phone": req.Phone,
-		"role":  otpDoc.Role,
-	}).Decode(&user)
+	user, err := h.db.Users().FindOne(ctx, store.UserFilter{
+		Phone: req.Phone,
+		Role:  otpDoc.Role,
+	})
+	// Managers manage the venue configured for their phone; valets start
+	// without one until a manager assigns them
+	var venueName string
+	if otpDoc.Role == models.RoleManager {
+		venueName = h.managerVenues[req.Phone]
+	}
 
 	if err != nil {
 		// Create new user
 		user = models.User{
-			ID:        primitive.NewObjectID(),
-			Phone:     req.Phone,
-			Name:      req.Name,
-			Role:      otpDoc.Role,
-			VenueName: req.VenueName, // Only used for valets
-			CreatedAt: time.Now(),
+			ID:                primitive.NewObjectID(),
+			Phone:             req.Phone,
+			Name:              req.Name,
+			Role:              otpDoc.Role,
+			VenueName:         venueName,
+			PreferredLanguage: req.Language,
+			CreatedAt:         time.Now(),
 		}
-		_, err = h.db.Users().InsertOne(ctx, user)
+		err = h.db.Users().Insert(ctx, user)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to create user")
+			return
+		}
+	} else if user.Role == models.RoleManager && user.VenueName != venueName {
+		// The configuration moved this manager to another venue
+		if err := h.db.Users().SetVenue(ctx, user.ID, venueName); err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update user")
 			return
 		}
+		user.VenueName = venueName
+	}
+
+	tokenString, err := h.issueToken(user)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to generate token")
+		return
 	}
 
-	// Generate JWT
+	c.JSON(http.StatusOK, gin.H{
+		"token": tokenString,
+		"user":  user,
+	})
+}
+
+// issueToken signs a 30-day JWT for user. The user's preferred language
+// travels in the token so requests are answered in it without a lookup.
+func (h *AuthHandler) issueToken(user models.User) (string, error) {
 	claims := middleware.Claims{
 		UserID: user.ID.Hex(),
 		Phone:  user.Phone,
 		Role:   string(user.Role),
+		Lang:   user.PreferredLanguage,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour * 30)), // 30 days
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.jwtSecret))
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(h.jwtSecret))
+// normalizePhone rewrites a sign-in phone to E.164, so every spelling of a
+// number reaches the same account, writing a 400 and returning false if it
+// is not a phone number
+func normalizePhone(c *gin.Context, p *string) bool {
+	normalized, err := phone.Normalize(*p)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-		return
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid phone number")
+		return false
 	}
+	*p = normalized
+	return true
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": tokenString,
-		"user":  user,
-	})
+// validLanguage checks an optional preferred language, writing a 400 and
+// returning false if it is not one the catalog has
+func validLanguage(c *gin.Context, lang string) bool {
+	if lang != "" && !i18n.Supported(i18n.Lang(lang)) {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid preferred language. Must be 'en', 'hi' or 'mr'")
+		return false
+	}
+	return true
 }
 
 type UpdateProfileRequest struct {
 	Name      string `json:"name" binding:"required"`
-	VenueName string `json:"venue_name"`
+	VenueName string `json:"venue_name"` // Accepted only if unchanged; venues are not self-service
+	Language  string `json:"preferred_language"`
 }
 
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierr.BindError(c, err)
+		return
+	}
+
+	if !validLanguage(c, req.Language) {
 		return
 	}
 
@@ -187,35 +262,43 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Build update document
-	updateDoc := bson.M{
-		"name": req.Name,
+	// A valet moving themselves to another venue would get its sessions and
+	// keys, so the venue cannot be changed here
+	current, err := h.db.Users().FindByID(ctx, userObjID)
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.UserNotFound, "User not found")
+		return
 	}
-	if req.VenueName != "" {
-		updateDoc["venue_name"] = req.VenueName
+	if req.VenueName != "" && req.VenueName != current.VenueName {
+		apierr.Abort(c, http.StatusForbidden, apierr.Forbidden, "Venue cannot be changed from the profile")
+		return
 	}
 
-	// Update user
-	_, err := h.db.Users().UpdateOne(ctx,
-		bson.M{"_id": userObjID},
-		bson.M{"$set": updateDoc},
-	)
-
+	// Update user; the language is kept unless a new one is given
+	err = h.db.Users().UpdateProfile(ctx, userObjID, req.Name, req.Language)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update profile")
 		return
 	}
 
 	// Get updated user
-	var user models.User
-	err = h.db.Users().FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user)
+	user, err := h.db.Users().FindByID(ctx, userObjID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get updated profile")
+		return
+	}
+
+	// The old token carries the old language, so hand out a fresh one
+	tokenString, err := h.issueToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get updated profile"})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to generate token")
 		return
 	}
+	c.Set("lang", user.PreferredLanguage)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Profile updated successfully",
+		"message": localized(c, "PROFILE_UPDATED"),
+		"token":   tokenString,
 		"user":    user,
 	})
 }
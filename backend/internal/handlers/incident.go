@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// incidentWindow is how long after delivery an incident can still be filed
+const incidentWindow = 7 * 24 * time.Hour
+
+type IncidentHandler struct {
+	db *store.Store
+}
+
+func NewIncidentHandler(database *store.Store) *IncidentHandler {
+	return &IncidentHandler{db: database}
+}
+
+var (
+	incidentTypes = map[models.IncidentType]bool{
+		models.IncidentDamage:      true,
+		models.IncidentMissingItem: true,
+		models.IncidentMisuse:      true,
+		models.IncidentDelay:       true,
+		models.IncidentOther:       true,
+	}
+	incidentSeverities = map[models.IncidentSeverity]bool{
+		models.SeverityLow:      true,
+		models.SeverityMedium:   true,
+		models.SeverityHigh:     true,
+		models.SeverityCritical: true,
+	}
+	// Triage moves an incident forward; closed incidents can be reopened for investigation
+	incidentTransitions = map[models.IncidentStatus][]models.IncidentStatus{
+		models.IncidentOpen:          {models.IncidentInvestigating, models.IncidentResolved, models.IncidentRejected},
+		models.IncidentInvestigating: {models.IncidentResolved, models.IncidentRejected},
+		models.IncidentResolved:      {models.IncidentInvestigating},
+		models.IncidentRejected:      {models.IncidentInvestigating},
+	}
+)
+
+type FileIncidentRequest struct {
+	Type        string   `json:"type" binding:"required"`
+	Severity    string   `json:"severity"` // Defaults to medium
+	Description string   `json:"description" binding:"required"`
+	Photos      []string `json:"photos"`
+}
+
+// FileIncident records a complaint or damage claim against a session.
+// Customers and valets can file; the venue's managers are alerted.
+func (h *IncidentHandler) FileIncident(c *gin.Context) {
+	var req FileIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	incidentType := models.IncidentType(req.Type)
+	if !incidentTypes[incidentType] {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid type. Must be: damage, missing_item, misuse, delay, or other")
+		return
+	}
+	severity := models.SeverityMedium
+	if req.Severity != "" {
+		severity = models.IncidentSeverity(req.Severity)
+		if !incidentSeverities[severity] {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid severity. Must be: low, medium, high, or critical")
+			return
+		}
+	}
+	description := strings.TrimSpace(req.Description)
+	if len(description) < 10 || len(description) > 2000 {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Description must be between 10 and 2000 characters")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	// Only cars the valets actually handled can have incidents
+	if session.Status == models.StatusPending || session.Status == models.StatusRejected {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidTransition, "Cannot file an incident on a session that was never accepted")
+		return
+	}
+	if session.DeliveredAt != nil && time.Since(*session.DeliveredAt) > incidentWindow {
+		apierr.Abort(c, http.StatusBadRequest, apierr.IncidentWindowClosed, "Incidents must be filed within 7 days of delivery")
+		return
+	}
+
+	if !checkMediaRefs(ctx, h.db, c, userObjID, models.MediaKindIncident, req.Photos) {
+		return
+	}
+
+	// Link the inspections so triage can compare condition at check-in and check-out
+	var inspectionIDs []primitive.ObjectID
+	if inspections, err := h.db.Inspections().ListBySession(ctx, session.ID); err == nil {
+		for _, i := range inspections {
+			inspectionIDs = append(inspectionIDs, i.ID)
+		}
+	}
+
+	now := time.Now()
+	incident := models.Incident{
+		ID:            primitive.NewObjectID(),
+		SessionID:     session.ID,
+		VehicleID:     session.VehicleID,
+		VenueName:     session.VenueName,
+		Type:          incidentType,
+		Severity:      severity,
+		Status:        models.IncidentOpen,
+		Description:   description,
+		Photos:        req.Photos,
+		InspectionIDs: inspectionIDs,
+		ReportedBy:    userObjID,
+		ReporterRole:  models.Role(role.(string)),
+		History: []models.IncidentEvent{{
+			Status: models.IncidentOpen,
+			By:     userObjID,
+			At:     now,
+		}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.db.Incidents().Insert(ctx, incident); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to file incident")
+		return
+	}
+
+	raiseAlert(ctx, h.db, models.Alert{
+		VenueName: session.VenueName,
+		Type:      models.AlertIncident,
+		Params: map[string]string{
+			"severity": string(severity),
+			"type":     string(incidentType),
+			"ticket":   session.TicketNumber,
+		},
+		SessionID: &session.ID,
+		RefID:     &incident.ID,
+	})
+
+	c.JSON(http.StatusCreated, incident)
+}
+
+// ListSessionIncidents returns the incidents filed against a session
+func (h *IncidentHandler) ListSessionIncidents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	h.listIncidents(ctx, c, store.IncidentFilter{SessionID: session.ID})
+}
+
+// ListIncidents returns incidents at the caller's venue, filtered by
+// ?status and ?severity (manager only)
+func (h *IncidentHandler) ListIncidents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	h.listIncidents(ctx, c, store.IncidentFilter{
+		VenueName: venueName,
+		Status:    models.IncidentStatus(c.Query("status")),
+		Severity:  models.IncidentSeverity(c.Query("severity")),
+	})
+}
+
+func (h *IncidentHandler) listIncidents(ctx context.Context, c *gin.Context, filter store.IncidentFilter) {
+	incidents, err := h.db.Incidents().Find(ctx, filter, 100)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch incidents")
+		return
+	}
+
+	if incidents == nil {
+		incidents = []models.Incident{}
+	}
+
+	c.JSON(http.StatusOK, incidents)
+}
+
+// findVenueIncident loads an incident at the caller's venue
+func (h *IncidentHandler) findVenueIncident(ctx context.Context, c *gin.Context) (models.Incident, bool) {
+	incidentObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid incident ID")
+		return models.Incident{}, false
+	}
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return models.Incident{}, false
+	}
+
+	incident, err := h.db.Incidents().FindOne(ctx, store.IncidentFilter{ID: incidentObjID, VenueName: venueName})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.IncidentNotFound, "Incident not found")
+		return incident, false
+	}
+
+	return incident, true
+}
+
+// GetIncident returns an incident with its linked inspections (manager only)
+func (h *IncidentHandler) GetIncident(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	incident, ok := h.findVenueIncident(ctx, c)
+	if !ok {
+		return
+	}
+
+	inspections := []models.Inspection{}
+	if len(incident.InspectionIDs) > 0 {
+		linked, err := h.db.Inspections().FindByIDs(ctx, incident.InspectionIDs)
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch inspections")
+			return
+		}
+		if linked != nil {
+			inspections = linked
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"incident":    incident,
+		"inspections": inspections,
+	})
+}
+
+type UpdateIncidentRequest struct {
+	Status     string `json:"status"`
+	Severity   string `json:"severity"`
+	Note       string `json:"note"`
+	Resolution string `json:"resolution"` // Required when resolving or rejecting
+}
+
+// UpdateIncident moves an incident through triage (manager only)
+func (h *IncidentHandler) UpdateIncident(c *gin.Context) {
+	var req UpdateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+	if req.Status == "" && req.Severity == "" && req.Note == "" {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Nothing to update")
+		return
+	}
+
+	managerID, _ := c.Get("user_id")
+	managerObjID, _ := primitive.ObjectIDFromHex(managerID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	incident, ok := h.findVenueIncident(ctx, c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	update := store.IncidentUpdate{UpdatedAt: now}
+	event := models.IncidentEvent{
+		Status: incident.Status,
+		Note:   strings.TrimSpace(req.Note),
+		By:     managerObjID,
+		At:     now,
+	}
+
+	if req.Status != "" {
+		status := models.IncidentStatus(req.Status)
+		allowed := false
+		for _, next := range incidentTransitions[incident.Status] {
+			if next == status {
+				allowed = true
+			}
+		}
+		if !allowed {
+			apierr.Abort(c, http.StatusBadRequest, apierr.InvalidTransition, fmt.Sprintf("Cannot move incident from '%s' to '%s'", incident.Status, req.Status))
+			return
+		}
+
+		if status == models.IncidentResolved || status == models.IncidentRejected {
+			resolution := strings.TrimSpace(req.Resolution)
+			if resolution == "" {
+				apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "A resolution is required to close an incident")
+				return
+			}
+			update.Resolution = resolution
+		}
+
+		update.Status = status
+		event.Status = status
+	}
+
+	if req.Severity != "" {
+		severity := models.IncidentSeverity(req.Severity)
+		if !incidentSeverities[severity] {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid severity. Must be: low, medium, high, or critical")
+			return
+		}
+		update.Severity = severity
+	}
+	update.Event = event
+
+	updated, err := h.db.Incidents().Update(ctx, incident.ID, incident.Status, update)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update incident")
+		return
+	}
+	if !updated {
+		apierr.Abort(c, http.StatusConflict, apierr.ConcurrentUpdate, "Incident changed while updating, please retry")
+		return
+	}
+
+	incident, err = h.db.Incidents().FindOne(ctx, store.IncidentFilter{ID: incident.ID})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get updated incident")
+		return
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InspectionHandler struct {
+	db *store.Store
+}
+
+func NewInspectionHandler(database *store.Store) *InspectionHandler {
+	return &InspectionHandler{db: database}
+}
+
+type DamageMarkerRequest struct {
+	Zone     string  `json:"zone" binding:"required"`
+	Type     string  `json:"type" binding:"required"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Severity string  `json:"severity"`
+	Note     string  `json:"note"`
+}
+
+type CreateInspectionRequest struct {
+	Stage         string                `json:"stage" binding:"required"`
+	DamageMarkers []DamageMarkerRequest `json:"damage_markers"`
+	FuelLevel     *int                  `json:"fuel_level"`
+	OdometerKm    *int                  `json:"odometer_km"`
+	Photos        []string              `json:"photos"`
+	Notes         string                `json:"notes"`
+}
+
+// findSessionForCaller loads a session the caller may see. Customers can only
+// see their own sessions and those of vehicles shared with them; valets can
+// see any session. Handlers that return the session pass it through
+// redactPickupOTP first.
+func findSessionForCaller(ctx context.Context, database *store.Store, c *gin.Context) (models.ParkingSession, bool) {
+	sessionObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+		return models.ParkingSession{}, false
+	}
+
+	role, _ := c.Get("role")
+
+	filter := store.SessionFilter{ID: sessionObjID}
+	if role == string(models.RoleCustomer) {
+		if filter.Access, err = customerAccess(ctx, database, c, ""); err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch session")
+			return models.ParkingSession{}, false
+		}
+	}
+
+	session, err := database.Sessions().FindOne(ctx, filter)
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.SessionNotFound, "Session not found")
+		return session, false
+	}
+
+	return session, true
+}
+
+// findInspection returns the inspection recorded for a session at the given stage, if any
+func findInspection(ctx context.Context, database *store.Store, sessionID primitive.ObjectID, stage models.InspectionStage) (*models.Inspection, error) {
+	inspection, err := database.Inspections().FindByStage(ctx, sessionID, stage)
+	if err != nil {
+		return nil, err
+	}
+	return &inspection, nil
+}
+
+// CreateInspection records the vehicle condition at check-in or check-out (valet only)
+func (h *InspectionHandler) CreateInspection(c *gin.Context) {
+	var req CreateInspectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	// Check-in happens while the valet takes the car, check-out while handing it back
+	var allowedStatuses []models.SessionStatus
+	stage := models.InspectionStage(req.Stage)
+	switch stage {
+	case models.InspectionCheckIn:
+		allowedStatuses = []models.SessionStatus{models.StatusPending, models.StatusPicked}
+	case models.InspectionCheckOut:
+		allowedStatuses = []models.SessionStatus{models.StatusRequested, models.StatusMoving, models.StatusAvailable}
+	default:
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid stage. Must be 'check_in' or 'check_out'")
+		return
+	}
+
+	if req.FuelLevel != nil && (*req.FuelLevel < 0 || *req.FuelLevel > 100) {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Fuel level must be a percentage between 0 and 100")
+		return
+	}
+	if req.OdometerKm != nil && *req.OdometerKm < 0 {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Odometer reading cannot be negative")
+		return
+	}
+
+	validDamageTypes := map[models.DamageType]bool{
+		models.DamageScratch: true,
+		models.DamageDent:    true,
+		models.DamageCrack:   true,
+		models.DamageBroken:  true,
+		models.DamageMissing: true,
+		models.DamageOther:   true,
+	}
+	markers := make([]models.DamageMarker, 0, len(req.DamageMarkers))
+	for _, m := range req.DamageMarkers {
+		if !validDamageTypes[models.DamageType(m.Type)] {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid damage type. Must be: scratch, dent, crack, broken, missing, or other")
+			return
+		}
+		if m.X < 0 || m.X > 1 || m.Y < 0 || m.Y > 1 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Damage marker coordinates must be between 0 and 1")
+			return
+		}
+		markers = append(markers, models.DamageMarker{
+			Zone:     m.Zone,
+			Type:     models.DamageType(m.Type),
+			X:        m.X,
+			Y:        m.Y,
+			Severity: m.Severity,
+			Note:     m.Note,
+		})
+	}
+
+	valetID, _ := c.Get("user_id")
+	valetObjID, _ := primitive.ObjectIDFromHex(valetID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	statusAllowed := false
+	for _, s := range allowedStatuses {
+		if session.Status == s {
+			statusAllowed = true
+		}
+	}
+	if !statusAllowed {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidTransition, "Cannot record a "+req.Stage+" inspection while session is '"+string(session.Status)+"'")
+		return
+	}
+
+	// Photos must be inspection media uploaded by this valet
+	if !checkMediaRefs(ctx, h.db, c, valetObjID, models.MediaKindInspection, req.Photos) {
+		return
+	}
+
+	if existing, _ := findInspection(ctx, h.db, session.ID, stage); existing != nil {
+		apierr.Abort(c, http.StatusConflict, apierr.InspectionExists, "Inspection already recorded for this stage")
+		return
+	}
+
+	inspection := models.Inspection{
+		ID:            primitive.NewObjectID(),
+		SessionID:     session.ID,
+		VehicleID:     session.VehicleID,
+		Stage:         stage,
+		InspectorID:   valetObjID,
+		DamageMarkers: markers,
+		FuelLevel:     req.FuelLevel,
+		OdometerKm:    req.OdometerKm,
+		Photos:        req.Photos,
+		Notes:         req.Notes,
+		CreatedAt:     time.Now(),
+	}
+
+	err := h.db.Inspections().Insert(ctx, inspection)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to save inspection")
+		return
+	}
+
+	c.JSON(http.StatusCreated, inspection)
+}
+
+// ListInspections returns all inspections recorded for a session
+func (h *InspectionHandler) ListInspections(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	inspections, err := h.db.Inspections().ListBySession(ctx, session.ID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch inspections")
+		return
+	}
+
+	if inspections == nil {
+		inspections = []models.Inspection{}
+	}
+
+	c.JSON(http.StatusOK, inspections)
+}
+
+// CompareInspections returns check-in and check-out side by side with the differences between them
+func (h *InspectionHandler) CompareInspections(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	checkIn, _ := findInspection(ctx, h.db, session.ID, models.InspectionCheckIn)
+	checkOut, _ := findInspection(ctx, h.db, session.ID, models.InspectionCheckOut)
+
+	if checkIn == nil && checkOut == nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.InspectionNotFound, "No inspections recorded for this session")
+		return
+	}
+
+	response := gin.H{
+		"check_in":  checkIn,
+		"check_out": checkOut,
+	}
+
+	if checkIn != nil && checkOut != nil {
+		// A check-out marker is new damage if check-in had nothing of the same type in that zone
+		seen := make(map[string]bool)
+		for _, m := range checkIn.DamageMarkers {
+			seen[m.Zone+"|"+string(m.Type)] = true
+		}
+		newDamage := []models.DamageMarker{}
+		for _, m := range checkOut.DamageMarkers {
+			if !seen[m.Zone+"|"+string(m.Type)] {
+				newDamage = append(newDamage, m)
+			}
+		}
+		response["new_damage"] = newDamage
+
+		if checkIn.FuelLevel != nil && checkOut.FuelLevel != nil {
+			response["fuel_change"] = *checkOut.FuelLevel - *checkIn.FuelLevel
+		}
+		if checkIn.OdometerKm != nil && checkOut.OdometerKm != nil {
+			response["distance_km"] = *checkOut.OdometerKm - *checkIn.OdometerKm
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type KeyTagHandler struct {
+	db *store.Store
+}
+
+func NewKeyTagHandler(database *store.Store) *KeyTagHandler {
+	return &KeyTagHandler{db: database}
+}
+
+// checkKeyReturn enforces the key return check before a car is handed back:
+// if the session's keys are tagged, the valet must confirm the tag number they
+// are returning. Venues that require key tags only let tagged cars be parked,
+// so untagged sessions come from venues that opted out. It reports whether the
+// keys are still out and writes the error response itself when the check fails.
+func checkKeyReturn(c *gin.Context, session models.ParkingSession, tagNumber string) (bool, bool) {
+	if session.KeyTag == nil || session.KeyTag.ReturnedAt != nil {
+		return false, true
+	}
+	if tagNumber == "" {
+		apierr.AbortWith(c, apierr.New(http.StatusBadRequest, apierr.KeyReturnRequired,
+			"Key return check required. Confirm the key tag number being handed over.").
+			With("key_return_required", true))
+		return true, false
+	}
+	if !strings.EqualFold(strings.TrimSpace(tagNumber), session.KeyTag.Number) {
+		apierr.Abort(c, http.StatusConflict, apierr.KeyTagMismatch, "Key tag does not match this session's keys")
+		return true, false
+	}
+	return true, true
+}
+
+// addKeyReturn extends a delivery update to mark the session's keys as returned
+func addKeyReturn(update *store.SessionUpdate, session models.ParkingSession, by primitive.ObjectID, now time.Time) {
+	update.KeyReturnedAt = &now
+	update.Custody = &models.KeyCustodyEntry{
+		Action:    models.KeyReturned,
+		FromValet: session.KeyTag.HolderID,
+		By:        by,
+		At:        now,
+	}
+}
+
+// ListKeyTags returns the key tag inventory of the caller's venue
+func (h *KeyTagHandler) ListKeyTags(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.db.KeyTags().Find(ctx, store.KeyTagFilter{
+		VenueName: venueName,
+		Status:    models.KeyTagStatus(c.Query("status")),
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch key tags")
+		return
+	}
+
+	if tags == nil {
+		tags = []models.KeyTag{}
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+type CreateKeyTagRequest struct {
+	Number   string `json:"number" binding:"required"`
+	Location string `json:"location" binding:"required"`
+}
+
+// CreateKeyTag adds a tag to the caller's venue inventory
+func (h *KeyTagHandler) CreateKeyTag(c *gin.Context) {
+	var req CreateKeyTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	number := strings.ToUpper(strings.TrimSpace(req.Number))
+	if number == "" {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Key tag number is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	count, err := h.db.KeyTags().Count(ctx, store.KeyTagFilter{VenueName: venueName, Number: number})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to check key tag")
+		return
+	}
+	if count > 0 {
+		apierr.Abort(c, http.StatusConflict, apierr.KeyTagExists, "Key tag number already exists at this venue")
+		return
+	}
+
+	now := time.Now()
+	tag := models.KeyTag{
+		ID:        primitive.NewObjectID(),
+		VenueName: venueName,
+		Number:    number,
+		Location:  strings.TrimSpace(req.Location),
+		Status:    models.KeyTagAvailable,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.db.KeyTags().Insert(ctx, tag); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to create key tag")
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+type UpdateKeyTagRequest struct {
+	Location *string `json:"location"`
+	Status   *string `json:"status"` // "available" or "retired"
+}
+
+// UpdateKeyTag moves a tag to another hook or retires/reinstates it
+func (h *KeyTagHandler) UpdateKeyTag(c *gin.Context) {
+	tagObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid key tag ID")
+		return
+	}
+
+	var req UpdateKeyTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	tag, err := h.db.KeyTags().FindOne(ctx, store.KeyTagFilter{ID: tagObjID, VenueName: venueName})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.KeyTagNotFound, "Key tag not found")
+		return
+	}
+
+	var location string
+	if req.Location != nil {
+		location = strings.TrimSpace(*req.Location)
+		if location == "" {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Location cannot be empty")
+			return
+		}
+	}
+	var status models.KeyTagStatus
+	if req.Status != nil {
+		status = models.KeyTagStatus(*req.Status)
+		if status != models.KeyTagAvailable && status != models.KeyTagRetired {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid status. Must be 'available' or 'retired'")
+			return
+		}
+	}
+
+	// Tags holding a customer's keys are changed through the session, not here
+	updated, err := h.db.KeyTags().UpdateDetails(ctx, tag.ID, location, status)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update key tag")
+		return
+	}
+	if !updated {
+		apierr.Abort(c, http.StatusConflict, apierr.KeyTagInUse, "Key tag is in use by an active session")
+		return
+	}
+
+	tag, err = h.db.KeyTags().FindOne(ctx, store.KeyTagFilter{ID: tag.ID})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get updated key tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+type AssignKeyRequest struct {
+	Number   string `json:"number" binding:"required"`
+	Location string `json:"location"` // Defaults to the tag's own hook
+}
+
+// AssignKey attaches a key tag to a session once the valet has taken the keys (valet only)
+func (h *KeyTagHandler) AssignKey(c *gin.Context) {
+	var req AssignKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	valetID, _ := c.Get("user_id")
+	valetObjID, _ := primitive.ObjectIDFromHex(valetID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok || !keySessionMatches(ctx, h.db, c, session) {
+		return
+	}
+
+	// Keys are tagged at picked; late tagging is allowed until the car is parked
+	assignable := []models.SessionStatus{models.StatusPicked, models.StatusParkingMoving, models.StatusParked}
+	allowed := false
+	for _, s := range assignable {
+		if session.Status == s {
+			allowed = true
+		}
+	}
+	if !allowed {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidTransition, "Cannot assign a key tag while session is '"+string(session.Status)+"'")
+		return
+	}
+	if session.KeyTag != nil {
+		apierr.Abort(c, http.StatusConflict, apierr.KeyTagAlreadyAssigned, "Session already has key tag "+session.KeyTag.Number)
+		return
+	}
+
+	tag, err := h.db.KeyTags().FindOne(ctx, store.KeyTagFilter{
+		VenueName: session.VenueName,
+		Number:    strings.ToUpper(strings.TrimSpace(req.Number)),
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.KeyTagNotFound, "Key tag not found at this venue")
+		return
+	}
+
+	now := time.Now()
+	claimed, err := h.db.KeyTags().Claim(ctx, tag.ID, session.ID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to assign key tag")
+		return
+	}
+	if !claimed {
+		apierr.Abort(c, http.StatusConflict, apierr.KeyTagUnavailable, "Key tag is not available")
+		return
+	}
+
+	location := strings.TrimSpace(req.Location)
+	if location == "" {
+		location = tag.Location
+	}
+
+	key := models.SessionKey{
+		TagID:      tag.ID,
+		Number:     tag.Number,
+		Location:   location,
+		HolderID:   &valetObjID,
+		AssignedAt: now,
+	}
+	entry := models.KeyCustodyEntry{
+		Action:   models.KeyAssigned,
+		ToValet:  &valetObjID,
+		Location: location,
+		By:       valetObjID,
+		At:       now,
+	}
+
+	updated, err := h.db.Sessions().Apply(ctx,
+		store.SessionFilter{
+			ID:       session.ID,
+			Version:  &session.Version,
+			Statuses: assignable,
+			NoKeyTag: true,
+		},
+		store.SessionUpdate{KeyTag: &key, Custody: &entry},
+	)
+	if err != nil {
+		_ = h.db.KeyTags().Free(ctx, tag.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			apierr.Abort(c, http.StatusConflict, apierr.ConcurrentUpdate, "Session changed while assigning the key tag, please retry")
+		} else {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to assign key tag")
+		}
+		return
+	}
+
+	c.Header("ETag", sessionETag(updated))
+	c.JSON(http.StatusOK, gin.H{
+		"message": localized(c, "KEY_TAG_ASSIGNED"),
+		"key_tag": key,
+	})
+}
+
+// keySessionMatches checks session against the If-Match header, writing a 412
+// and returning false if the client acted on another version of it. Key
+// changes then apply only to the version checked here.
+func keySessionMatches(ctx context.Context, database *store.Store, c *gin.Context, session models.ParkingSession) bool {
+	filter := store.SessionFilter{ID: session.ID}
+	if !ifMatch(c, &filter) {
+		return false
+	}
+	if filter.Versions != nil && !slices.Contains(filter.Versions, session.Version) {
+		sessionConflict(ctx, database, c, store.SessionFilter{ID: session.ID}, filter.Versions, "Session not found")
+		return false
+	}
+	return true
+}
+
+type HandoffKeyRequest struct {
+	ToValetID string `json:"to_valet_id"` // Valet receiving the keys
+	Location  string `json:"location"`    // Hook or box the keys were put in
+	Note      string `json:"note"`
+}
+
+// HandoffKey records the keys passing to another valet or being put away (valet only)
+func (h *KeyTagHandler) HandoffKey(c *gin.Context) {
+	var req HandoffKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+	if req.ToValetID == "" && strings.TrimSpace(req.Location) == "" {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Either to_valet_id or location is required")
+		return
+	}
+
+	valetID, _ := c.Get("user_id")
+	valetObjID, _ := primitive.ObjectIDFromHex(valetID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok || !keySessionMatches(ctx, h.db, c, session) {
+		return
+	}
+	if session.KeyTag == nil || session.KeyTag.ReturnedAt != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.NoKeyTag, "Session has no key tag in custody")
+		return
+	}
+
+	entry := models.KeyCustodyEntry{
+		FromValet: session.KeyTag.HolderID,
+		Location:  strings.TrimSpace(req.Location),
+		Note:      req.Note,
+		By:        valetObjID,
+		At:        time.Now(),
+	}
+	update := store.SessionUpdate{Custody: &entry}
+
+	if req.ToValetID != "" {
+		toObjID, err := primitive.ObjectIDFromHex(req.ToValetID)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid valet ID")
+			return
+		}
+		count, err := h.db.Users().Count(ctx, store.UserFilter{ID: toObjID, Role: models.RoleValet})
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get valet info")
+			return
+		}
+		if count == 0 {
+			apierr.Abort(c, http.StatusNotFound, apierr.ValetNotFound, "Valet not found")
+			return
+		}
+		entry.Action = models.KeyHandoff
+		entry.ToValet = &toObjID
+		update.KeyHolder = &toObjID
+	} else {
+		entry.Action = models.KeyStored
+		update.ClearKeyHolder = true
+	}
+	update.KeyLocation = entry.Location
+
+	updated, err := h.db.Sessions().Apply(ctx,
+		store.SessionFilter{ID: session.ID, Version: &session.Version, KeyOut: session.KeyTag.TagID},
+		update,
+	)
+	if errors.Is(err, store.ErrNotFound) {
+		apierr.Abort(c, http.StatusConflict, apierr.ConcurrentUpdate, "Key custody changed, please retry")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to record key handoff")
+		return
+	}
+
+	c.Header("ETag", sessionETag(updated))
+	c.JSON(http.StatusOK, gin.H{
+		"message": localized(c, "KEY_CUSTODY_UPDATED"),
+		"entry":   entry,
+	})
+}
@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/media"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/storage"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// How long upload and download URLs handed to clients stay valid
+const mediaURLTTL = 15 * time.Minute
+
+type MediaHandler struct {
+	db             *store.Store
+	store          storage.BlobStore
+	urlSecret      []byte
+	maxUploadBytes int64
+}
+
+func NewMediaHandler(database *store.Store, blobStore storage.BlobStore, urlSecret string, maxUploadBytes int64) *MediaHandler {
+	return &MediaHandler{
+		db:             database,
+		store:          blobStore,
+		urlSecret:      []byte(urlSecret),
+		maxUploadBytes: maxUploadBytes,
+	}
+}
+
+// checkMediaRefs verifies that every ID refers to a ready media object of the
+// given kind uploaded by owner
+func checkMediaRefs(ctx context.Context, database *store.Store, c *gin.Context, ownerID primitive.ObjectID, kind models.MediaKind, ids []string) bool {
+	if len(ids) == 0 {
+		return true
+	}
+
+	unique := make(map[primitive.ObjectID]bool)
+	for _, id := range ids {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Photos must be IDs of uploaded media")
+			return false
+		}
+		unique[objID] = true
+	}
+
+	objIDs := make([]primitive.ObjectID, 0, len(unique))
+	for id := range unique {
+		objIDs = append(objIDs, id)
+	}
+
+	count, err := database.Media().CountReady(ctx, objIDs, ownerID, kind)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to verify photos")
+		return false
+	}
+
+	if int(count) != len(objIDs) {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Photos must be IDs of "+string(kind)+" media you uploaded")
+		return false
+	}
+
+	return true
+}
+
+func parseMediaKind(kind string) (models.MediaKind, bool) {
+	switch models.MediaKind(kind) {
+	case models.MediaKindVehicle, models.MediaKindInspection, models.MediaKindIncident:
+		return models.MediaKind(kind), true
+	}
+	return "", false
+}
+
+// processAndStore validates and normalizes an image, writes it to the blob
+// store and marks the media object ready
+func (h *MediaHandler) processAndStore(ctx context.Context, c *gin.Context, m *models.Media, data []byte) bool {
+	processed, err := media.ProcessImage(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrUnsupportedType), errors.Is(err, media.ErrInvalidImage):
+			apierr.Abort(c, http.StatusUnsupportedMediaType, apierr.UnsupportedMediaType, err.Error())
+		case errors.Is(err, media.ErrImageTooLarge):
+			apierr.Abort(c, http.StatusRequestEntityTooLarge, apierr.FileTooLarge, err.Error())
+		default:
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to process image")
+		}
+		return false
+	}
+
+	m.Key = fmt.Sprintf("%s/%s/full.jpg", m.Kind, m.ID.Hex())
+	m.ThumbnailKey = fmt.Sprintf("%s/%s/thumb.jpg", m.Kind, m.ID.Hex())
+
+	if err := h.store.Put(ctx, m.Key, processed.Image, media.OutputMediaType); err != nil {
+		log.Printf("media: failed to store %s: %v", m.Key, err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to store image")
+		return false
+	}
+	if err := h.store.Put(ctx, m.ThumbnailKey, processed.Thumbnail, media.OutputMediaType); err != nil {
+		log.Printf("media: failed to store %s: %v", m.ThumbnailKey, err)
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to store image")
+		return false
+	}
+
+	now := time.Now()
+	m.Status = models.MediaStatusReady
+	m.ContentType = media.OutputMediaType
+	m.Width = processed.Width
+	m.Height = processed.Height
+	m.Size = len(processed.Image)
+	m.UploadedAt = &now
+
+	if err := h.db.Media().MarkReady(ctx, *m); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to save media")
+		return false
+	}
+
+	return true
+}
+
+// downloadURL returns a time-limited URL for one variant ("full" or "thumb") of a media object
+func (h *MediaHandler) downloadURL(ctx context.Context, m *models.Media, variant string, expires time.Time) string {
+	key := m.Key
+	if variant == "thumb" {
+		key = m.ThumbnailKey
+	}
+
+	if url, err := h.store.PresignGet(ctx, key, time.Until(expires)); err == nil {
+		return url
+	}
+
+	// Fall back to serving through the API with our own signature
+	return fmt.Sprintf("/api/media/files/%s/%s?expires=%d&signature=%s",
+		m.ID.Hex(), variant, expires.Unix(), media.Sign(h.urlSecret, "download", m.ID.Hex(), variant, expires))
+}
+
+func (h *MediaHandler) mediaResponse(ctx context.Context, m *models.Media) gin.H {
+	expires := time.Now().Add(mediaURLTTL)
+	return gin.H{
+		"media":         m,
+		"url":           h.downloadURL(ctx, m, "full", expires),
+		"thumbnail_url": h.downloadURL(ctx, m, "thumb", expires),
+		"expires_at":    expires,
+	}
+}
+
+// Upload accepts a multipart image upload in the "file" field
+func (h *MediaHandler) Upload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadBytes+1024*1024)
+
+	kind, ok := parseMediaKind(c.PostForm("kind"))
+	if !ok {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid kind. Must be 'vehicle', 'inspection' or 'incident'")
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidUpload, "Image file required in 'file' field")
+		return
+	}
+	if fileHeader.Size > h.maxUploadBytes {
+		apierr.Abort(c, http.StatusRequestEntityTooLarge, apierr.FileTooLarge, "File too large")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidUpload, "Failed to read upload")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxUploadBytes))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidUpload, "Failed to read upload")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	m := models.Media{
+		ID:        primitive.NewObjectID(),
+		OwnerID:   userObjID,
+		Kind:      kind,
+		Status:    models.MediaStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.db.Media().Insert(ctx, m); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to save media")
+		return
+	}
+
+	if !h.processAndStore(ctx, c, &m, data) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.mediaResponse(ctx, &m))
+}
+
+type CreateUploadRequest struct {
+	Kind string `json:"kind" binding:"required"`
+}
+
+// CreateUpload issues a presigned URL the client can PUT the raw image bytes to
+func (h *MediaHandler) CreateUpload(c *gin.Context) {
+	var req CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	kind, ok := parseMediaKind(req.Kind)
+	if !ok {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid kind. Must be 'vehicle', 'inspection' or 'incident'")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	m := models.Media{
+		ID:        primitive.NewObjectID(),
+		OwnerID:   userObjID,
+		Kind:      kind,
+		Status:    models.MediaStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.db.Media().Insert(ctx, m); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to create upload")
+		return
+	}
+
+	expires := time.Now().Add(mediaURLTTL)
+	uploadURL := fmt.Sprintf("/api/media/uploads/%s?expires=%d&signature=%s",
+		m.ID.Hex(), expires.Unix(), media.Sign(h.urlSecret, "upload", m.ID.Hex(), "", expires))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"media":      m,
+		"upload_url": uploadURL,
+		"method":     http.MethodPut,
+		"max_bytes":  h.maxUploadBytes,
+		"expires_at": expires,
+	})
+}
+
+// CompleteUpload receives the raw image bytes for a presigned upload URL.
+// Authorization comes from the URL signature, not the bearer token.
+func (h *MediaHandler) CompleteUpload(c *gin.Context) {
+	mediaID := c.Param("id")
+	if !media.Verify(h.urlSecret, "upload", mediaID, "", c.Query("expires"), c.Query("signature")) {
+		apierr.Abort(c, http.StatusForbidden, apierr.SignedURLInvalid, "Upload URL is invalid or expired")
+		return
+	}
+
+	mediaObjID, err := primitive.ObjectIDFromHex(mediaID)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid media ID")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadBytes))
+	if err != nil {
+		apierr.Abort(c, http.StatusRequestEntityTooLarge, apierr.FileTooLarge, "File too large")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	m, err := h.db.Media().FindWithStatus(ctx, mediaObjID, models.MediaStatusPending)
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.UploadNotFound, "Upload not found or already completed")
+		return
+	}
+
+	if !h.processAndStore(ctx, c, &m, data) {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mediaResponse(ctx, &m))
+}
+
+// canView reports whether the user may see a media object. Uploaders always
+// can. Customers can see photos of their own vehicles and inspection and
+// incident photos from their own sessions; valets and managers, those tied to
+// sessions at their venue (venueName) or vehicles parked there.
+func (h *MediaHandler) canView(ctx context.Context, m *models.Media, userObjID primitive.ObjectID, venueName string) bool {
+	if m.OwnerID == userObjID {
+		return true
+	}
+
+	// The sessions the user may see: their own, or their venue's
+	sessions := store.SessionFilter{CustomerID: userObjID}
+	if venueName != "" {
+		sessions = store.SessionFilter{VenueName: venueName}
+	}
+
+	switch m.Kind {
+	case models.MediaKindVehicle:
+		if venueName == "" {
+			count, _ := h.db.Vehicles().Count(ctx, store.VehicleFilter{
+				OwnerID: userObjID,
+				Photo:   m.ID.Hex(),
+			})
+			return count > 0
+		}
+		vehicles, err := h.db.Vehicles().Find(ctx, store.VehicleFilter{Photo: m.ID.Hex()}, store.VehicleOrderNone, 0)
+		if err != nil {
+			return false
+		}
+		for _, v := range vehicles {
+			sessions.VehicleID = v.ID
+			if count, _ := h.db.Sessions().Count(ctx, sessions); count > 0 {
+				return true
+			}
+		}
+		return false
+	case models.MediaKindInspection:
+		sessionIDs, err := h.db.Inspections().SessionIDsWithPhoto(ctx, m.ID.Hex())
+		if err != nil || len(sessionIDs) == 0 {
+			return false
+		}
+		sessions.IDs = sessionIDs
+		count, _ := h.db.Sessions().Count(ctx, sessions)
+		return count > 0
+	case models.MediaKindIncident:
+		sessionIDs, err := h.db.Incidents().SessionIDsWithPhoto(ctx, m.ID.Hex())
+		if err != nil || len(sessionIDs) == 0 {
+			return false
+		}
+		sessions.IDs = sessionIDs
+		count, _ := h.db.Sessions().Count(ctx, sessions)
+		return count > 0
+	}
+	return false
+}
+
+// GetMedia returns media metadata with time-limited download URLs
+func (h *MediaHandler) GetMedia(c *gin.Context) {
+	mediaObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid media ID")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Staff see media from their own venue only
+	var venueName string
+	if role == string(models.RoleValet) || role == string(models.RoleManager) {
+		var ok bool
+		if venueName, ok = valetVenue(ctx, h.db, c); !ok {
+			return
+		}
+	}
+
+	m, err := h.db.Media().FindWithStatus(ctx, mediaObjID, models.MediaStatusReady)
+	if err != nil || !h.canView(ctx, &m, userObjID, venueName) {
+		apierr.Abort(c, http.StatusNotFound, apierr.MediaNotFound, "Media not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mediaResponse(ctx, &m))
+}
+
+// ServeFile streams a media variant for a signed download URL
+func (h *MediaHandler) ServeFile(c *gin.Context) {
+	mediaID := c.Param("id")
+	variant := c.Param("variant")
+	if variant != "full" && variant != "thumb" {
+		apierr.Abort(c, http.StatusNotFound, apierr.MediaNotFound, "Media not found")
+		return
+	}
+
+	if !media.Verify(h.urlSecret, "download", mediaID, variant, c.Query("expires"), c.Query("signature")) {
+		apierr.Abort(c, http.StatusForbidden, apierr.SignedURLInvalid, "Download URL is invalid or expired")
+		return
+	}
+
+	mediaObjID, err := primitive.ObjectIDFromHex(mediaID)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid media ID")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	m, err := h.db.Media().FindByID(ctx, mediaObjID)
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.MediaNotFound, "Media not found")
+		return
+	}
+
+	key := m.Key
+	if variant == "thumb" {
+		key = m.ThumbnailKey
+	}
+
+	reader, err := h.store.Get(ctx, key)
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.MediaNotFound, "Media not found")
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(mediaURLTTL.Seconds())))
+	c.DataFromReader(http.StatusOK, -1, m.ContentType, reader, nil)
+}
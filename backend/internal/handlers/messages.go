@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localized returns the catalog message for key in the caller's language
+func localized(c *gin.Context, key string) string {
+	lang := apierr.Language(c)
+	c.Header("Content-Language", string(lang))
+	return i18n.Message(lang, key)
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rivalVehicles returns other customers' live vehicles registered with the same plate
+func (h *VehicleHandler) rivalVehicles(ctx context.Context, vehicle models.Vehicle) ([]models.Vehicle, error) {
+	return h.db.Vehicles().Find(ctx, store.VehicleFilter{
+		NotID:         vehicle.ID,
+		NotOwnerID:    vehicle.OwnerID,
+		NormalizedReg: vehicle.NormalizedReg,
+		Active:        true,
+		NotRejected:   true,
+	}, store.VehicleOrderNone, 0)
+}
+
+// openPlateDispute records that the given vehicles claim the same plate and
+// marks every unverified claim among them as disputed
+func (h *VehicleHandler) openPlateDispute(ctx context.Context, normalizedReg string, vehicleIDs []primitive.ObjectID) error {
+	err := h.db.Vehicles().UpdateMany(ctx,
+		store.VehicleFilter{IDs: vehicleIDs, Unresolved: true},
+		store.VehicleUpdate{OwnershipStatus: models.OwnershipDisputed},
+	)
+	if err != nil {
+		return err
+	}
+
+	return h.db.PlateDisputes().AddClaims(ctx, normalizedReg, vehicleIDs)
+}
+
+// checkPlateOwnership sets the initial ownership state of a vehicle that is
+// about to be stored under a plate, and opens a dispute once it is saved if
+// another customer already has that plate. Call the returned func after the
+// vehicle has been written.
+func (h *VehicleHandler) checkPlateOwnership(ctx context.Context, vehicle *models.Vehicle) (func() error, error) {
+	vehicle.Ownership = models.Ownership{Status: models.OwnershipUnverified}
+
+	rivals, err := h.rivalVehicles(ctx, *vehicle)
+	if err != nil {
+		return nil, err
+	}
+	if len(rivals) == 0 {
+		return func() error { return nil }, nil
+	}
+
+	vehicle.Ownership.Status = models.OwnershipDisputed
+	ids := []primitive.ObjectID{vehicle.ID}
+	for _, r := range rivals {
+		ids = append(ids, r.ID)
+	}
+
+	return func() error {
+		return h.openPlateDispute(ctx, vehicle.NormalizedReg, ids)
+	}, nil
+}
+
+type ClaimOwnershipRequest struct {
+	DocumentNumber string `json:"document_number" binding:"required"`
+	DocumentPhoto  string `json:"document_photo"`
+}
+
+// ClaimOwnership lets a customer submit their registration certificate for a vehicle
+func (h *VehicleHandler) ClaimOwnership(c *gin.Context) {
+	var req ClaimOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vehicle, ok := h.findOwnedVehicle(ctx, c)
+	if !ok {
+		return
+	}
+
+	if vehicle.ArchivedAt != nil {
+		apierr.Abort(c, http.StatusConflict, apierr.VehicleArchived, "Vehicle is archived")
+		return
+	}
+
+	switch vehicle.Ownership.Status {
+	case models.OwnershipVerified:
+		apierr.Abort(c, http.StatusConflict, apierr.OwnershipAlreadyVerified, "Ownership already verified")
+		return
+	case models.OwnershipRejected:
+		apierr.Abort(c, http.StatusConflict, apierr.OwnershipRejected, "Ownership claim was rejected. Please contact the venue.")
+		return
+	}
+
+	if req.DocumentPhoto != "" && !checkMediaRefs(ctx, h.db, c, vehicle.OwnerID, models.MediaKindVehicle, []string{req.DocumentPhoto}) {
+		return
+	}
+
+	// Disputed claims stay disputed until a valet verifies one of them
+	status := vehicle.Ownership.Status
+	if status != models.OwnershipDisputed {
+		status = models.OwnershipPending
+	}
+
+	now := time.Now()
+	vehicle.Ownership.Status = status
+	vehicle.Ownership.DocumentNumber = req.DocumentNumber
+	vehicle.Ownership.DocumentPhoto = req.DocumentPhoto
+	vehicle.Ownership.ClaimedAt = &now
+
+	err := h.db.Vehicles().Update(ctx, vehicle.ID, store.VehicleUpdate{Ownership: &vehicle.Ownership})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to submit ownership claim")
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+type VerifyOwnershipRequest struct {
+	DocumentNumber string `json:"document_number" binding:"required"` // As read off the physical certificate
+	Note           string `json:"note"`
+}
+
+// VerifyOwnership lets a valet confirm a vehicle's owner after checking the
+// registration certificate. The car must be checked in at the valet's venue,
+// where they can see the certificate. Any other claims on the same plate are
+// rejected.
+func (h *VehicleHandler) VerifyOwnership(c *gin.Context) {
+	var req VerifyOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	vehicleObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid vehicle ID")
+		return
+	}
+
+	valetID, _ := c.Get("user_id")
+	valetObjID, _ := primitive.ObjectIDFromHex(valetID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	vehicle, err := h.db.Vehicles().FindOne(ctx, store.VehicleFilter{ID: vehicleObjID, Active: true})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.VehicleNotFound, "Vehicle not found")
+		return
+	}
+
+	_, err = h.db.Sessions().FindOne(ctx, store.SessionFilter{
+		VehicleID:   vehicle.ID,
+		VenueName:   venueName,
+		NotStatuses: models.ClosedStatuses,
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.VehicleNotFound, "Vehicle is not checked in at your venue")
+		return
+	}
+
+	if vehicle.Ownership.DocumentNumber != "" && vehicle.Ownership.DocumentNumber != req.DocumentNumber {
+		apierr.Abort(c, http.StatusConflict, apierr.DocumentMismatch, "Document number does not match the customer's claim")
+		return
+	}
+
+	now := time.Now()
+	ownership := vehicle.Ownership
+	ownership.Status = models.OwnershipVerified
+	ownership.DocumentNumber = req.DocumentNumber
+	ownership.VerifiedAt = &now
+	ownership.VerifiedBy = &valetObjID
+	ownership.Note = req.Note
+
+	err = h.db.Vehicles().Update(ctx, vehicle.ID, store.VehicleUpdate{Ownership: &ownership})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to verify ownership")
+		return
+	}
+
+	// The verified owner wins: reject everyone else holding this plate
+	rivals, err := h.rivalVehicles(ctx, vehicle)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to resolve ownership dispute")
+		return
+	}
+	if len(rivals) > 0 {
+		rivalIDs := make([]primitive.ObjectID, 0, len(rivals))
+		for _, r := range rivals {
+			rivalIDs = append(rivalIDs, r.ID)
+		}
+		notDefault := false
+		err = h.db.Vehicles().UpdateMany(ctx,
+			store.VehicleFilter{IDs: rivalIDs},
+			store.VehicleUpdate{
+				OwnershipStatus: models.OwnershipRejected,
+				OwnershipNote:   "Plate verified for another customer",
+				IsDefault:       &notDefault,
+			},
+		)
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to resolve ownership dispute")
+			return
+		}
+	}
+
+	err = h.db.PlateDisputes().Resolve(ctx, vehicle.NormalizedReg, vehicle.ID, valetObjID, now)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to resolve ownership dispute")
+		return
+	}
+
+	if vehicle, err = h.db.Vehicles().FindByID(ctx, vehicle.ID); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get updated vehicle")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         localized(c, "OWNERSHIP_VERIFIED"),
+		"vehicle":         vehicle,
+		"rejected_claims": len(rivals),
+	})
+}
+
+// ListDisputes returns plate disputes with every candidate vehicle and owner (valet only)
+func (h *VehicleHandler) ListDisputes(c *gin.Context) {
+	status := c.DefaultQuery("status", string(models.DisputeOpen))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	disputes, err := h.db.PlateDisputes().Find(ctx, models.DisputeStatus(status), 100)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch disputes")
+		return
+	}
+
+	results := []gin.H{}
+	for _, d := range disputes {
+		candidates, err := h.plateCandidates(ctx, store.VehicleFilter{IDs: d.VehicleIDs})
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch disputes")
+			return
+		}
+		results = append(results, gin.H{
+			"dispute":    d,
+			"candidates": candidates,
+		})
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+type plateCandidate struct {
+	Vehicle models.Vehicle `json:"vehicle"`
+	Owner   *models.User   `json:"owner"`
+}
+
+// plateCandidates loads matching vehicles with their owners, verified claims first
+func (h *VehicleHandler) plateCandidates(ctx context.Context, filter store.VehicleFilter) ([]plateCandidate, error) {
+	vehicles, err := h.db.Vehicles().Find(ctx, filter, store.VehicleOrderOldestFirst, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]plateCandidate, 0, len(vehicles))
+	var verified, others []plateCandidate
+	for _, v := range vehicles {
+		candidate := plateCandidate{Vehicle: v}
+		if owner, err := h.db.Users().FindByID(ctx, v.OwnerID); err == nil {
+			candidate.Owner = &owner
+		}
+		if v.Ownership.Status == models.OwnershipVerified {
+			verified = append(verified, candidate)
+		} else {
+			others = append(others, candidate)
+		}
+	}
+	candidates = append(candidates, verified...)
+	return append(candidates, others...), nil
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RatingHandler struct {
+	db *store.Store
+}
+
+func NewRatingHandler(database *store.Store) *RatingHandler {
+	return &RatingHandler{db: database}
+}
+
+type SubmitRatingRequest struct {
+	Score   int      `json:"score" binding:"required"`
+	Tags    []string `json:"tags"`
+	Comment string   `json:"comment"`
+}
+
+// SubmitRating records the customer's feedback on a delivered session. Each
+// session can be rated once.
+func (h *RatingHandler) SubmitRating(c *gin.Context) {
+	sessionObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+		return
+	}
+
+	var req SubmitRatingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	if req.Score < 1 || req.Score > 5 {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Score must be between 1 and 5")
+		return
+	}
+	for _, tag := range req.Tags {
+		if !models.RatingTags[tag] {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Unknown rating tag '"+tag+"'")
+			return
+		}
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if len(comment) > 1000 {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Comment must be at most 1000 characters")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := h.db.Sessions().FindOne(ctx, store.SessionFilter{ID: sessionObjID, CustomerID: userObjID})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.SessionNotFound, "Session not found")
+		return
+	}
+	if session.Status != models.StatusDelivered {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidTransition, "Only delivered sessions can be rated")
+		return
+	}
+
+	// Claim the session's single rating slot before writing the rating
+	now := time.Now()
+	claimed, err := h.db.Sessions().Update(ctx,
+		store.SessionFilter{ID: session.ID, NotRated: true},
+		store.SessionUpdate{RatedAt: &now},
+	)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to save rating")
+		return
+	}
+	if !claimed {
+		apierr.Abort(c, http.StatusConflict, apierr.AlreadyRated, "This session has already been rated")
+		return
+	}
+
+	valetIDs := []primitive.ObjectID{session.ValetID}
+	if session.DeliveredBy != nil && *session.DeliveredBy != session.ValetID {
+		valetIDs = append(valetIDs, *session.DeliveredBy)
+	}
+
+	rating := models.Rating{
+		ID:         primitive.NewObjectID(),
+		SessionID:  session.ID,
+		CustomerID: userObjID,
+		VenueName:  session.VenueName,
+		ValetIDs:   valetIDs,
+		Score:      req.Score,
+		Tags:       req.Tags,
+		Comment:    comment,
+		CreatedAt:  now,
+	}
+
+	if err := h.db.Ratings().Insert(ctx, rating); err != nil {
+		_, _ = h.db.Sessions().Update(ctx, store.SessionFilter{ID: session.ID}, store.SessionUpdate{ClearRatedAt: true})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to save rating")
+		return
+	}
+
+	venue := loadVenue(ctx, h.db, session.VenueName)
+	if venue.LowRatingThreshold > 0 && rating.Score <= venue.LowRatingThreshold {
+		raiseAlert(ctx, h.db, models.Alert{
+			VenueName: session.VenueName,
+			Type:      models.AlertLowRating,
+			Params: map[string]string{
+				"ticket": session.TicketNumber,
+				"score":  strconv.Itoa(rating.Score),
+			},
+			SessionID: &session.ID,
+			RefID:     &rating.ID,
+		})
+	}
+
+	c.JSON(http.StatusCreated, rating)
+}
+
+// GetRating returns the rating left on a session
+func (h *RatingHandler) GetRating(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	rating, err := h.db.Ratings().FindOne(ctx, store.RatingFilter{SessionID: session.ID})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.RatingNotFound, "Session has not been rated")
+		return
+	}
+
+	c.JSON(http.StatusOK, rating)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"valet-parking-backend/internal/anpr"
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/plate"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+type recognizedPlate struct {
+	Plate      string           `json:"plate"`   // Normalized
+	Display    string           `json:"display"` // Formatted for the valet
+	Confidence float64          `json:"confidence"`
+	Valid      bool             `json:"valid"`
+	Matches    []plateCandidate `json:"matches"` // Registered vehicles with this plate
+}
+
+// RecognizePlate reads the plate from a kerbside photo (multipart "photo"
+// field) and returns candidate plates with any registered vehicles and owners
+// matching them, so the valet can start a session from the snapshot.
+func (h *VehicleHandler) RecognizePlate(c *gin.Context) {
+	if h.recognizer == nil {
+		apierr.Abort(c, http.StatusServiceUnavailable, apierr.RecognitionUnavailable, "Plate recognition is disabled: no ANPR provider is configured, please enter the plate manually")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadBytes+1024*1024)
+
+	fileHeader, err := c.FormFile("photo")
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidUpload, "Photo required in 'photo' field")
+		return
+	}
+	if fileHeader.Size > h.maxUploadBytes {
+		apierr.Abort(c, http.StatusRequestEntityTooLarge, apierr.FileTooLarge, "File too large")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidUpload, "Failed to read photo")
+		return
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(io.LimitReader(file, h.maxUploadBytes))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidUpload, "Failed to read photo")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	candidates, err := h.recognizer.Recognize(ctx, image)
+	if errors.Is(err, anpr.ErrNoPlate) {
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.PlateNotFound, "No registration plate found in the photo")
+		return
+	}
+	if err != nil {
+		log.Printf("anpr: recognition failed: %v", err)
+		apierr.Abort(c, http.StatusBadGateway, apierr.RecognitionUnavailable, "Plate recognition is unavailable, please enter the plate manually")
+		return
+	}
+
+	results := []recognizedPlate{}
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		normalized := plate.Normalize(candidate.Plate)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+
+		result := recognizedPlate{
+			Plate:      normalized,
+			Display:    plate.Format(h.plateCountry, normalized),
+			Confidence: candidate.Confidence,
+			Valid:      plate.Validate(h.plateCountry, normalized) == nil,
+			Matches:    []plateCandidate{},
+		}
+
+		if result.Valid {
+			matches, err := h.plateCandidates(ctx, store.VehicleFilter{
+				NormalizedReg: normalized,
+				Active:        true,
+				NotRejected:   true,
+			})
+			if err != nil {
+				apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to search vehicles")
+				return
+			}
+			result.Matches = matches
+		}
+
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"candidates": results})
+}
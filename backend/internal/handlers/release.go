@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/plate"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var idDocumentTypes = map[string]bool{
+	"driving_licence": true,
+	"aadhaar":         true,
+	"passport":        true,
+	"voter_id":        true,
+	"pan":             true,
+	"other":           true,
+}
+
+type ManualReleaseRequest struct {
+	RegistrationNumber string `json:"registration_number" binding:"required"`
+	IDDocumentType     string `json:"id_document_type" binding:"required"`
+	IDDocumentNumber   string `json:"id_document_number" binding:"required"`
+	IDHolderName       string `json:"id_holder_name" binding:"required"`
+	Reason             string `json:"reason" binding:"required"`
+	ChargeFee          bool   `json:"charge_fee"`
+	FeePaid            bool   `json:"fee_paid"` // The fee was collected before the car was handed over
+	KeyTagNumber       string `json:"key_tag_number"`
+}
+
+// maskDocumentNumber keeps only the last four characters of an ID document number
+func maskDocumentNumber(number string) string {
+	number = strings.ReplaceAll(number, " ", "")
+	if len(number) <= 4 {
+		return strings.Repeat("X", len(number))
+	}
+	return strings.Repeat("X", len(number)-4) + number[len(number)-4:]
+}
+
+// ManualRelease hands a car back without the pickup OTP, e.g. when the
+// customer lost their ticket or their phone is dead (manager only). The
+// claimant must state the registration number and show an ID document; the
+// override is recorded on the session and in the audit log.
+func (h *SessionHandler) ManualRelease(c *gin.Context) {
+	sessionObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+		return
+	}
+
+	var req ManualReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) < 10 {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Please describe the reason for the override (at least 10 characters)")
+		return
+	}
+	if !idDocumentTypes[req.IDDocumentType] {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid ID document type. Must be: driving_licence, aadhaar, passport, voter_id, pan, or other")
+		return
+	}
+	if req.FeePaid && !req.ChargeFee {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "fee_paid needs charge_fee")
+		return
+	}
+	if len(strings.ReplaceAll(req.IDDocumentNumber, " ", "")) < 4 {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "ID document number is too short")
+		return
+	}
+
+	managerID, _ := c.Get("user_id")
+	managerObjID, _ := primitive.ObjectIDFromHex(managerID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	filter := store.SessionFilter{ID: sessionObjID, VenueName: venueName}
+	if !ifMatch(c, &filter) {
+		return
+	}
+	session, err := h.db.Sessions().FindOne(ctx, filter)
+	if err != nil {
+		sessionConflict(ctx, h.db, c, store.SessionFilter{ID: sessionObjID, VenueName: venueName}, filter.Versions, "Session not found at your venue")
+		return
+	}
+
+	// Only cars still in the venue's custody can be released
+	releasable := []models.SessionStatus{
+		models.StatusParked,
+		models.StatusRequested,
+		models.StatusMoving,
+		models.StatusAvailable,
+		models.StatusInTransit,
+	}
+	allowed := false
+	for _, s := range releasable {
+		if session.Status == s {
+			allowed = true
+		}
+	}
+	if !allowed {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidTransition, "Cannot release - session status is '"+string(session.Status)+"'")
+		return
+	}
+
+	document := models.IDDocument{
+		Type:         req.IDDocumentType,
+		NumberMasked: maskDocumentNumber(req.IDDocumentNumber),
+		HolderName:   strings.TrimSpace(req.IDHolderName),
+	}
+
+	vehicle, err := h.db.Vehicles().FindByID(ctx, session.VehicleID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get vehicle info")
+		return
+	}
+
+	// The claimant must know the plate of the car they are collecting
+	if plate.Normalize(req.RegistrationNumber) != vehicle.NormalizedReg {
+		recordAudit(ctx, h.db, c, models.AuditManualReleaseRejected, venueName, &session.ID, map[string]interface{}{
+			"reason":       "registration_mismatch",
+			"stated_plate": req.RegistrationNumber,
+			"id_document":  document,
+		})
+		apierr.Abort(c, http.StatusConflict, apierr.RegistrationMismatch, "Registration number does not match this session's vehicle")
+		return
+	}
+
+	keyOut, ok := checkKeyReturn(c, session, req.KeyTagNumber)
+	if !ok {
+		return
+	}
+
+	var fee int64
+	var currency string
+	if req.ChargeFee {
+		venue := loadVenue(ctx, h.db, venueName)
+		if venue.LostTicketFee <= 0 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.LostTicketFeeNotSet, "No lost-ticket fee is configured for this venue")
+			return
+		}
+		fee, currency = venue.LostTicketFee, venue.Currency
+	}
+
+	// Flag for review when the ID does not name the owner or a shared driver
+	nameMatches := false
+	if customer, err := h.db.Users().FindByID(ctx, session.CustomerID); err == nil &&
+		strings.EqualFold(customer.Name, document.HolderName) {
+		nameMatches = true
+	}
+	for _, share := range vehicle.SharedWith {
+		if strings.EqualFold(share.Name, document.HolderName) {
+			nameMatches = true
+		}
+	}
+
+	now := time.Now()
+	release := models.ManualRelease{
+		Reason:       reason,
+		ApprovedBy:   managerObjID,
+		Registration: vehicle.RegistrationNumber,
+		IDDocument:   document,
+		FeeAmount:    fee,
+		FeeCurrency:  currency,
+		ReleasedAt:   now,
+	}
+	if req.ChargeFee {
+		// Unpaid fees stay due until a manager collects them
+		release.FeeStatus = models.FeeDue
+		if req.FeePaid {
+			release.FeeStatus = models.FeePaid
+			release.FeePaidAt = &now
+			release.FeePaidTo = &managerObjID
+		}
+	}
+
+	update := store.SessionUpdate{
+		Status:      models.StatusDelivered,
+		DeliveredAt: &now,
+		DeliveredBy: &managerObjID,
+		Release:     &release,
+		ClearOTP:    true,
+	}
+	if keyOut {
+		addKeyReturn(&update, session, managerObjID, now)
+	}
+
+	audit := auditEntry(c, models.AuditManualRelease, venueName, &session.ID, map[string]interface{}{
+		"reason":         reason,
+		"registration":   vehicle.RegistrationNumber,
+		"id_document":    document,
+		"name_matches":   nameMatches,
+		"fee_amount":     fee,
+		"fee_currency":   currency,
+		"fee_status":     release.FeeStatus,
+		"previous_state": session.Status,
+	})
+
+	// Release, return the keys and audit the override together, only from the
+	// version that was checked
+	var updated models.ParkingSession
+	err = h.db.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = h.db.Sessions().Apply(ctx,
+			store.SessionFilter{ID: session.ID, Version: &session.Version},
+			update,
+		)
+		if err != nil {
+			return err
+		}
+		if keyOut {
+			if err := h.db.KeyTags().Free(ctx, session.KeyTag.TagID); err != nil {
+				return err
+			}
+		}
+		return h.db.AuditLogs().Insert(ctx, audit)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		apierr.Abort(c, http.StatusConflict, apierr.ConcurrentUpdate, "Session changed while releasing, please retry")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to release vehicle")
+		return
+	}
+
+	c.Header("ETag", sessionETag(updated))
+	c.JSON(http.StatusOK, gin.H{
+		"message":        localized(c, "VEHICLE_RELEASED"),
+		"delivered_at":   now,
+		"manual_release": release,
+		"name_matches":   nameMatches,
+	})
+}
+
+// CollectReleaseFee marks the lost-ticket fee of a manually released session
+// as paid (manager only)
+func (h *SessionHandler) CollectReleaseFee(c *gin.Context) {
+	sessionObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+		return
+	}
+
+	managerID, _ := c.Get("user_id")
+	managerObjID, _ := primitive.ObjectIDFromHex(managerID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	filter := store.SessionFilter{ID: sessionObjID, VenueName: venueName}
+	if !ifMatch(c, &filter) {
+		return
+	}
+	session, err := h.db.Sessions().FindOne(ctx, filter)
+	if err != nil {
+		sessionConflict(ctx, h.db, c, store.SessionFilter{ID: sessionObjID, VenueName: venueName}, filter.Versions, "Session not found at your venue")
+		return
+	}
+
+	if session.Release == nil || session.Release.FeeStatus != models.FeeDue {
+		apierr.Abort(c, http.StatusConflict, apierr.ReleaseFeeNotDue, "No lost-ticket fee is due on this session")
+		return
+	}
+
+	now := time.Now()
+	release := *session.Release
+	release.FeeStatus = models.FeePaid
+	release.FeePaidAt = &now
+	release.FeePaidTo = &managerObjID
+
+	audit := auditEntry(c, models.AuditReleaseFeeCollected, venueName, &session.ID, map[string]interface{}{
+		"fee_amount":   release.FeeAmount,
+		"fee_currency": release.FeeCurrency,
+	})
+
+	// Only the version that was checked can be marked paid, so a fee is collected once
+	var updated models.ParkingSession
+	err = h.db.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = h.db.Sessions().Apply(ctx,
+			store.SessionFilter{ID: session.ID, Version: &session.Version},
+			store.SessionUpdate{Release: &release},
+		)
+		if err != nil {
+			return err
+		}
+		return h.db.AuditLogs().Insert(ctx, audit)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		apierr.Abort(c, http.StatusConflict, apierr.ConcurrentUpdate, "Session changed while collecting the fee, please retry")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to record the fee")
+		return
+	}
+
+	c.Header("ETag", sessionETag(updated))
+	c.JSON(http.StatusOK, gin.H{
+		"message":        localized(c, "RELEASE_FEE_COLLECTED"),
+		"manual_release": release,
+	})
+}
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReportHandler struct {
+	db *store.Store
+}
+
+func NewReportHandler(database *store.Store) *ReportHandler {
+	return &ReportHandler{db: database}
+}
+
+// reportRange reads the inclusive from/to dates (YYYY-MM-DD) of a report,
+// defaulting to the last 30 days. It writes the error response itself.
+func reportRange(c *gin.Context) (time.Time, time.Time, bool) {
+	today := time.Now().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -29), today
+
+	if s := c.Query("from"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid 'from' date. Use YYYY-MM-DD")
+			return from, to, false
+		}
+		from = t
+	}
+	if s := c.Query("to"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid 'to' date. Use YYYY-MM-DD")
+			return from, to, false
+		}
+		to = t
+	}
+	if to.Before(from) {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "'to' must not be before 'from'")
+		return from, to, false
+	}
+
+	// Make the end of the range exclusive so the whole 'to' day is included
+	return from, to.AddDate(0, 0, 1), true
+}
+
+// valetNames looks up the names of the given valets with a single query
+func valetNames(ctx context.Context, database *store.Store, ids []primitive.ObjectID) (map[primitive.ObjectID]string, error) {
+	names := make(map[primitive.ObjectID]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	users, err := database.Users().Find(ctx, store.UserFilter{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+	return names, nil
+}
+
+type valetRejections struct {
+	ValetID       primitive.ObjectID             `json:"valet_id"`
+	ValetName     string                         `json:"valet_name"`
+	TotalSessions int                            `json:"total_sessions"`
+	Rejected      int                            `json:"rejected"`
+	RejectionRate float64                        `json:"rejection_rate"`
+	Reasons       map[models.RejectionReason]int `json:"reasons"`
+}
+
+// RejectionMetrics returns how often customers rejected each valet's parking
+// requests at the caller's venue (manager only)
+func (h *ReportHandler) RejectionMetrics(c *gin.Context) {
+	from, to, ok := reportRange(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.Sessions().ValetStats(ctx, venueName, store.TimeRange{From: from, To: to})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ValetID)
+	}
+	names, err := valetNames(ctx, h.db, ids)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	valets := []valetRejections{}
+	var total, rejected int
+	for _, row := range rows {
+		entry := valetRejections{
+			ValetID:       row.ValetID,
+			ValetName:     names[row.ValetID],
+			TotalSessions: row.Total,
+			Rejected:      row.Rejected,
+			Reasons:       row.Reasons,
+		}
+		if row.Total > 0 {
+			entry.RejectionRate = float64(row.Rejected) / float64(row.Total)
+		}
+
+		total += row.Total
+		rejected += row.Rejected
+		valets = append(valets, entry)
+	}
+
+	// Worst offenders first
+	sort.Slice(valets, func(i, j int) bool {
+		if valets[i].RejectionRate != valets[j].RejectionRate {
+			return valets[i].RejectionRate > valets[j].RejectionRate
+		}
+		return valets[i].Rejected > valets[j].Rejected
+	})
+
+	var rate float64
+	if total > 0 {
+		rate = float64(rejected) / float64(total)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"venue_name":     venueName,
+		"from":           from.Format("2006-01-02"),
+		"to":             to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"total_sessions": total,
+		"rejected":       rejected,
+		"rejection_rate": rate,
+		"valets":         valets,
+	})
+}
+
+type valetRatings struct {
+	ValetID    primitive.ObjectID `json:"valet_id"`
+	ValetName  string             `json:"valet_name"`
+	Average    float64            `json:"average"`
+	Count      int                `json:"count"`
+	LowRatings int                `json:"low_ratings"`
+}
+
+// RatingMetrics returns the venue's customer rating score and each valet's
+// score over a date range (manager only)
+func (h *ReportHandler) RatingMetrics(c *gin.Context) {
+	from, to, ok := reportRange(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+	venue := loadVenue(ctx, h.db, venueName)
+
+	created := store.TimeRange{From: from, To: to}
+
+	scores, err := h.db.Ratings().ScoreCounts(ctx, venueName, created)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	distribution := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	var count, sum int
+	for score, n := range scores {
+		distribution[score] = n
+		count += n
+		sum += score * n
+	}
+	var average float64
+	if count > 0 {
+		average = float64(sum) / float64(count)
+	}
+
+	rows, err := h.db.Ratings().ValetScores(ctx, venueName, created, venue.LowRatingThreshold)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ValetID)
+	}
+	names, err := valetNames(ctx, h.db, ids)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	valets := make([]valetRatings, 0, len(rows))
+	for _, row := range rows {
+		valets = append(valets, valetRatings{
+			ValetID:    row.ValetID,
+			ValetName:  names[row.ValetID],
+			Average:    row.Average,
+			Count:      row.Count,
+			LowRatings: row.Low,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"venue_name":   venueName,
+		"from":         from.Format("2006-01-02"),
+		"to":           to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"average":      average,
+		"count":        count,
+		"distribution": distribution,
+		"valets":       valets,
+	})
+}
+
+// shiftRating is a rating as shown to the valet it was about: the score and
+// tags, but not who gave it or what they wrote
+type shiftRating struct {
+	Score     int       `json:"score"`
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShiftReport summarises one valet's work over a shift: cars parked and
+// delivered, rejected check-ins and the ratings they received. Valets get
+// their own report; managers can pass ?valet_id for anyone at their venue.
+// The shift is given as RFC 3339 from/to times and defaults to the last 12 hours.
+func (h *ReportHandler) ShiftReport(c *gin.Context) {
+	to := time.Now()
+	from := to.Add(-12 * time.Hour)
+	if s := c.Query("from"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid 'from' time. Use RFC 3339")
+			return
+		}
+		from = t
+	}
+	if s := c.Query("to"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid 'to' time. Use RFC 3339")
+			return
+		}
+		to = t
+	}
+	if !to.After(from) || to.Sub(from) > 24*time.Hour {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "A shift must end after it starts and last at most 24 hours")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	valetObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	if role == string(models.RoleManager) {
+		id, err := primitive.ObjectIDFromHex(c.Query("valet_id"))
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "valet_id is required")
+			return
+		}
+		valetObjID = id
+	}
+
+	valet, err := h.db.Users().FindOne(ctx, store.UserFilter{
+		ID:        valetObjID,
+		Role:      models.RoleValet,
+		VenueName: venueName,
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.ValetNotFound, "Valet not found at your venue")
+		return
+	}
+
+	window := &store.TimeRange{From: from, To: to}
+
+	parked, err := h.db.Sessions().Count(ctx, store.SessionFilter{ValetID: valet.ID, ParkedAt: window})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+	rejected, err := h.db.Sessions().Count(ctx, store.SessionFilter{
+		ValetID:  valet.ID,
+		ParkedAt: window,
+		Statuses: []models.SessionStatus{models.StatusRejected},
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+	delivered, err := h.db.Sessions().Count(ctx, store.SessionFilter{DeliveredBy: valet.ID, DeliveredAt: window})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	ratings, err := h.db.Ratings().Find(ctx, store.RatingFilter{ValetID: valet.ID, CreatedAt: window})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	venue := loadVenue(ctx, h.db, venueName)
+	lowRatings := []models.Rating{}
+	var sum int
+	for _, r := range ratings {
+		sum += r.Score
+		if r.Score <= venue.LowRatingThreshold {
+			lowRatings = append(lowRatings, r)
+		}
+	}
+
+	// Managers follow up on low ratings; valets must not learn who left them
+	var low interface{} = lowRatings
+	if role != string(models.RoleManager) {
+		summaries := make([]shiftRating, 0, len(lowRatings))
+		for _, r := range lowRatings {
+			summaries = append(summaries, shiftRating{Score: r.Score, Tags: r.Tags, CreatedAt: r.CreatedAt})
+		}
+		low = summaries
+	}
+	var average float64
+	if len(ratings) > 0 {
+		average = float64(sum) / float64(len(ratings))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valet_id":   valet.ID,
+		"valet_name": valet.Name,
+		"from":       from,
+		"to":         to,
+		"parked":     parked,
+		"delivered":  delivered,
+		"rejected":   rejected,
+		"ratings": gin.H{
+			"average": average,
+			"count":   len(ratings),
+			"low":     low,
+		},
+	})
+}
+
+type valetTips struct {
+	ValetID   primitive.ObjectID `json:"valet_id"`
+	ValetName string             `json:"valet_name"`
+	Tips      int                `json:"tips"`
+	Cash      int64              `json:"cash"`       // Already in the valet's hands
+	Online    int64              `json:"online"`     // Collected by the venue
+	PayoutDue int64              `json:"payout_due"` // Online tips the venue owes the valet
+}
+
+// TipPayouts returns each valet's share of tips over a date range. Managers
+// see every valet at their venue; valets see only their own line.
+func (h *ReportHandler) TipPayouts(c *gin.Context) {
+	from, to, ok := reportRange(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	// Managers see every valet; valets only their own line
+	var valetObjID primitive.ObjectID
+	role, _ := c.Get("role")
+	if role != string(models.RoleManager) {
+		userID, _ := c.Get("user_id")
+		valetObjID, _ = primitive.ObjectIDFromHex(userID.(string))
+	}
+
+	rows, err := h.db.Tips().ValetTotals(ctx, venueName, store.TimeRange{From: from, To: to}, valetObjID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ValetID)
+	}
+	names, err := valetNames(ctx, h.db, ids)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to build report")
+		return
+	}
+
+	valets := make([]valetTips, 0, len(rows))
+	var totalCash, totalOnline int64
+	for _, row := range rows {
+		entry := valetTips{
+			ValetID:   row.ValetID,
+			ValetName: names[row.ValetID],
+			Tips:      row.Tips,
+			Cash:      row.Cash,
+			Online:    row.Online,
+			PayoutDue: row.Online,
+		}
+		totalCash += row.Cash
+		totalOnline += row.Online
+		valets = append(valets, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"venue_name": venueName,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"currency":   loadVenue(ctx, h.db, venueName).Currency,
+		"cash":       totalCash,
+		"online":     totalOnline,
+		"valets":     valets,
+	})
+}
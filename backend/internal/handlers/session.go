@@ -2,26 +2,40 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
+	"slices"
 	"time"
 
-	"valet-parking-backend/internal/db"
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/middleware"
 	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/notify"
+	"valet-parking-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type SessionHandler struct {
-	db *db.MongoDB
+	db  *store.Store
+	sms notify.Sender
 }
 
-func NewSessionHandler(database *db.MongoDB) *SessionHandler {
-	return &SessionHandler{db: database}
+func NewSessionHandler(database *store.Store, sms notify.Sender) *SessionHandler {
+	return &SessionHandler{db: database, sms: sms}
+}
+
+// smsPickupOTP texts the session's customer a new pickup OTP, so they can
+// pass it on to whoever collects the car
+func (h *SessionHandler) smsPickupOTP(ctx context.Context, session models.ParkingSession, otp string) {
+	smsUser(ctx, h.db, h.sms, session.CustomerID, "SMS_PICKUP_OTP", map[string]string{
+		"otp":   otp,
+		"venue": session.VenueName,
+	})
 }
 
 type CreateSessionRequest struct {
@@ -32,7 +46,7 @@ type CreateSessionRequest struct {
 func (h *SessionHandler) CreateSession(c *gin.Context) {
 	var req CreateSessionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierr.BindError(c, err)
 		return
 	}
 
@@ -40,12 +54,12 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 	valetObjID, _ := primitive.ObjectIDFromHex(valetID.(string))
 	vehicleObjID, err := primitive.ObjectIDFromHex(req.VehicleID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vehicle ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid vehicle ID")
 		return
 	}
 	customerObjID, err := primitive.ObjectIDFromHex(req.CustomerID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid customer ID")
 		return
 	}
 
@@ -53,27 +67,30 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 	defer cancel()
 
 	// Get valet's venue name
-	var valet models.User
-	err = h.db.Users().FindOne(ctx, bson.M{"_id": valetObjID}).Decode(&valet)
+	valet, err := h.db.Users().FindByID(ctx, valetObjID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get valet info"})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get valet info")
 		return
 	}
 
 	if valet.VenueName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Valet has no venue assigned. Please update your profile."})
+		apierr.Abort(c, http.StatusBadRequest, apierr.VenueNotAssigned, "Valet has no venue assigned. Ask your venue manager to add you.")
 		return
 	}
 
-	// Check if vehicle already has an active session
-	var existing models.ParkingSession
-	err = h.db.Sessions().FindOne(ctx, bson.M{
-		"vehicle_id": vehicleObjID,
-		"status":     bson.M{"$nin": []models.SessionStatus{models.StatusDelivered, models.StatusCancelled}},
-	}).Decode(&existing)
-
-	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Vehicle already has an active parking session"})
+	// Vehicle must belong to the customer, not be archived and not have lost an ownership dispute
+	vehicleCount, err := h.db.Vehicles().Count(ctx, store.VehicleFilter{
+		ID:          vehicleObjID,
+		OwnerID:     customerObjID,
+		Active:      true,
+		NotRejected: true,
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get vehicle info")
+		return
+	}
+	if vehicleCount == 0 {
+		apierr.Abort(c, http.StatusNotFound, apierr.VehicleNotFound, "Vehicle not found for this customer")
 		return
 	}
 
@@ -89,14 +106,21 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 		VenueName:    valet.VenueName, // Use valet's assigned venue
 		Status:       models.StatusPending, // Waiting for customer acceptance
 		ParkedAt:     time.Now(),
+		Version:      1,
 	}
 
-	_, err = h.db.Sessions().InsertOne(ctx, session)
+	// The store allows one active session per vehicle, even under concurrent check-ins
+	err = h.db.Sessions().Insert(ctx, session)
+	if errors.Is(err, store.ErrDuplicate) {
+		apierr.Abort(c, http.StatusConflict, apierr.ActiveSessionExists, "Vehicle already has an active parking session")
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to create session")
 		return
 	}
 
+	c.Header("ETag", sessionETag(session))
 	c.JSON(http.StatusCreated, session)
 }
 
@@ -108,38 +132,35 @@ func (h *SessionHandler) GetActiveSession(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var filter bson.M
+	filter := store.SessionFilter{NotStatuses: models.ClosedStatuses}
 	if role == string(models.RoleCustomer) {
-		filter = bson.M{
-			"customer_id": userObjID,
-			"status":      bson.M{"$nin": []models.SessionStatus{models.StatusDelivered, models.StatusCancelled}},
+		// Includes sessions for vehicles shared with the customer
+		access, err := customerAccess(ctx, h.db, c, "")
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch session")
+			return
 		}
+		filter.Access = access
 	} else {
-		filter = bson.M{
-			"valet_id": userObjID,
-			"status":   bson.M{"$nin": []models.SessionStatus{models.StatusDelivered, models.StatusCancelled}},
-		}
+		filter.ValetID = userObjID
 	}
 
-	var session models.ParkingSession
-	err := h.db.Sessions().FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "parked_at", Value: -1}})).Decode(&session)
-
+	session, err := h.db.Sessions().FindOne(ctx, filter)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No active session found"})
+		apierr.Abort(c, http.StatusNotFound, apierr.NoActiveSession, "No active session found")
 		return
 	}
+	// View-only shares can follow the car but not collect it
+	redactPickupOTP(ctx, h.db, c, &session)
 
 	// Get vehicle details
-	var vehicle models.Vehicle
-	_ = h.db.Vehicles().FindOne(ctx, bson.M{"_id": session.VehicleID}).Decode(&vehicle)
+	vehicle, _ := h.db.Vehicles().FindByID(ctx, session.VehicleID)
 
 	// Get customer details
-	var customer models.User
-	_ = h.db.Users().FindOne(ctx, bson.M{"_id": session.CustomerID}).Decode(&customer)
+	customer, _ := h.db.Users().FindByID(ctx, session.CustomerID)
 
 	// Get valet details
-	var valet models.User
-	_ = h.db.Users().FindOne(ctx, bson.M{"_id": session.ValetID}).Decode(&valet)
+	valet, _ := h.db.Users().FindByID(ctx, session.ValetID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"session":  session,
@@ -150,34 +171,31 @@ func (h *SessionHandler) GetActiveSession(c *gin.Context) {
 }
 
 func (h *SessionHandler) GetSession(c *gin.Context) {
-	sessionID := c.Param("id")
-	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
-		return
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var session models.ParkingSession
-	err = h.db.Sessions().FindOne(ctx, bson.M{"_id": sessionObjID}).Decode(&session)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+	redactPickupOTP(ctx, h.db, c, &session)
+
+	// Clients send the ETag back in If-Match to make their next change conditional
+	etag := sessionETag(session)
+	c.Header("ETag", etag)
+	if notModified(c, etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
 	// Get vehicle details
-	var vehicle models.Vehicle
-	_ = h.db.Vehicles().FindOne(ctx, bson.M{"_id": session.VehicleID}).Decode(&vehicle)
+	vehicle, _ := h.db.Vehicles().FindByID(ctx, session.VehicleID)
 
 	// Get customer details
-	var customer models.User
-	_ = h.db.Users().FindOne(ctx, bson.M{"_id": session.CustomerID}).Decode(&customer)
+	customer, _ := h.db.Users().FindByID(ctx, session.CustomerID)
 
 	// Get valet details
-	var valet models.User
-	_ = h.db.Users().FindOne(ctx, bson.M{"_id": session.ValetID}).Decode(&valet)
+	valet, _ := h.db.Users().FindByID(ctx, session.ValetID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"session":  session,
@@ -191,7 +209,7 @@ func (h *SessionHandler) RequestPickup(c *gin.Context) {
 	sessionID := c.Param("id")
 	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
 		return
 	}
 
@@ -201,55 +219,173 @@ func (h *SessionHandler) RequestPickup(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Find session and verify ownership
-	var session models.ParkingSession
-	err = h.db.Sessions().FindOne(ctx, bson.M{
-		"_id":         sessionObjID,
-		"customer_id": userObjID,
-		"status":      models.StatusParked,
-	}).Decode(&session)
+	// Find session and verify the customer owns it or may request pickup for a shared vehicle
+	filter := store.SessionFilter{
+		ID:       sessionObjID,
+		Statuses: []models.SessionStatus{models.StatusParked},
+	}
+	if filter.Access, err = customerAccess(ctx, h.db, c, models.ShareRequestPickup); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to request pickup")
+		return
+	}
+	if !ifMatch(c, &filter) {
+		return
+	}
+	visible := store.SessionFilter{ID: sessionObjID, Access: filter.Access}
 
+	session, err := h.db.Sessions().FindOne(ctx, filter)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or already requested"})
+		sessionConflict(ctx, h.db, c, visible, filter.Versions, "Session not found")
 		return
 	}
 
-	// Generate pickup OTP
-	pickupOTP := fmt.Sprintf("%06d", rand.Intn(1000000))
+	// Generate pickup OTP valid for the venue's configured TTL
 	now := time.Now()
-	expiresAt := now.Add(30 * time.Minute)
-
-	// Update session
-	_, err = h.db.Sessions().UpdateOne(ctx,
-		bson.M{"_id": sessionObjID},
-		bson.M{
-			"$set": bson.M{
-				"status":         models.StatusRequested,
-				"requested_at":   now,
-				"pickup_otp":     pickupOTP,
-				"otp_expires_at": expiresAt,
-			},
+	pickupOTP, expiresAt := newPickupOTP(loadVenue(ctx, h.db, session.VenueName), now)
+
+	// Only a still-parked session moves to requested, so concurrent requests issue one OTP
+	updated, err := h.db.Sessions().Apply(ctx,
+		filter,
+		store.SessionUpdate{
+			Status:            models.StatusRequested,
+			RequestedAt:       &now,
+			RequestedBy:       &userObjID,
+			PickupOTP:         pickupOTP,
+			OTPExpiresAt:      &expiresAt,
+			ResetOTPRollovers: true,
 		},
 	)
+	if errors.Is(err, store.ErrNotFound) {
+		sessionConflict(ctx, h.db, c, visible, filter.Versions, "Session not found")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to request pickup")
+		return
+	}
+	h.smsPickupOTP(ctx, updated, pickupOTP)
+
+	c.Header("ETag", sessionETag(updated))
+	middleware.RedactReplay(c, "pickup_otp") // The customer reads it from the session instead
+	c.JSON(http.StatusOK, gin.H{
+		"message":    localized(c, "PICKUP_REQUESTED"),
+		"pickup_otp": pickupOTP,
+		"expires_at": expiresAt,
+	})
+}
+
+// newPickupOTP generates a pickup OTP and its expiry for the given venue
+func newPickupOTP(venue models.Venue, now time.Time) (string, time.Time) {
+	return fmt.Sprintf("%06d", rand.Intn(1000000)), now.Add(venue.PickupOTPTTL())
+}
 
+// RegeneratePickupOTP issues a fresh pickup OTP for a session whose pickup is already in progress
+func (h *SessionHandler) RegeneratePickupOTP(c *gin.Context) {
+	sessionID := c.Param("id")
+	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request pickup"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pickupStatuses := []models.SessionStatus{models.StatusRequested, models.StatusMoving, models.StatusAvailable}
+
+	// Find session and verify the customer owns it or may request pickup for a shared vehicle
+	filter := store.SessionFilter{
+		ID:       sessionObjID,
+		Statuses: pickupStatuses,
+	}
+	if filter.Access, err = customerAccess(ctx, h.db, c, models.ShareRequestPickup); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to regenerate OTP")
 		return
 	}
+	if !ifMatch(c, &filter) {
+		return
+	}
+	visible := store.SessionFilter{ID: sessionObjID, Access: filter.Access}
 
+	session, err := h.db.Sessions().FindOne(ctx, filter)
+	if err != nil {
+		sessionConflict(ctx, h.db, c, visible, filter.Versions, "Session not found")
+		return
+	}
+
+	pickupOTP, expiresAt := newPickupOTP(loadVenue(ctx, h.db, session.VenueName), time.Now())
+
+	updated, err := h.db.Sessions().Apply(ctx,
+		filter,
+		store.SessionUpdate{PickupOTP: pickupOTP, OTPExpiresAt: &expiresAt},
+	)
+	if errors.Is(err, store.ErrNotFound) {
+		sessionConflict(ctx, h.db, c, visible, filter.Versions, "Session not found")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to regenerate OTP")
+		return
+	}
+	h.smsPickupOTP(ctx, updated, pickupOTP)
+
+	c.Header("ETag", sessionETag(updated))
+	middleware.RedactReplay(c, "pickup_otp") // The customer reads it from the session instead
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Pickup requested successfully",
+		"message":    localized(c, "PICKUP_OTP_REGENERATED"),
 		"pickup_otp": pickupOTP,
 		"expires_at": expiresAt,
 	})
 }
 
+// rolloverPickupOTP replaces an expired pickup OTP when the venue policy allows it.
+// Returns the new expiry, or nil if the OTP was not rolled over.
+func (h *SessionHandler) rolloverPickupOTP(ctx context.Context, session models.ParkingSession) *time.Time {
+	venue := loadVenue(ctx, h.db, session.VenueName)
+	if venue.OTPRolloverPolicy == models.OTPRolloverManual || session.OTPRollovers >= venue.MaxOTPRollovers {
+		return nil
+	}
+
+	pickupOTP, expiresAt := newPickupOTP(venue, time.Now())
+
+	// Match on the old OTP so concurrent attempts only roll over once
+	matched, err := h.db.Sessions().Update(ctx,
+		store.SessionFilter{
+			ID:        session.ID,
+			Statuses:  []models.SessionStatus{session.Status},
+			PickupOTP: session.PickupOTP,
+		},
+		store.SessionUpdate{
+			PickupOTP:       pickupOTP,
+			OTPExpiresAt:    &expiresAt,
+			IncOTPRollovers: true,
+		},
+	)
+	if err != nil || !matched {
+		return nil
+	}
+	h.smsPickupOTP(ctx, session, pickupOTP)
+
+	return &expiresAt
+}
+
+type AcceptParkingRequest struct {
+	InspectionAcknowledged bool `json:"inspection_acknowledged"`
+}
+
 // AcceptParking allows customer to accept a pending parking session
 func (h *SessionHandler) AcceptParking(c *gin.Context) {
 	sessionID := c.Param("id")
 	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+		return
+	}
+
+	// Body is optional; older clients send none
+	var req AcceptParkingRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierr.BindError(c, err)
 		return
 	}
 
@@ -259,39 +395,83 @@ func (h *SessionHandler) AcceptParking(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Update session from pending to picked (valet will then update to parking_moving -> parked)
-	result, err := h.db.Sessions().UpdateOne(ctx,
-		bson.M{
-			"_id":         sessionObjID,
-			"customer_id": userObjID,
-			"status":      models.StatusPending,
-		},
-		bson.M{
-			"$set": bson.M{
-				"status": models.StatusPicked,
-			},
-		},
-	)
+	// The owner, or a driver the vehicle is shared with, may accept
+	filter := store.SessionFilter{
+		ID:       sessionObjID,
+		Statuses: []models.SessionStatus{models.StatusPending},
+	}
+	if filter.Access, err = customerAccess(ctx, h.db, c, models.ShareAcceptParking); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to accept parking")
+		return
+	}
+	if !ifMatch(c, &filter) {
+		return
+	}
+	visible := store.SessionFilter{ID: sessionObjID, Access: filter.Access}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept parking"})
+	// Only someone who may accept gets to see the inspection
+	if _, err := h.db.Sessions().FindOne(ctx, filter); err != nil {
+		sessionConflict(ctx, h.db, c, visible, filter.Versions, "Session not found")
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or already accepted"})
+	// If the valet recorded a check-in inspection, the customer must acknowledge it
+	checkIn, _ := findInspection(ctx, h.db, sessionObjID, models.InspectionCheckIn)
+	if checkIn != nil && !req.InspectionAcknowledged {
+		apierr.AbortWith(c, apierr.New(http.StatusBadRequest, apierr.InspectionNotAcknowledged,
+			"Please review and acknowledge the vehicle inspection before accepting").
+			With("inspection", checkIn))
+		return
+	}
+
+	// Update session from pending to picked (valet will then update to parking_moving -> parked)
+	updated, err := h.db.Sessions().Apply(ctx, filter, store.SessionUpdate{Status: models.StatusPicked})
+	if errors.Is(err, store.ErrNotFound) {
+		sessionConflict(ctx, h.db, c, visible, filter.Versions, "Session not found")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to accept parking")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Parking accepted"})
+	if checkIn != nil {
+		_ = h.db.Inspections().Acknowledge(ctx, checkIn.ID, userObjID, time.Now())
+	}
+
+	c.Header("ETag", sessionETag(updated))
+	c.JSON(http.StatusOK, gin.H{"message": localized(c, "PARKING_ACCEPTED")})
+}
+
+type RejectParkingRequest struct {
+	Reason string `json:"reason"` // not_my_vehicle, not_requested, changed_mind or other
+	Note   string `json:"note"`
 }
 
-// RejectParking allows customer to reject a pending parking session
+// RejectParking allows customer to reject a pending parking session. The
+// session is kept with the reason so wrong check-ins stay on record.
 func (h *SessionHandler) RejectParking(c *gin.Context) {
 	sessionID := c.Param("id")
 	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+		return
+	}
+
+	// Body is optional; older clients send none
+	var req RejectParkingRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierr.BindError(c, err)
+		return
+	}
+
+	reason := models.RejectionReason(req.Reason)
+	switch reason {
+	case "":
+		reason = models.RejectOther
+	case models.RejectNotMyVehicle, models.RejectNotRequested, models.RejectChangedMind, models.RejectOther:
+	default:
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid reason. Must be: not_my_vehicle, not_requested, changed_mind, or other")
 		return
 	}
 
@@ -301,24 +481,41 @@ func (h *SessionHandler) RejectParking(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Delete the pending session
-	result, err := h.db.Sessions().DeleteOne(ctx, bson.M{
-		"_id":         sessionObjID,
-		"customer_id": userObjID,
-		"status":      models.StatusPending,
-	})
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject parking"})
+	// The owner, or a driver the vehicle is shared with, may reject
+	filter := store.SessionFilter{
+		ID:       sessionObjID,
+		Statuses: []models.SessionStatus{models.StatusPending},
+	}
+	if filter.Access, err = customerAccess(ctx, h.db, c, models.ShareAcceptParking); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to reject parking")
 		return
 	}
+	if !ifMatch(c, &filter) {
+		return
+	}
+
+	rejection := models.Rejection{
+		Reason:     reason,
+		Note:       req.Note,
+		RejectedBy: userObjID,
+		RejectedAt: time.Now(),
+	}
 
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or already processed"})
+	updated, err := h.db.Sessions().Apply(ctx, filter, store.SessionUpdate{
+		Status:    models.StatusRejected,
+		Rejection: &rejection,
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		sessionConflict(ctx, h.db, c, store.SessionFilter{ID: sessionObjID, Access: filter.Access}, filter.Versions, "Session not found")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to reject parking")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Parking rejected"})
+	c.Header("ETag", sessionETag(updated))
+	c.JSON(http.StatusOK, gin.H{"message": localized(c, "PARKING_REJECTED")})
 }
 
 // CancelSession allows customer to cancel the entire parking session
@@ -326,7 +523,7 @@ func (h *SessionHandler) CancelSession(c *gin.Context) {
 	sessionID := c.Param("id")
 	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
 		return
 	}
 
@@ -337,35 +534,61 @@ func (h *SessionHandler) CancelSession(c *gin.Context) {
 	defer cancel()
 
 	// Only allow cancelling sessions that are not already delivered or in pickup process
-	result, err := h.db.Sessions().UpdateOne(ctx,
-		bson.M{
-			"_id":         sessionObjID,
-			"customer_id": userObjID,
-			"status": bson.M{"$in": []models.SessionStatus{
-				models.StatusPending,
-				models.StatusPicked,
-				models.StatusParkingMoving,
-				models.StatusParked,
-			}},
+	filter := store.SessionFilter{
+		ID:         sessionObjID,
+		CustomerID: userObjID,
+		Statuses: []models.SessionStatus{
+			models.StatusPending,
+			models.StatusPicked,
+			models.StatusParkingMoving,
+			models.StatusParked,
 		},
-		bson.M{
-			"$set": bson.M{
-				"status": models.StatusCancelled,
-			},
-		},
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel session"})
+	}
+	if !ifMatch(c, &filter) {
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or cannot be cancelled (pickup may already be in progress)"})
+	// Cancel and put any key tag back into the venue inventory together
+	var session models.ParkingSession
+	err = h.db.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		session, err = h.db.Sessions().Apply(ctx, filter, store.SessionUpdate{Status: models.StatusCancelled})
+		if err != nil {
+			return err
+		}
+		if session.KeyTag == nil || session.KeyTag.ReturnedAt != nil {
+			return nil
+		}
+		now := time.Now()
+		session, err = h.db.Sessions().Apply(ctx,
+			store.SessionFilter{ID: sessionObjID},
+			store.SessionUpdate{
+				KeyReturnedAt: &now,
+				Custody: &models.KeyCustodyEntry{
+					Action:    models.KeyReleased,
+					FromValet: session.KeyTag.HolderID,
+					Note:      "Session cancelled",
+					By:        userObjID,
+					At:        now,
+				},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		return h.db.KeyTags().Free(ctx, session.KeyTag.TagID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		sessionConflict(ctx, h.db, c, store.SessionFilter{ID: sessionObjID, CustomerID: userObjID}, filter.Versions, "Session not found")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to cancel session")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Session cancelled successfully"})
+	c.Header("ETag", sessionETag(session))
+	c.JSON(http.StatusOK, gin.H{"message": localized(c, "SESSION_CANCELLED")})
 }
 
 // CancelPickup allows customer to cancel a pickup request
@@ -373,63 +596,59 @@ func (h *SessionHandler) CancelPickup(c *gin.Context) {
 	sessionID := c.Param("id")
 	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
-
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Reset session back to parked status
-	result, err := h.db.Sessions().UpdateOne(ctx,
-		bson.M{
-			"_id":         sessionObjID,
-			"customer_id": userObjID,
-			"status":      bson.M{"$in": []models.SessionStatus{models.StatusRequested, models.StatusMoving}},
-		},
-		bson.M{
-			"$set": bson.M{
-				"status": models.StatusParked,
-			},
-			"$unset": bson.M{
-				"requested_at":   "",
-				"pickup_otp":     "",
-				"otp_expires_at": "",
-			},
-		},
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel pickup"})
+	filter := store.SessionFilter{
+		ID:       sessionObjID,
+		Statuses: []models.SessionStatus{models.StatusRequested, models.StatusMoving},
+	}
+	if filter.Access, err = customerAccess(ctx, h.db, c, models.ShareRequestPickup); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to cancel pickup")
+		return
+	}
+	if !ifMatch(c, &filter) {
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Cannot cancel - car may already be ready for pickup"})
+	// Reset session back to parked status
+	updated, err := h.db.Sessions().Apply(ctx, filter, store.SessionUpdate{
+		Status:      models.StatusParked,
+		ClearPickup: true,
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		sessionConflict(ctx, h.db, c, store.SessionFilter{ID: sessionObjID, Access: filter.Access}, filter.Versions, "Session not found")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to cancel pickup")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Pickup cancelled"})
+	c.Header("ETag", sessionETag(updated))
+	c.JSON(http.StatusOK, gin.H{"message": localized(c, "PICKUP_CANCELLED")})
 }
 
 type VerifyDeliveryRequest struct {
-	OTP string `json:"otp" binding:"required"`
+	OTP          string `json:"otp" binding:"required"`
+	KeyTagNumber string `json:"key_tag_number"` // Tag on the keys being handed back
 }
 
 func (h *SessionHandler) VerifyDelivery(c *gin.Context) {
 	sessionID := c.Param("id")
 	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID format")
 		return
 	}
 
 	var req VerifyDeliveryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierr.BindError(c, err)
 		return
 	}
 
@@ -437,66 +656,103 @@ func (h *SessionHandler) VerifyDelivery(c *gin.Context) {
 	defer cancel()
 
 	// First find the session by ID
-	var session models.ParkingSession
-	err = h.db.Sessions().FindOne(ctx, bson.M{"_id": sessionObjID}).Decode(&session)
+	filter := store.SessionFilter{ID: sessionObjID}
+	if !ifMatch(c, &filter) {
+		return
+	}
+	session, err := h.db.Sessions().FindOne(ctx, filter)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		sessionConflict(ctx, h.db, c, store.SessionFilter{ID: sessionObjID}, filter.Versions, "Session not found")
 		return
 	}
 
 	// Check if session is in valid status for delivery
-	validStatuses := map[models.SessionStatus]bool{
-		models.StatusRequested: true,
-		models.StatusMoving:    true,
-		models.StatusAvailable: true,
-		models.StatusInTransit: true,
+	deliverable := []models.SessionStatus{
+		models.StatusRequested,
+		models.StatusMoving,
+		models.StatusAvailable,
+		models.StatusInTransit,
 	}
-	if !validStatuses[session.Status] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot deliver - session status is '%s'. Customer must request pickup first.", session.Status)})
+	if !slices.Contains(deliverable, session.Status) {
+		apierr.Abort(c, http.StatusBadRequest, apierr.PickupNotRequested, fmt.Sprintf("Cannot deliver - session status is '%s'. Customer must request pickup first.", session.Status))
 		return
 	}
 
 	// Check if OTP exists
 	if session.PickupOTP == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No OTP found for this session. Customer must request pickup first."})
+		apierr.Abort(c, http.StatusBadRequest, apierr.PickupNotRequested, "No OTP found for this session. Customer must request pickup first.")
 		return
 	}
 
 	// Verify OTP
 	if session.PickupOTP != req.OTP {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP"})
+		apierr.Abort(c, http.StatusUnauthorized, apierr.OTPInvalid, "Invalid OTP")
 		return
 	}
 
-	// Check if OTP expired
+	// Check if OTP expired, rolling it over when the venue allows it
 	if session.OTPExpiresAt != nil && time.Now().After(*session.OTPExpiresAt) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP has expired. Customer needs to request pickup again."})
+		if expiresAt := h.rolloverPickupOTP(ctx, session); expiresAt != nil {
+			apierr.AbortWith(c, apierr.New(http.StatusUnauthorized, apierr.OTPExpired,
+				"OTP has expired. A new OTP has been issued to the customer.").
+				With("otp_rolled_over", true).
+				With("expires_at", expiresAt))
+			return
+		}
+		apierr.Abort(c, http.StatusUnauthorized, apierr.OTPExpired, "OTP has expired. Customer needs to regenerate the pickup OTP.")
 		return
 	}
 
+	keyOut, ok := checkKeyReturn(c, session, req.KeyTagNumber)
+	if !ok {
+		return
+	}
+
+	valetID, _ := c.Get("user_id")
+	valetObjID, _ := primitive.ObjectIDFromHex(valetID.(string))
+
 	// Update session to delivered
 	now := time.Now()
-	_, err = h.db.Sessions().UpdateOne(ctx,
-		bson.M{"_id": sessionObjID},
-		bson.M{
-			"$set": bson.M{
-				"status":       models.StatusDelivered,
-				"delivered_at": now,
-			},
-			"$unset": bson.M{
-				"pickup_otp":     "",
-				"otp_expires_at": "",
-			},
-		},
-	)
-
+	update := store.SessionUpdate{
+		Status:      models.StatusDelivered,
+		DeliveredAt: &now,
+		DeliveredBy: &valetObjID,
+		ClearOTP:    true,
+	}
+	if keyOut {
+		addKeyReturn(&update, session, valetObjID, now)
+	}
+
+	// Match on the version that was checked so a session is delivered at most once
+	var updated models.ParkingSession
+	err = h.db.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = h.db.Sessions().Apply(ctx,
+			store.SessionFilter{ID: sessionObjID, Version: &session.Version},
+			update,
+		)
+		if err != nil || !keyOut {
+			return err
+		}
+		return h.db.KeyTags().Free(ctx, session.KeyTag.TagID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		sessionConflict(ctx, h.db, c, store.SessionFilter{ID: sessionObjID}, filter.Versions, "Session not found")
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete delivery"})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to complete delivery")
 		return
 	}
+	smsUser(ctx, h.db, h.sms, updated.CustomerID, "SMS_VEHICLE_DELIVERED", map[string]string{
+		"ticket": updated.TicketNumber,
+		"venue":  updated.VenueName,
+	})
+
+	c.Header("ETag", sessionETag(updated))
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":      "Vehicle delivered successfully",
+		"message":      localized(c, "VEHICLE_DELIVERED"),
 		"delivered_at": now,
 	})
 }
@@ -511,13 +767,13 @@ func (h *SessionHandler) UpdateStatus(c *gin.Context) {
 	sessionID := c.Param("id")
 	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
 		return
 	}
 
 	var req UpdateStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierr.BindError(c, err)
 		return
 	}
 
@@ -530,7 +786,7 @@ func (h *SessionHandler) UpdateStatus(c *gin.Context) {
 		"available":      true,
 	}
 	if !validStatuses[req.Status] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Must be: picked, parking_moving, parked, moving, or available"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid status. Must be: picked, parking_moving, parked, moving, or available")
 		return
 	}
 
@@ -558,44 +814,52 @@ func (h *SessionHandler) UpdateStatus(c *gin.Context) {
 		allowedFromStatuses = []models.SessionStatus{models.StatusRequested, models.StatusMoving, models.StatusAvailable}
 	}
 
-	// Build update document
-	updateDoc := bson.M{
-		"status": models.SessionStatus(req.Status),
+	// Build update
+	update := store.SessionUpdate{
+		Status: models.SessionStatus(req.Status),
 	}
 
 	// Add parking spot if provided and status is "parked"
 	if req.Status == "parked" && req.ParkingSpot != "" {
-		updateDoc["parking_spot"] = req.ParkingSpot
+		update.ParkingSpot = req.ParkingSpot
 	}
 
 	// Update session status
-	result, err := h.db.Sessions().UpdateOne(ctx,
-		bson.M{
-			"_id":    sessionObjID,
-			"status": bson.M{"$in": allowedFromStatuses},
-		},
-		bson.M{
-			"$set": updateDoc,
-		},
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
+	filter := store.SessionFilter{ID: sessionObjID, Statuses: allowedFromStatuses}
+	if !ifMatch(c, &filter) {
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or cannot update status from current state"})
+	// Keys are tagged before the car is parked so they are checked on the way back
+	if req.Status == "parked" {
+		session, err := h.db.Sessions().FindOne(ctx, filter)
+		if err == nil && session.KeyTag == nil && loadVenue(ctx, h.db, session.VenueName).RequireKeyTag {
+			apierr.Abort(c, http.StatusConflict, apierr.KeyTagRequired, "Tag the keys before marking the car parked")
+			return
+		}
+	}
+
+	updated, err := h.db.Sessions().Apply(ctx, filter, update)
+	if errors.Is(err, store.ErrNotFound) {
+		sessionConflict(ctx, h.db, c, store.SessionFilter{ID: sessionObjID}, filter.Versions, "Session not found")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update status")
 		return
 	}
 
+	c.Header("ETag", sessionETag(updated))
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Status updated successfully",
+		"message": localized(c, "STATUS_UPDATED"),
 		"status":  req.Status,
 	})
 }
 
-// GetHistory returns completed and cancelled sessions for the user
+// GetHistory returns finished sessions for the user, a page at a time.
+// Customers see their delivered and cancelled sessions; valets also see the
+// requests customers rejected, and managers see every finished session at
+// their venue.
 func (h *SessionHandler) GetHistory(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	role, _ := c.Get("role")
@@ -604,141 +868,82 @@ func (h *SessionHandler) GetHistory(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var filter bson.M
-	if role == string(models.RoleCustomer) {
-		filter = bson.M{
-			"customer_id": userObjID,
-			"status":      bson.M{"$in": []models.SessionStatus{models.StatusDelivered, models.StatusCancelled}},
+	var filter store.SessionFilter
+	switch role {
+	case string(models.RoleCustomer):
+		filter = store.SessionFilter{
+			CustomerID: userObjID,
+			Statuses:   []models.SessionStatus{models.StatusDelivered, models.StatusCancelled},
 		}
-	} else {
-		filter = bson.M{
-			"valet_id": userObjID,
-			"status":   bson.M{"$in": []models.SessionStatus{models.StatusDelivered, models.StatusCancelled}},
+	case string(models.RoleManager):
+		venueName, ok := valetVenue(ctx, h.db, c)
+		if !ok {
+			return
+		}
+		filter = store.SessionFilter{
+			VenueName: venueName,
+			Statuses:  models.ClosedStatuses,
+		}
+	default:
+		filter = store.SessionFilter{
+			ValetID:  userObjID,
+			Statuses: models.ClosedStatuses,
 		}
 	}
 
-	cursor, err := h.db.Sessions().Find(ctx, filter, options.Find().SetSort(bson.D{bson.E{Key: "parked_at", Value: -1}}).SetLimit(50))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var sessions []models.ParkingSession
-	if err := cursor.All(ctx, &sessions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode sessions"})
-		return
-	}
-
-	// Enrich with vehicle and customer details
-	var results []gin.H
-	for _, session := range sessions {
-		var vehicle models.Vehicle
-		_ = h.db.Vehicles().FindOne(ctx, bson.M{"_id": session.VehicleID}).Decode(&vehicle)
-
-		var customer models.User
-		_ = h.db.Users().FindOne(ctx, bson.M{"_id": session.CustomerID}).Decode(&customer)
-
-		var valet models.User
-		_ = h.db.Users().FindOne(ctx, bson.M{"_id": session.ValetID}).Decode(&valet)
-
-		results = append(results, gin.H{
-			"session":  session,
-			"vehicle":  vehicle,
-			"customer": customer,
-			"valet":    valet,
-		})
-	}
-
-	if results == nil {
-		results = []gin.H{}
-	}
-
-	c.JSON(http.StatusOK, results)
+	h.sessionPage(ctx, c, filter, true)
 }
 
-// GetPendingPickups returns all sessions needing valet attention (requested, moving, available)
+// GetPendingPickups pages through sessions at the valet's venue needing
+// attention (requested, moving, available)
 func (h *SessionHandler) GetPendingPickups(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	cursor, err := h.db.Sessions().Find(ctx, bson.M{
-		"status": bson.M{"$in": []models.SessionStatus{models.StatusRequested, models.StatusMoving, models.StatusAvailable}},
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pickups"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var sessions []models.ParkingSession
-	if err := cursor.All(ctx, &sessions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode sessions"})
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
 		return
 	}
 
-	// Enrich with vehicle and customer details
-	var results []gin.H
-	for _, session := range sessions {
-		var vehicle models.Vehicle
-		_ = h.db.Vehicles().FindOne(ctx, bson.M{"_id": session.VehicleID}).Decode(&vehicle)
-
-		var customer models.User
-		_ = h.db.Users().FindOne(ctx, bson.M{"_id": session.CustomerID}).Decode(&customer)
-
-		results = append(results, gin.H{
-			"session":  session,
-			"vehicle":  vehicle,
-			"customer": customer,
-		})
-	}
-
-	if results == nil {
-		results = []gin.H{}
-	}
-
-	c.JSON(http.StatusOK, results)
+	h.sessionPage(ctx, c, store.SessionFilter{
+		VenueName: venueName,
+		Statuses:  []models.SessionStatus{models.StatusRequested, models.StatusMoving, models.StatusAvailable},
+	}, false)
 }
 
-// GetAllActiveSessions returns all active sessions for valet (pending, parked, requested, moving, available)
+// GetAllActiveSessions pages through all active sessions at the valet's venue
+// (pending, parked, requested, moving, available)
 func (h *SessionHandler) GetAllActiveSessions(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	cursor, err := h.db.Sessions().Find(ctx, bson.M{
-		"status": bson.M{"$nin": []models.SessionStatus{models.StatusDelivered, models.StatusCancelled}},
-	}, options.Find().SetSort(bson.D{bson.E{Key: "parked_at", Value: -1}}))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var sessions []models.ParkingSession
-	if err := cursor.All(ctx, &sessions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode sessions"})
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
 		return
 	}
 
-	// Enrich with vehicle and customer details
-	var results []gin.H
-	for _, session := range sessions {
-		var vehicle models.Vehicle
-		_ = h.db.Vehicles().FindOne(ctx, bson.M{"_id": session.VehicleID}).Decode(&vehicle)
-
-		var customer models.User
-		_ = h.db.Users().FindOne(ctx, bson.M{"_id": session.CustomerID}).Decode(&customer)
-
-		results = append(results, gin.H{
-			"session":  session,
-			"vehicle":  vehicle,
-			"customer": customer,
-		})
-	}
+	h.sessionPage(ctx, c, store.SessionFilter{
+		VenueName:   venueName,
+		NotStatuses: models.ClosedStatuses,
+	}, false)
+}
 
-	if results == nil {
-		results = []gin.H{}
+// sessionList shapes joined sessions the way the app reads them: each session
+// alongside its vehicle, customer and, if asked for, valet
+func sessionList(sessions []models.SessionWithDetails, withValet bool) []gin.H {
+	results := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		// Lists are for valets and past sessions; the OTP stays with the customer
+		s.PickupOTP = ""
+		entry := gin.H{
+			"session":  s.ParkingSession,
+			"vehicle":  s.Vehicle,
+			"customer": s.Customer,
+		}
+		if withValet {
+			entry["valet"] = s.Valet
+		}
+		results = append(results, entry)
 	}
-
-	c.JSON(http.StatusOK, results)
+	return results
 }
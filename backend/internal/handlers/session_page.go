@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	sessionPageDefault = 20
+	sessionPageMax     = 100
+)
+
+var listableStatuses = []models.SessionStatus{
+	models.StatusPending, models.StatusPicked, models.StatusParkingMoving, models.StatusParked,
+	models.StatusRequested, models.StatusMoving, models.StatusAvailable, models.StatusInTransit,
+	models.StatusDelivered, models.StatusCancelled, models.StatusRejected,
+}
+
+// queryLimit reads the ?limit page size, defaulting to fallback when absent.
+// It writes a 400 and returns false unless the limit is between 1 and max.
+func queryLimit(c *gin.Context, fallback, max int) (int, bool) {
+	s := c.Query("limit")
+	if s == "" {
+		return fallback, true
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > max {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, fmt.Sprintf("Invalid limit. Must be between 1 and %d", max))
+		return 0, false
+	}
+	return limit, true
+}
+
+// sessionPage writes one page of the sessions matching filter as
+// {items, next_cursor, has_more}, newest parked first. Callers may narrow the
+// list with ?status (comma-separated), ?from and ?to (inclusive YYYY-MM-DD
+// parking dates), ?venue, ?vehicle_id and ?ticket, set the page size with
+// ?limit and continue from an earlier page with ?cursor. It writes the error
+// response itself.
+func (h *SessionHandler) sessionPage(ctx context.Context, c *gin.Context, filter store.SessionFilter, withValet bool) {
+	limit, ok := queryLimit(c, sessionPageDefault, sessionPageMax)
+	if !ok {
+		return
+	}
+
+	if s := c.Query("cursor"); s != "" {
+		cursor, err := store.ParseSessionCursor(s)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid cursor")
+			return
+		}
+		filter.After = &cursor
+	}
+
+	if s := c.Query("status"); s != "" {
+		var statuses []models.SessionStatus
+		for _, part := range strings.Split(s, ",") {
+			status := models.SessionStatus(strings.TrimSpace(part))
+			if !slices.Contains(listableStatuses, status) {
+				apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid status: "+string(status))
+				return
+			}
+			// Statuses outside the endpoint's own scope simply match nothing
+			if filter.Statuses == nil || slices.Contains(filter.Statuses, status) {
+				statuses = append(statuses, status)
+			}
+		}
+		if statuses == nil {
+			statuses = []models.SessionStatus{}
+		}
+		filter.Statuses = statuses
+	}
+
+	if c.Query("from") != "" || c.Query("to") != "" {
+		var parked store.TimeRange
+		if s := c.Query("from"); s != "" {
+			t, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid 'from' date. Use YYYY-MM-DD")
+				return
+			}
+			parked.From = t
+		}
+		if s := c.Query("to"); s != "" {
+			t, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid 'to' date. Use YYYY-MM-DD")
+				return
+			}
+			parked.To = t.AddDate(0, 0, 1)
+		}
+		if !parked.To.IsZero() && !parked.To.After(parked.From) {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "'to' must not be before 'from'")
+			return
+		}
+		filter.ParkedAt = &parked
+	}
+
+	if venue := c.Query("venue"); venue != "" {
+		if filter.VenueName != "" && filter.VenueName != venue {
+			// Scoped to another venue already, so nothing can match
+			c.JSON(http.StatusOK, gin.H{"items": []gin.H{}, "next_cursor": nil, "has_more": false})
+			return
+		}
+		filter.VenueName = venue
+	}
+
+	if s := c.Query("vehicle_id"); s != "" {
+		vehicleObjID, err := primitive.ObjectIDFromHex(s)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid vehicle ID")
+			return
+		}
+		filter.VehicleID = vehicleObjID
+	}
+
+	filter.TicketNumber = c.Query("ticket")
+
+	// One extra row tells whether another page follows
+	sessions, err := h.db.Sessions().FindWithDetails(ctx, filter, int64(limit)+1)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch sessions")
+		return
+	}
+
+	hasMore := len(sessions) > limit
+	var nextCursor any
+	if hasMore {
+		sessions = sessions[:limit]
+		nextCursor = store.CursorAfter(sessions[limit-1].ParkingSession).String()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":       sessionList(sessions, withValet),
+		"next_cursor": nextCursor,
+		"has_more":    hasMore,
+	})
+}
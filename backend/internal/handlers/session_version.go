@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionETag is the entity tag of one version of a session
+func sessionETag(session models.ParkingSession) string {
+	return `"` + strconv.FormatInt(session.Version, 10) + `"`
+}
+
+// entityTag is one element of an If-Match or If-None-Match list
+type entityTag struct {
+	weak   bool
+	opaque string // The quoted tag, quotes included
+}
+
+// parseETags splits a comma-separated list of entity tags, returning false if
+// an element is not a quoted tag with an optional W/ prefix
+func parseETags(header string) ([]entityTag, bool) {
+	var tags []entityTag
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		weak := strings.HasPrefix(tag, "W/")
+		opaque := strings.TrimPrefix(tag, "W/")
+		if len(opaque) < 2 || opaque[0] != '"' || opaque[len(opaque)-1] != '"' || strings.Contains(opaque[1:len(opaque)-1], `"`) {
+			return nil, false
+		}
+		tags = append(tags, entityTag{weak: weak, opaque: opaque})
+	}
+	return tags, true
+}
+
+// ifMatch limits filter to the session versions named in the If-Match
+// header, so a client acting on an outdated view changes nothing. A missing
+// header or "*" leaves the filter alone. The header may list several tags;
+// If-Match uses strong comparison, so weak tags and tags that are not session
+// versions never match. It writes a 400 and returns false if the header is
+// not a list of entity tags.
+func ifMatch(c *gin.Context, filter *store.SessionFilter) bool {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return true
+	}
+
+	tags, ok := parseETags(header)
+	if !ok {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid If-Match header")
+		return false
+	}
+	versions := []int64{}
+	for _, tag := range tags {
+		version, err := strconv.ParseInt(tag.opaque[1:len(tag.opaque)-1], 10, 64)
+		if tag.weak || err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	filter.Versions = versions
+	return true
+}
+
+// notModified reports whether the If-None-Match header names etag. It uses
+// weak comparison, so W/"3" matches "3", and "*" matches any version. A
+// malformed header matches nothing and the full response is sent.
+func notModified(c *gin.Context, etag string) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "*" {
+		return true
+	}
+	tags, _ := parseETags(header)
+	for _, tag := range tags {
+		if tag.opaque == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionConflict answers a guarded session read or update that matched
+// nothing. It looks the session up through visible, the caller's view of it
+// without the status and version guards, and responds 404 with notFound if
+// the caller cannot see it, 412 if it is not at an If-Match version, or
+// 409 because it has moved on to a state that no longer allows the change.
+func sessionConflict(ctx context.Context, database *store.Store, c *gin.Context, visible store.SessionFilter, expected []int64, notFound string) {
+	session, err := database.Sessions().FindOne(ctx, visible)
+	if errors.Is(err, store.ErrNotFound) {
+		apierr.Abort(c, http.StatusNotFound, apierr.SessionNotFound, notFound)
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch session")
+		return
+	}
+
+	c.Header("ETag", sessionETag(session))
+	if expected != nil && !slices.Contains(expected, session.Version) {
+		apierr.AbortWith(c, apierr.New(http.StatusPreconditionFailed, apierr.PreconditionFailed,
+			"Session was changed by someone else, please refresh").
+			With("session_status", session.Status).
+			With("version", session.Version))
+		return
+	}
+	apierr.AbortWith(c, apierr.New(http.StatusConflict, apierr.InvalidTransition,
+		fmt.Sprintf("Session is already %s, please refresh", session.Status)).
+		With("session_status", session.Status).
+		With("version", session.Version))
+}
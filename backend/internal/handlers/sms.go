@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"valet-parking-backend/internal/i18n"
+	"valet-parking-backend/internal/notify"
+	"valet-parking-backend/internal/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sendSMS texts phone the template key in lang. Failures are logged so they
+// never fail the request that triggered the message.
+func sendSMS(ctx context.Context, sms notify.Sender, phone string, lang i18n.Lang, key string, params map[string]string) {
+	if err := sms.SendSMS(ctx, phone, i18n.Format(lang, key, params)); err != nil {
+		log.Printf("sms: failed to send %s to %s: %v", key, phone, err)
+	}
+}
+
+// smsUser texts a user the template key in their preferred language
+func smsUser(ctx context.Context, database *store.Store, sms notify.Sender, userID primitive.ObjectID, key string, params map[string]string) {
+	user, err := database.Users().FindByID(ctx, userID)
+	if err != nil {
+		log.Printf("sms: failed to find user %s for %s: %v", userID.Hex(), key, err)
+		return
+	}
+	sendSMS(ctx, sms, user.Phone, i18n.Resolve(user.PreferredLanguage, ""), key, params)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TimelineEvent is one entry in a session's history
+type TimelineEvent struct {
+	At      time.Time           `json:"at"`
+	Type    string              `json:"type"`
+	ActorID *primitive.ObjectID `json:"actor_id,omitempty"`
+	RefID   *primitive.ObjectID `json:"ref_id,omitempty"` // Inspection, incident, rating or tip behind the event
+	Details gin.H               `json:"details,omitempty"`
+}
+
+// GetTimeline returns everything that happened to a session in time order:
+// status milestones, inspections, key custody, incidents, ratings and tips
+func (h *SessionHandler) GetTimeline(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+	redactPickupOTP(ctx, h.db, c, &session)
+
+	events := []TimelineEvent{{
+		At:      session.ParkedAt,
+		Type:    "session_created",
+		ActorID: &session.ValetID,
+		Details: gin.H{"ticket_number": session.TicketNumber},
+	}}
+
+	if session.Rejection != nil {
+		events = append(events, TimelineEvent{
+			At:      session.Rejection.RejectedAt,
+			Type:    "rejected",
+			ActorID: &session.Rejection.RejectedBy,
+			Details: gin.H{"reason": session.Rejection.Reason, "note": session.Rejection.Note},
+		})
+	}
+	for _, k := range session.KeyCustody {
+		by := k.By
+		events = append(events, TimelineEvent{
+			At:      k.At,
+			Type:    "key_" + string(k.Action),
+			ActorID: &by,
+			Details: gin.H{"location": k.Location, "to_valet": k.ToValet, "note": k.Note},
+		})
+	}
+	if session.RequestedAt != nil {
+		events = append(events, TimelineEvent{At: *session.RequestedAt, Type: "pickup_requested", ActorID: session.RequestedBy})
+	}
+	if session.Release != nil {
+		events = append(events, TimelineEvent{
+			At:      session.Release.ReleasedAt,
+			Type:    "manual_release",
+			ActorID: &session.Release.ApprovedBy,
+			Details: gin.H{"reason": session.Release.Reason},
+		})
+	} else if session.DeliveredAt != nil {
+		events = append(events, TimelineEvent{At: *session.DeliveredAt, Type: "delivered", ActorID: session.DeliveredBy})
+	}
+
+	inspections, _ := h.db.Inspections().ListBySession(ctx, session.ID)
+	for _, i := range inspections {
+		id, inspector := i.ID, i.InspectorID
+		events = append(events, TimelineEvent{
+			At:      i.CreatedAt,
+			Type:    "inspection_" + string(i.Stage),
+			ActorID: &inspector,
+			RefID:   &id,
+			Details: gin.H{"damage_markers": len(i.DamageMarkers)},
+		})
+		if i.AcknowledgedAt != nil {
+			events = append(events, TimelineEvent{At: *i.AcknowledgedAt, Type: "inspection_acknowledged", ActorID: i.AcknowledgedBy, RefID: &id})
+		}
+	}
+
+	incidents, _ := h.db.Incidents().Find(ctx, store.IncidentFilter{SessionID: session.ID}, 0)
+	for _, inc := range incidents {
+		id := inc.ID
+		for n, e := range inc.History {
+			by := e.By
+			eventType := "incident_" + string(e.Status)
+			if n == 0 {
+				eventType = "incident_filed"
+			}
+			events = append(events, TimelineEvent{
+				At:      e.At,
+				Type:    eventType,
+				ActorID: &by,
+				RefID:   &id,
+				Details: gin.H{"type": inc.Type, "severity": inc.Severity, "note": e.Note},
+			})
+		}
+	}
+
+	if rating, err := h.db.Ratings().FindOne(ctx, store.RatingFilter{SessionID: session.ID}); err == nil {
+		events = append(events, TimelineEvent{
+			At:      rating.CreatedAt,
+			Type:    "rated",
+			ActorID: &rating.CustomerID,
+			RefID:   &rating.ID,
+			Details: gin.H{"score": rating.Score},
+		})
+	}
+
+	tips, _ := h.db.Tips().ListBySession(ctx, session.ID)
+	for _, t := range tips {
+		id, by := t.ID, t.RecordedBy
+		events = append(events, TimelineEvent{
+			At:      t.CreatedAt,
+			Type:    "tip",
+			ActorID: &by,
+			RefID:   &id,
+			Details: gin.H{"amount": t.Amount, "currency": t.Currency, "method": t.Method, "status": t.Status},
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+		"events":  events,
+	})
+}
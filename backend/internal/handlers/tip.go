@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/payment"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxTipAmount caps a single tip, in the smallest currency unit
+const maxTipAmount = 500000
+
+type TipHandler struct {
+	db            *store.Store
+	payments      payment.Provider // nil when online tips are disabled
+	webhookSecret []byte
+}
+
+func NewTipHandler(database *store.Store, payments payment.Provider, webhookSecret string) *TipHandler {
+	return &TipHandler{db: database, payments: payments, webhookSecret: []byte(webhookSecret)}
+}
+
+type AddTipRequest struct {
+	Amount int64 `json:"amount" binding:"required"` // In the smallest currency unit, e.g. paise
+}
+
+// AddTip attaches a tip to a delivered session. Customers pay online through
+// the payment provider; valets record cash they were handed, once per session
+// and with an audit entry for managers to review.
+func (h *TipHandler) AddTip(c *gin.Context) {
+	sessionObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid session ID")
+		return
+	}
+
+	var req AddTipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+	if req.Amount <= 0 || req.Amount > maxTipAmount {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Tip amount is out of range")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	filter := store.SessionFilter{ID: sessionObjID}
+	method := models.TipOnline
+	if role == string(models.RoleCustomer) {
+		if h.payments == nil {
+			apierr.Abort(c, http.StatusServiceUnavailable, apierr.PaymentUnavailable, "Online tips are disabled: no payment provider is configured")
+			return
+		}
+		filter.CustomerID = userObjID
+	} else {
+		venueName, ok := valetVenue(ctx, h.db, c)
+		if !ok {
+			return
+		}
+		filter.VenueName = venueName
+		method = models.TipCash
+	}
+
+	session, err := h.db.Sessions().FindOne(ctx, filter)
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.SessionNotFound, "Session not found")
+		return
+	}
+	if session.Status != models.StatusDelivered {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidTransition, "Tips can only be added once the car has been delivered")
+		return
+	}
+
+	retriever := session.ValetID
+	if session.DeliveredBy != nil {
+		retriever = *session.DeliveredBy
+	}
+	venue := loadVenue(ctx, h.db, session.VenueName)
+
+	tip := models.Tip{
+		ID:         primitive.NewObjectID(),
+		SessionID:  session.ID,
+		VenueName:  session.VenueName,
+		CustomerID: session.CustomerID,
+		Amount:     req.Amount,
+		Currency:   venue.Currency,
+		Method:     method,
+		Status:     models.TipRecorded,
+		Shares:     venue.SplitTip(req.Amount, session.ValetID, retriever),
+		RecordedBy: userObjID,
+		CreatedAt:  time.Now(),
+	}
+	if method == models.TipOnline {
+		tip.Status = models.TipPending
+	}
+
+	// Store the tip before charging so every payment attempt can be reconciled
+	err = h.db.Tips().Insert(ctx, tip)
+	if errors.Is(err, store.ErrDuplicate) {
+		apierr.Abort(c, http.StatusConflict, apierr.CashTipExists, "A cash tip has already been recorded for this session")
+		return
+	}
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to save tip")
+		return
+	}
+
+	if method == models.TipCash {
+		recordAudit(ctx, h.db, c, models.AuditCashTipRecorded, session.VenueName, &session.ID, map[string]interface{}{
+			"tip_id":   tip.ID.Hex(),
+			"amount":   tip.Amount,
+			"currency": tip.Currency,
+		})
+	}
+
+	if method == models.TipOnline {
+		result, err := h.payments.Charge(ctx, payment.Charge{
+			Amount:      tip.Amount,
+			Currency:    tip.Currency,
+			CustomerID:  userObjID.Hex(),
+			Description: "Valet tip for ticket " + session.TicketNumber,
+			Reference:   tip.ID.Hex(),
+		})
+		if errors.Is(err, payment.ErrDeclined) {
+			_ = h.db.Tips().SetStatus(ctx, tip.ID, models.TipFailed, "")
+			apierr.Abort(c, http.StatusPaymentRequired, apierr.PaymentDeclined, "Payment was declined")
+			return
+		}
+		if err != nil {
+			// A timeout or gateway error does not say whether money was taken,
+			// so the tip stays pending until the webhook settles it
+			log.Printf("payment: tip %s outcome unknown: %v", tip.ID.Hex(), err)
+			c.JSON(http.StatusAccepted, tip)
+			return
+		}
+
+		tip.PaymentRef = result.ProviderRef
+		if result.Status == payment.StatusCaptured {
+			tip.Status = models.TipCaptured
+		}
+		if err := h.db.Tips().SetStatus(ctx, tip.ID, tip.Status, tip.PaymentRef); err != nil {
+			log.Printf("payment: tip %s charged as %s but not updated: %v", tip.ID.Hex(), tip.PaymentRef, err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, tip)
+}
+
+// PaymentWebhook settles a pending online tip when the payment provider
+// confirms or abandons the charge. The provider signs the raw body with the
+// shared webhook secret; redelivered confirmations are answered idempotently.
+func (h *TipHandler) PaymentWebhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidRequest, "Failed to read request body")
+		return
+	}
+	if !payment.Verify(h.webhookSecret, body, c.GetHeader("X-Payment-Signature")) {
+		apierr.Abort(c, http.StatusUnauthorized, apierr.SignatureInvalid, "Invalid or missing X-Payment-Signature")
+		return
+	}
+
+	var confirmation payment.Confirmation
+	if err := json.Unmarshal(body, &confirmation); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidRequest, "Invalid JSON body")
+		return
+	}
+	tipObjID, err := primitive.ObjectIDFromHex(confirmation.Reference)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid payment reference")
+		return
+	}
+
+	var status models.TipStatus
+	switch confirmation.Status {
+	case payment.StatusCaptured:
+		status = models.TipCaptured
+	case payment.StatusFailed:
+		status = models.TipFailed
+	default:
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Status must be 'captured' or 'failed'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = h.db.Tips().Settle(ctx, tipObjID, status, confirmation.ProviderRef)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update tip")
+		return
+	}
+
+	tip, findErr := h.db.Tips().FindByID(ctx, tipObjID)
+	if findErr != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.TipNotFound, "Tip not found")
+		return
+	}
+	// Not pending any more: fine if this is a redelivery, a conflict otherwise
+	if err != nil && tip.Status != status {
+		apierr.Abort(c, http.StatusConflict, apierr.InvalidTransition, "Tip is already '"+string(tip.Status)+"'")
+		return
+	}
+
+	c.JSON(http.StatusOK, tip)
+}
+
+// ListTips returns the tips given on a session
+func (h *TipHandler) ListTips(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, ok := findSessionForCaller(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	tips, err := h.db.Tips().ListBySession(ctx, session.ID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch tips")
+		return
+	}
+
+	if tips == nil {
+		tips = []models.Tip{}
+	}
+
+	c.JSON(http.StatusOK, tips)
+}
@@ -3,22 +3,47 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-	"valet-parking-backend/internal/db"
+	"valet-parking-backend/internal/anpr"
+	"valet-parking-backend/internal/apierr"
 	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/plate"
+	"valet-parking-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type VehicleHandler struct {
-	db *db.MongoDB
+	db             *store.Store
+	plateCountry   string
+	recognizer     anpr.PlateRecognizer
+	maxUploadBytes int64
+	visits         *visitCounts
 }
 
-func NewVehicleHandler(database *db.MongoDB) *VehicleHandler {
-	return &VehicleHandler{db: database}
+func NewVehicleHandler(database *store.Store, plateCountry string, recognizer anpr.PlateRecognizer, maxUploadBytes int64) *VehicleHandler {
+	return &VehicleHandler{
+		db:             database,
+		plateCountry:   plateCountry,
+		recognizer:     recognizer,
+		maxUploadBytes: maxUploadBytes,
+		visits:         newVisitCounts(),
+	}
+}
+
+// parseRegistration normalizes and validates a registration number, returning
+// the normalized form and the display form
+func (h *VehicleHandler) parseRegistration(c *gin.Context, registration string) (string, string, bool) {
+	normalized := plate.Normalize(registration)
+	if err := plate.Validate(h.plateCountry, normalized); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidRegistration, "Invalid registration number: "+err.Error())
+		return "", "", false
+	}
+	return normalized, plate.Format(h.plateCountry, normalized), true
 }
 
 type AddVehicleRequest struct {
@@ -33,14 +58,19 @@ type AddVehicleRequest struct {
 func (h *VehicleHandler) AddVehicle(c *gin.Context) {
 	var req AddVehicleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierr.BindError(c, err)
 		return
 	}
 
 	userID, _ := c.Get("user_id")
 	ownerID, err := primitive.ObjectIDFromHex(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid user ID")
+		return
+	}
+
+	normalizedReg, displayReg, ok := h.parseRegistration(c, req.RegistrationNumber)
+	if !ok {
 		return
 	}
 
@@ -48,14 +78,18 @@ func (h *VehicleHandler) AddVehicle(c *gin.Context) {
 	defer cancel()
 
 	// Check if vehicle already exists for this owner
-	var existing models.Vehicle
-	err = h.db.Vehicles().FindOne(ctx, bson.M{
-		"owner_id":            ownerID,
-		"registration_number": req.RegistrationNumber,
-	}).Decode(&existing)
-
+	_, err = h.db.Vehicles().FindOne(ctx, store.VehicleFilter{
+		OwnerID:       ownerID,
+		NormalizedReg: normalizedReg,
+		Active:        true,
+	})
 	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Vehicle already registered"})
+		apierr.Abort(c, http.StatusConflict, apierr.VehicleAlreadyRegistered, "Vehicle already registered")
+		return
+	}
+
+	// Photos must be vehicle media uploaded by this customer
+	if !checkMediaRefs(ctx, h.db, c, ownerID, models.MediaKindVehicle, req.Photos) {
 		return
 	}
 
@@ -65,21 +99,45 @@ func (h *VehicleHandler) AddVehicle(c *gin.Context) {
 		vehicleType = models.VehicleTypeCar // Default to car
 	}
 
+	// The customer's first vehicle becomes their default
+	vehicleCount, err := h.db.Vehicles().Count(ctx, store.VehicleFilter{
+		OwnerID: ownerID,
+		Active:  true,
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to add vehicle")
+		return
+	}
+
 	vehicle := models.Vehicle{
 		ID:                 primitive.NewObjectID(),
 		OwnerID:            ownerID,
-		RegistrationNumber: req.RegistrationNumber,
+		RegistrationNumber: displayReg,
+		NormalizedReg:      normalizedReg,
 		Make:               req.Make,
 		Model:              req.Model,
 		Color:              req.Color,
 		VehicleType:        vehicleType,
 		Photos:             req.Photos,
+		IsDefault:          vehicleCount == 0,
 		CreatedAt:          time.Now(),
 	}
 
-	_, err = h.db.Vehicles().InsertOne(ctx, vehicle)
+	// Another customer may already have registered this plate
+	openDispute, err := h.checkPlateOwnership(ctx, &vehicle)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to add vehicle")
+		return
+	}
+
+	err = h.db.Vehicles().Insert(ctx, vehicle)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add vehicle"})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to add vehicle")
+		return
+	}
+
+	if err := openDispute(); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to record ownership dispute")
 		return
 	}
 
@@ -90,23 +148,22 @@ func (h *VehicleHandler) ListVehicles(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	ownerID, err := primitive.ObjectIDFromHex(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid user ID")
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	cursor, err := h.db.Vehicles().Find(ctx, bson.M{"owner_id": ownerID})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vehicles"})
-		return
+	filter := store.VehicleFilter{
+		OwnerID: ownerID,
+		Active:  c.Query("include_archived") != "true",
 	}
-	defer cursor.Close(ctx)
 
-	var vehicles []models.Vehicle
-	if err := cursor.All(ctx, &vehicles); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode vehicles"})
+	// Default vehicle first, then newest
+	vehicles, err := h.db.Vehicles().Find(ctx, filter, store.VehicleOrderDefaultFirst, 0)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch vehicles")
 		return
 	}
 
@@ -121,29 +178,492 @@ func (h *VehicleHandler) ListVehicles(c *gin.Context) {
 func (h *VehicleHandler) GetVehicleByRegistration(c *gin.Context) {
 	regNumber := c.Query("registration_number")
 	if regNumber == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Registration number required"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Registration number required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Several customers may claim the same plate; return all of them, verified owner first
+	candidates, err := h.plateCandidates(ctx, store.VehicleFilter{
+		NormalizedReg: plate.Normalize(regNumber),
+		Active:        true,
+		NotRejected:   true,
+	})
+
+	if err != nil || len(candidates) == 0 {
+		apierr.Abort(c, http.StatusNotFound, apierr.VehicleNotFound, "Vehicle not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"vehicle":    candidates[0].Vehicle,
+		"owner":      candidates[0].Owner,
+		"candidates": candidates,
+		"disputed":   len(candidates) > 1,
+	})
+}
+
+// findOwnedVehicle loads a vehicle by the :id path parameter, scoped to the calling customer
+func (h *VehicleHandler) findOwnedVehicle(ctx context.Context, c *gin.Context) (models.Vehicle, bool) {
+	vehicleObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid vehicle ID")
+		return models.Vehicle{}, false
+	}
+
+	userID, _ := c.Get("user_id")
+	ownerID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	vehicle, err := h.db.Vehicles().FindOne(ctx, store.VehicleFilter{
+		ID:      vehicleObjID,
+		OwnerID: ownerID,
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.VehicleNotFound, "Vehicle not found")
+		return vehicle, false
+	}
+
+	return vehicle, true
+}
+
+// hasActiveSession reports whether the vehicle is currently with a valet
+func (h *VehicleHandler) hasActiveSession(ctx context.Context, vehicleID primitive.ObjectID) (bool, error) {
+	count, err := h.db.Sessions().Count(ctx, store.SessionFilter{
+		VehicleID:   vehicleID,
+		NotStatuses: models.ClosedStatuses,
+	})
+	return count > 0, err
+}
+
+type UpdateVehicleRequest struct {
+	RegistrationNumber *string   `json:"registration_number"`
+	Make               *string   `json:"make"`
+	Model              *string   `json:"model"`
+	Color              *string   `json:"color"`
+	VehicleType        *string   `json:"vehicle_type"`
+	Photos             *[]string `json:"photos"`
+}
+
+// UpdateVehicle lets a customer correct the details of one of their vehicles
+func (h *VehicleHandler) UpdateVehicle(c *gin.Context) {
+	var req UpdateVehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var vehicle models.Vehicle
-	err := h.db.Vehicles().FindOne(ctx, bson.M{
-		"registration_number": regNumber,
-	}).Decode(&vehicle)
+	vehicle, ok := h.findOwnedVehicle(ctx, c)
+	if !ok {
+		return
+	}
+
+	if vehicle.ArchivedAt != nil {
+		apierr.Abort(c, http.StatusConflict, apierr.VehicleArchived, "Vehicle is archived. Restore it before editing.")
+		return
+	}
+
+	var update store.VehicleUpdate
+	changed := false
+	openDispute := func() error { return nil }
+
+	if req.RegistrationNumber != nil && plate.Normalize(*req.RegistrationNumber) != vehicle.NormalizedReg {
+		normalizedReg, displayReg, ok := h.parseRegistration(c, *req.RegistrationNumber)
+		if !ok {
+			return
+		}
+
+		// Changing the plate mid-session would confuse valets looking for the car
+		active, err := h.hasActiveSession(ctx, vehicle.ID)
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update vehicle")
+			return
+		}
+		if active {
+			apierr.Abort(c, http.StatusConflict, apierr.VehicleInSession, "Cannot change registration number while the vehicle is parked")
+			return
+		}
+
+		count, err := h.db.Vehicles().Count(ctx, store.VehicleFilter{
+			NotID:         vehicle.ID,
+			OwnerID:       vehicle.OwnerID,
+			NormalizedReg: normalizedReg,
+			Active:        true,
+		})
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update vehicle")
+			return
+		}
+		if count > 0 {
+			apierr.Abort(c, http.StatusConflict, apierr.VehicleAlreadyRegistered, "Vehicle already registered")
+			return
+		}
+
+		update.RegistrationNumber = displayReg
+		update.NormalizedReg = normalizedReg
+
+		// A new plate means a new ownership claim
+		vehicle.NormalizedReg = normalizedReg
+		dispute, err := h.checkPlateOwnership(ctx, &vehicle)
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update vehicle")
+			return
+		}
+		openDispute = dispute
+		update.Ownership = &vehicle.Ownership
+		changed = true
+	}
+
+	if req.Make != nil && *req.Make != "" {
+		update.Make = *req.Make
+		changed = true
+	}
+	if req.Model != nil && *req.Model != "" {
+		update.Model = *req.Model
+		changed = true
+	}
+	if req.Color != nil && *req.Color != "" {
+		update.Color = *req.Color
+		changed = true
+	}
+
+	if req.VehicleType != nil {
+		vehicleType := models.VehicleType(*req.VehicleType)
+		if vehicleType != models.VehicleTypeCar && vehicleType != models.VehicleTypeBike && vehicleType != models.VehicleTypeThreeWheel {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid vehicle type. Must be: car, bike, or three_wheeler")
+			return
+		}
+		update.VehicleType = vehicleType
+		changed = true
+	}
+
+	if req.Photos != nil {
+		if !checkMediaRefs(ctx, h.db, c, vehicle.OwnerID, models.MediaKindVehicle, *req.Photos) {
+			return
+		}
+		update.Photos = req.Photos
+		changed = true
+	}
+
+	if !changed {
+		c.JSON(http.StatusOK, vehicle)
+		return
+	}
 
+	now := time.Now()
+	update.UpdatedAt = &now
+
+	err := h.db.Vehicles().Update(ctx, vehicle.ID, update)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Vehicle not found"})
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update vehicle")
+		return
+	}
+
+	if err := openDispute(); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to record ownership dispute")
+		return
+	}
+
+	if vehicle, err = h.db.Vehicles().FindByID(ctx, vehicle.ID); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get updated vehicle")
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// ArchiveVehicle soft-deletes a vehicle. Past sessions keep pointing at it,
+// so history still resolves the vehicle details.
+func (h *VehicleHandler) ArchiveVehicle(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vehicle, ok := h.findOwnedVehicle(ctx, c)
+	if !ok {
+		return
+	}
+
+	if vehicle.ArchivedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": localized(c, "VEHICLE_ALREADY_ARCHIVED")})
 		return
 	}
 
-	// Get owner details
-	var owner models.User
-	_ = h.db.Users().FindOne(ctx, bson.M{"_id": vehicle.OwnerID}).Decode(&owner)
+	active, err := h.hasActiveSession(ctx, vehicle.ID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to archive vehicle")
+		return
+	}
+	if active {
+		apierr.Abort(c, http.StatusConflict, apierr.VehicleInSession, "Vehicle has an active parking session and cannot be removed")
+		return
+	}
+
+	now := time.Now()
+	notDefault := false
+	err = h.db.Vehicles().Update(ctx, vehicle.ID, store.VehicleUpdate{
+		ArchivedAt: &now,
+		IsDefault:  &notDefault,
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to archive vehicle")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localized(c, "VEHICLE_REMOVED")})
+}
+
+// RestoreVehicle brings an archived vehicle back to the customer's garage
+func (h *VehicleHandler) RestoreVehicle(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vehicle, ok := h.findOwnedVehicle(ctx, c)
+	if !ok {
+		return
+	}
+
+	if vehicle.ArchivedAt == nil {
+		c.JSON(http.StatusOK, vehicle)
+		return
+	}
+
+	// The same plate may have been registered again after archiving
+	count, err := h.db.Vehicles().Count(ctx, store.VehicleFilter{
+		OwnerID:       vehicle.OwnerID,
+		NormalizedReg: vehicle.NormalizedReg,
+		Active:        true,
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to restore vehicle")
+		return
+	}
+	if count > 0 {
+		apierr.Abort(c, http.StatusConflict, apierr.VehicleAlreadyRegistered, "Vehicle already registered")
+		return
+	}
+
+	now := time.Now()
+	err = h.db.Vehicles().Update(ctx, vehicle.ID, store.VehicleUpdate{
+		UpdatedAt: &now,
+		Restore:   true,
+	})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to restore vehicle")
+		return
+	}
+
+	// Someone else may have registered the plate while it was archived
+	if vehicle.Ownership.Status != models.OwnershipVerified {
+		rivals, err := h.rivalVehicles(ctx, vehicle)
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to restore vehicle")
+			return
+		}
+		if len(rivals) > 0 {
+			ids := []primitive.ObjectID{vehicle.ID}
+			for _, r := range rivals {
+				ids = append(ids, r.ID)
+			}
+			if err := h.openPlateDispute(ctx, vehicle.NormalizedReg, ids); err != nil {
+				apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to record ownership dispute")
+				return
+			}
+			vehicle.Ownership.Status = models.OwnershipDisputed
+		}
+	}
+
+	vehicle.ArchivedAt = nil
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// SetDefaultVehicle marks one vehicle as the customer's default and clears the flag on the others
+func (h *VehicleHandler) SetDefaultVehicle(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vehicle, ok := h.findOwnedVehicle(ctx, c)
+	if !ok {
+		return
+	}
+
+	if vehicle.ArchivedAt != nil {
+		apierr.Abort(c, http.StatusConflict, apierr.VehicleArchived, "Archived vehicles cannot be the default")
+		return
+	}
+
+	isDefault, notDefault := true, false
+	err := h.db.Vehicles().UpdateMany(ctx,
+		store.VehicleFilter{OwnerID: vehicle.OwnerID, NotID: vehicle.ID},
+		store.VehicleUpdate{IsDefault: &notDefault},
+	)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to set default vehicle")
+		return
+	}
+
+	err = h.db.Vehicles().Update(ctx, vehicle.ID, store.VehicleUpdate{IsDefault: &isDefault})
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to set default vehicle")
+		return
+	}
+
+	vehicle.IsDefault = true
+	c.JSON(http.StatusOK, vehicle)
+}
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+)
+
+type vehicleSearchResult struct {
+	Vehicle models.Vehicle `json:"vehicle"`
+	Owner   *models.User   `json:"owner"`
+	Score   int            `json:"score"`
+	Match   string         `json:"match,omitempty"` // How the plate matched: exact, suffix or partial
+	Visits  int            `json:"visits"`          // Sessions this vehicle had at the valet's venue
+}
+
+// SearchVehicles finds vehicles by partial plate, make/model/colour or owner
+// phone for valets, ranked by match quality and history at the valet's venue.
+// Only customers who have used the venue are searched, unless the plate or
+// phone is an exact match (a first-time customer at the kerb). The store ranks
+// every match before paging, see store.VehicleRank.
+func (h *VehicleHandler) SearchVehicles(c *gin.Context) {
+	plateQuery := plate.Normalize(c.Query("plate"))
+	makeQuery := strings.TrimSpace(c.Query("make"))
+	modelQuery := strings.TrimSpace(c.Query("model"))
+	colorQuery := strings.TrimSpace(c.Query("color"))
+	phoneQuery := strings.TrimSpace(c.Query("phone"))
+
+	if plateQuery == "" && makeQuery == "" && modelQuery == "" && colorQuery == "" && phoneQuery == "" {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Provide at least one of: plate, make, model, color, phone")
+		return
+	}
+	// Accounts are stored by E.164 phone, however the customer typed it at sign-in
+	if phoneQuery != "" && !normalizePhone(c, &phoneQuery) {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, ok := queryLimit(c, searchDefaultLimit, searchMaxLimit)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	filter := store.VehicleFilter{
+		RegContains: plateQuery,
+		Make:        makeQuery,
+		Model:       modelQuery,
+		Color:       colorQuery,
+		Active:      true,
+		NotRejected: true,
+	}
+
+	if phoneQuery != "" {
+		users, err := h.db.Users().Find(ctx, store.UserFilter{
+			Phone: phoneQuery,
+			Role:  models.RoleCustomer,
+		})
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to search vehicles")
+			return
+		}
+		filter.OwnerIDs = []primitive.ObjectID{}
+		for _, u := range users {
+			filter.OwnerIDs = append(filter.OwnerIDs, u.ID)
+		}
+	}
+
+	// Visits per customer at this venue decide scope and ranking
+	visits, err := h.visits.get(ctx, h.db, venueName)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to search vehicles")
+		return
+	}
+
+	// A full plate is looked up by equality on the indexed normalized registration
+	if plateQuery != "" && plate.Validate(h.plateCountry, plateQuery) == nil {
+		filter.NormalizedReg, filter.RegContains = plateQuery, ""
+	} else if phoneQuery == "" {
+		customers := make([]primitive.ObjectID, 0, len(visits))
+		for id := range visits {
+			customers = append(customers, id)
+		}
+		filter.OwnerIDs = customers
+	}
+
+	total, err := h.db.Vehicles().Count(ctx, filter)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to search vehicles")
+		return
+	}
+	ranked, err := h.db.Vehicles().Search(ctx, filter, store.VehicleRank{
+		Plate:  plateQuery,
+		Make:   makeQuery,
+		Model:  modelQuery,
+		Color:  colorQuery,
+		Visits: visits,
+	}, int64((page-1)*limit), int64(limit))
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to search vehicles")
+		return
+	}
+
+	results := make([]vehicleSearchResult, 0, len(ranked))
+	for _, v := range ranked {
+		r := vehicleSearchResult{Vehicle: v.Vehicle, Score: v.Score, Visits: visits[v.OwnerID]}
+		switch {
+		case plateQuery == "":
+		case v.NormalizedReg == plateQuery:
+			r.Match = "exact"
+		case strings.HasSuffix(v.NormalizedReg, plateQuery):
+			r.Match = "suffix"
+		default:
+			r.Match = "partial"
+		}
+		results = append(results, r)
+	}
+
+	// Attach owners with a single query
+	ownerIDs := make([]primitive.ObjectID, 0, len(results))
+	for _, r := range results {
+		ownerIDs = append(ownerIDs, r.Vehicle.OwnerID)
+	}
+	owners := make(map[primitive.ObjectID]*models.User)
+	if len(ownerIDs) > 0 {
+		users, err := h.db.Users().Find(ctx, store.UserFilter{IDs: ownerIDs})
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to search vehicles")
+			return
+		}
+		for i := range users {
+			owners[users[i].ID] = &users[i]
+		}
+	}
+	for i := range results {
+		results[i].Owner = owners[results[i].Vehicle.OwnerID]
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"vehicle": vehicle,
-		"owner":   owner,
+		"results": results,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
 	})
 }
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/phone"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// customerAccess limits sessions to those the calling customer owns, or that
+// are for a vehicle shared with them. With a permission only shares granting
+// it count; with "" any share does.
+func customerAccess(ctx context.Context, database *store.Store, c *gin.Context, perm models.SharePermission) (*store.CustomerAccess, error) {
+	userID, _ := c.Get("user_id")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	shared, err := database.Vehicles().Find(ctx, store.VehicleFilter{
+		SharedWith:      callerPhone(c),
+		SharePermission: perm,
+		Active:          true,
+	}, store.VehicleOrderNone, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &store.CustomerAccess{CustomerID: userObjID}
+	for _, v := range shared {
+		access.VehicleIDs = append(access.VehicleIDs, v.ID)
+	}
+	return access, nil
+}
+
+// callerPhone returns the signed-in user's phone in E.164 form, the way
+// shares store it
+func callerPhone(c *gin.Context) string {
+	raw := c.GetString("phone")
+	if normalized, err := phone.Normalize(raw); err == nil {
+		return normalized
+	}
+	return raw
+}
+
+// redactPickupOTP clears a session's pickup OTP unless the caller may use it:
+// the customer whose session it is, the vehicle's owner, or someone the vehicle
+// is shared with for pickup. Valets never see it; the customer shows it to them.
+func redactPickupOTP(ctx context.Context, database *store.Store, c *gin.Context, session *models.ParkingSession) {
+	if session.PickupOTP == "" {
+		return
+	}
+	if role, _ := c.Get("role"); role == string(models.RoleCustomer) {
+		userObjID, _ := primitive.ObjectIDFromHex(c.GetString("user_id"))
+		if session.CustomerID == userObjID {
+			return
+		}
+		if vehicle, err := database.Vehicles().FindByID(ctx, session.VehicleID); err == nil {
+			if vehicle.OwnerID == userObjID {
+				return
+			}
+			for _, s := range vehicle.SharedWith {
+				if phone.Equal(s.Phone, callerPhone(c)) && slices.Contains(s.Permissions, models.ShareRequestPickup) {
+					return
+				}
+			}
+		}
+	}
+	session.PickupOTP = ""
+}
+
+type ShareVehicleRequest struct {
+	Phone       string   `json:"phone" binding:"required"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// ShareVehicle invites another phone number to drive one of the customer's vehicles.
+// Sharing again with the same phone replaces its permissions.
+func (h *VehicleHandler) ShareVehicle(c *gin.Context) {
+	var req ShareVehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	// Shares are keyed by E.164 phone, so every spelling of a number is one share
+	sharePhone, err := phone.Normalize(req.Phone)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid phone number")
+		return
+	}
+	if sharePhone == callerPhone(c) {
+		apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "You cannot share a vehicle with yourself")
+		return
+	}
+
+	permissions := []models.SharePermission{}
+	for _, p := range req.Permissions {
+		perm := models.SharePermission(p)
+		if perm != models.ShareAcceptParking && perm != models.ShareRequestPickup {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid permission. Must be: accept_parking or request_pickup")
+			return
+		}
+		permissions = append(permissions, perm)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vehicle, ok := h.findOwnedVehicle(ctx, c)
+	if !ok {
+		return
+	}
+
+	if vehicle.ArchivedAt != nil {
+		apierr.Abort(c, http.StatusConflict, apierr.VehicleArchived, "Vehicle is archived")
+		return
+	}
+
+	share := models.VehicleShare{
+		Phone:       sharePhone,
+		Name:        req.Name,
+		Permissions: permissions,
+		InvitedAt:   time.Now(),
+	}
+
+	// Drop any existing share for this phone, then add the new one
+	if err := h.db.Vehicles().PutShare(ctx, vehicle.ID, share); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to share vehicle")
+		return
+	}
+
+	vehicle, err = h.db.Vehicles().FindByID(ctx, vehicle.ID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get updated vehicle")
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicle)
+}
+
+// UnshareVehicle revokes a phone number's access to a vehicle
+func (h *VehicleHandler) UnshareVehicle(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vehicle, ok := h.findOwnedVehicle(ctx, c)
+	if !ok {
+		return
+	}
+
+	sharePhone, err := phone.Normalize(c.Param("phone"))
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.ShareNotFound, "Vehicle is not shared with this phone")
+		return
+	}
+
+	removed, err := h.db.Vehicles().RemoveShare(ctx, vehicle.ID, sharePhone)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to remove share")
+		return
+	}
+
+	if !removed {
+		apierr.Abort(c, http.StatusNotFound, apierr.ShareNotFound, "Vehicle is not shared with this phone")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localized(c, "SHARE_REMOVED")})
+}
+
+// ListSharedVehicles returns vehicles other customers have shared with the caller
+func (h *VehicleHandler) ListSharedVehicles(c *gin.Context) {
+	sharePhone := callerPhone(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vehicles, err := h.db.Vehicles().Find(ctx, store.VehicleFilter{
+		SharedWith: sharePhone,
+		Active:     true,
+	}, store.VehicleOrderNone, 0)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to fetch vehicles")
+		return
+	}
+
+	// Only show the caller their own share, not the rest of the household
+	results := []gin.H{}
+	for _, v := range vehicles {
+		var share models.VehicleShare
+		for _, s := range v.SharedWith {
+			if s.Phone == sharePhone {
+				share = s
+			}
+		}
+		v.SharedWith = nil
+
+		owner, _ := h.db.Users().FindByID(ctx, v.OwnerID)
+
+		results = append(results, gin.H{
+			"vehicle": v,
+			"owner":   owner,
+			"share":   share,
+		})
+	}
+
+	c.JSON(http.StatusOK, results)
+}
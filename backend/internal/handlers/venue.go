@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type VenueHandler struct {
+	db *store.Store
+}
+
+func NewVenueHandler(database *store.Store) *VenueHandler {
+	return &VenueHandler{db: database}
+}
+
+// loadVenue returns the settings for a venue, falling back to defaults when
+// the venue has not been configured yet
+func loadVenue(ctx context.Context, database *store.Store, name string) models.Venue {
+	venue, err := database.Venues().FindByName(ctx, name)
+	if err != nil {
+		return models.DefaultVenue(name)
+	}
+	return venue
+}
+
+// valetVenue looks up the venue name assigned to the calling user
+func valetVenue(ctx context.Context, database *store.Store, c *gin.Context) (string, bool) {
+	userID, _ := c.Get("user_id")
+	userObjID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	user, err := database.Users().FindByID(ctx, userObjID)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to get user info")
+		return "", false
+	}
+
+	if user.VenueName == "" {
+		apierr.Abort(c, http.StatusBadRequest, apierr.VenueNotAssigned, "Valet has no venue assigned. Ask your venue manager to add you.")
+		return "", false
+	}
+
+	return user.VenueName, true
+}
+
+// GetSettings returns the settings of the caller's venue
+func (h *VenueHandler) GetSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, loadVenue(ctx, h.db, venueName))
+}
+
+type UpdateVenueSettingsRequest struct {
+	PickupOTPTTLMinutes *int    `json:"pickup_otp_ttl_minutes"`
+	OTPRolloverPolicy   *string `json:"otp_rollover_policy"`
+	MaxOTPRollovers     *int    `json:"max_otp_rollovers"`
+	Currency            *string `json:"currency"`
+	LostTicketFee       *int64  `json:"lost_ticket_fee"`
+	LowRatingThreshold  *int    `json:"low_rating_threshold"`
+	TipParkingShare     *int    `json:"tip_parking_share"`
+	RequireKeyTag       *bool   `json:"require_key_tag"`
+}
+
+// UpdateSettings changes the settings of the caller's venue (manager only)
+func (h *VenueHandler) UpdateSettings(c *gin.Context) {
+	var req UpdateVenueSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	venue := loadVenue(ctx, h.db, venueName)
+
+	if req.PickupOTPTTLMinutes != nil {
+		if *req.PickupOTPTTLMinutes < 5 || *req.PickupOTPTTLMinutes > 240 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Pickup OTP TTL must be between 5 and 240 minutes")
+			return
+		}
+		venue.PickupOTPTTLMinutes = *req.PickupOTPTTLMinutes
+	}
+
+	if req.OTPRolloverPolicy != nil {
+		policy := models.OTPRolloverPolicy(*req.OTPRolloverPolicy)
+		if policy != models.OTPRolloverAuto && policy != models.OTPRolloverManual {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Invalid OTP rollover policy. Must be 'auto' or 'manual'")
+			return
+		}
+		venue.OTPRolloverPolicy = policy
+	}
+
+	if req.MaxOTPRollovers != nil {
+		if *req.MaxOTPRollovers < 0 || *req.MaxOTPRollovers > 10 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Max OTP rollovers must be between 0 and 10")
+			return
+		}
+		venue.MaxOTPRollovers = *req.MaxOTPRollovers
+	}
+
+	if req.Currency != nil {
+		currency := strings.ToUpper(strings.TrimSpace(*req.Currency))
+		if len(currency) != 3 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Currency must be a 3-letter ISO 4217 code")
+			return
+		}
+		venue.Currency = currency
+	}
+
+	if req.LostTicketFee != nil {
+		if *req.LostTicketFee < 0 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Lost-ticket fee cannot be negative")
+			return
+		}
+		venue.LostTicketFee = *req.LostTicketFee
+	}
+
+	if req.LowRatingThreshold != nil {
+		if *req.LowRatingThreshold < 0 || *req.LowRatingThreshold > 4 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Low rating threshold must be between 0 (off) and 4")
+			return
+		}
+		venue.LowRatingThreshold = *req.LowRatingThreshold
+	}
+
+	if req.TipParkingShare != nil {
+		if *req.TipParkingShare < 0 || *req.TipParkingShare > 100 {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Tip parking share must be a percentage between 0 and 100")
+			return
+		}
+		venue.TipParkingShare = *req.TipParkingShare
+	}
+
+	if req.RequireKeyTag != nil {
+		venue.RequireKeyTag = *req.RequireKeyTag
+	}
+
+	venue.UpdatedAt = time.Now()
+
+	err := h.db.Venues().SaveSettings(ctx, venue)
+	if err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to update venue settings")
+		return
+	}
+
+	recordAudit(ctx, h.db, c, models.AuditVenueSettingsUpdated, venueName, nil, map[string]interface{}{
+		"changes": req,
+	})
+
+	venue = loadVenue(ctx, h.db, venueName)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localized(c, "VENUE_SETTINGS_UPDATED"),
+		"venue":   venue,
+	})
+}
+
+type AssignValetRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+// AssignValet adds a signed-up valet to the caller's venue (manager only).
+// Valets cannot choose a venue themselves, since it decides whose sessions
+// and keys they can reach.
+func (h *VenueHandler) AssignValet(c *gin.Context) {
+	var req AssignValetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.BindError(c, err)
+		return
+	}
+	if !normalizePhone(c, &req.Phone) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	valet, err := h.db.Users().FindOne(ctx, store.UserFilter{Phone: req.Phone, Role: models.RoleValet})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.ValetNotFound, "No valet has signed up with this phone")
+		return
+	}
+
+	// Another venue's manager has to let the valet go first
+	if valet.VenueName != "" && valet.VenueName != venueName {
+		apierr.Abort(c, http.StatusConflict, apierr.ValetAtOtherVenue, "Valet works at another venue")
+		return
+	}
+
+	if valet.VenueName == "" {
+		if err := h.db.Users().SetVenue(ctx, valet.ID, venueName); err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to assign valet")
+			return
+		}
+		valet.VenueName = venueName
+
+		recordAudit(ctx, h.db, c, models.AuditValetAssigned, venueName, nil, map[string]interface{}{
+			"valet_id": valet.ID.Hex(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localized(c, "VALET_ASSIGNED"),
+		"valet":   valet,
+	})
+}
+
+// UnassignValet takes a valet off the caller's venue (manager only)
+func (h *VenueHandler) UnassignValet(c *gin.Context) {
+	valetObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.InvalidID, "Invalid valet ID")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	venueName, ok := valetVenue(ctx, h.db, c)
+	if !ok {
+		return
+	}
+
+	// Valets of other venues are reported as missing rather than revealed
+	valet, err := h.db.Users().FindOne(ctx, store.UserFilter{ID: valetObjID, Role: models.RoleValet, VenueName: venueName})
+	if err != nil {
+		apierr.Abort(c, http.StatusNotFound, apierr.ValetNotFound, "Valet not found")
+		return
+	}
+
+	if err := h.db.Users().SetVenue(ctx, valet.ID, ""); err != nil {
+		apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to unassign valet")
+		return
+	}
+
+	recordAudit(ctx, h.db, c, models.AuditValetUnassigned, venueName, nil, map[string]interface{}{
+		"valet_id": valet.ID.Hex(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": localized(c, "VALET_UNASSIGNED")})
+}
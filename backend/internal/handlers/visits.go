@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"valet-parking-backend/internal/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// visitCacheTTL is how long a venue's visit counts are reused. Search runs on
+// every keystroke; a visit counted a little late only nudges the ranking.
+const visitCacheTTL = 30 * time.Second
+
+// visitCounts caches the sessions per customer at each venue for vehicle search
+type visitCounts struct {
+	mu      sync.Mutex
+	byVenue map[string]venueVisits
+}
+
+type venueVisits struct {
+	visits   map[primitive.ObjectID]int
+	loadedAt time.Time
+}
+
+func newVisitCounts() *visitCounts {
+	return &visitCounts{byVenue: make(map[string]venueVisits)}
+}
+
+// get returns the venue's visit counts, loading them when missing or stale.
+// The returned map is shared and must not be modified.
+func (v *visitCounts) get(ctx context.Context, database *store.Store, venueName string) (map[primitive.ObjectID]int, error) {
+	v.mu.Lock()
+	cached, ok := v.byVenue[venueName]
+	v.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < visitCacheTTL {
+		return cached.visits, nil
+	}
+
+	visits, err := database.Sessions().CustomerVisits(ctx, venueName)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	v.byVenue[venueName] = venueVisits{visits: visits, loadedAt: time.Now()}
+	v.mu.Unlock()
+	return visits, nil
+}
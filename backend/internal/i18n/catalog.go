@@ -0,0 +1,301 @@
+package i18n
+
+// catalog maps each language to its messages. Error titles are keyed by
+// their apierr code; confirmations, alerts and SMS templates by event code.
+// Templates name their parameters as {name}.
+var catalog = map[Lang]map[string]string{
+	English: {
+		"INVALID_REQUEST":             "The request could not be read",
+		"VALIDATION_FAILED":           "Some of the details are missing or invalid",
+		"INVALID_ID":                  "The ID is not valid",
+		"INVALID_REGISTRATION":        "The registration number is not valid",
+		"INVALID_UPLOAD":              "The uploaded file could not be read",
+		"AUTH_REQUIRED":               "Please sign in to continue",
+		"TOKEN_INVALID":               "Your session has expired, please sign in again",
+		"FORBIDDEN":                   "You are not allowed to do this",
+		"NOT_A_MANAGER":               "This phone number is not registered as a venue manager",
+		"VENUE_NOT_ASSIGNED":          "You have no venue assigned. Ask your venue manager to add you.",
+		"OTP_INVALID":                 "Invalid OTP",
+		"OTP_EXPIRED":                 "OTP expired",
+		"ROUTE_NOT_FOUND":             "This page does not exist",
+		"METHOD_NOT_ALLOWED":          "This action is not supported here",
+		"INTERNAL":                    "Something went wrong, please try again",
+		"SESSION_NOT_FOUND":           "Session not found",
+		"NO_ACTIVE_SESSION":           "No active session found",
+		"ACTIVE_SESSION_EXISTS":       "Vehicle already has an active parking session",
+		"INVALID_TRANSITION":          "This session can no longer be changed this way",
+		"PICKUP_NOT_REQUESTED":        "The customer has not requested pickup yet",
+		"PRECONDITION_FAILED":         "The session was changed by someone else, please refresh",
+		"CONCURRENT_UPDATE":           "Something changed while saving, please retry",
+		"INSPECTION_NOT_ACKNOWLEDGED": "Please review and acknowledge the vehicle inspection",
+		"INSPECTION_NOT_FOUND":        "No inspection found",
+		"INSPECTION_EXISTS":           "Inspection already recorded",
+		"ALREADY_RATED":               "This session has already been rated",
+		"RATING_NOT_FOUND":            "This session has not been rated",
+		"LOST_TICKET_FEE_NOT_SET":     "No lost-ticket fee is configured for this venue",
+		"RELEASE_FEE_NOT_DUE":         "No lost-ticket fee is due on this session",
+		"VEHICLE_NOT_FOUND":           "Vehicle not found",
+		"VEHICLE_ALREADY_REGISTERED":  "Vehicle already registered",
+		"VEHICLE_ARCHIVED":            "Vehicle is archived",
+		"VEHICLE_IN_SESSION":          "Vehicle has an active parking session",
+		"REGISTRATION_MISMATCH":       "Registration number does not match this session's vehicle",
+		"SHARE_NOT_FOUND":             "Vehicle is not shared with this phone",
+		"OWNERSHIP_ALREADY_VERIFIED":  "Ownership already verified",
+		"OWNERSHIP_REJECTED":          "Ownership claim was rejected. Please contact the venue.",
+		"DOCUMENT_MISMATCH":           "Document number does not match the claim",
+		"USER_NOT_FOUND":              "User not found",
+		"VALET_NOT_FOUND":             "Valet not found",
+		"VALET_AT_OTHER_VENUE":        "This valet works at another venue",
+		"KEY_TAG_NOT_FOUND":           "Key tag not found",
+		"KEY_TAG_EXISTS":              "Key tag number already exists at this venue",
+		"KEY_TAG_UNAVAILABLE":         "Key tag is not available",
+		"KEY_TAG_IN_USE":              "Key tag is in use by an active session",
+		"KEY_TAG_ALREADY_ASSIGNED":    "Session already has a key tag",
+		"KEY_TAG_MISMATCH":            "Key tag does not match this session's keys",
+		"KEY_RETURN_REQUIRED":         "Confirm the key tag number being handed over",
+		"NO_KEY_TAG":                  "Session has no key tag in custody",
+		"KEY_TAG_REQUIRED":            "Tag the keys before marking the car parked",
+		"MEDIA_NOT_FOUND":             "Media not found",
+		"UPLOAD_NOT_FOUND":            "Upload not found or already completed",
+		"SIGNED_URL_INVALID":          "The link is invalid or has expired",
+		"FILE_TOO_LARGE":              "File too large",
+		"REQUEST_TOO_LARGE":           "Request too large",
+		"UNSUPPORTED_MEDIA_TYPE":      "This file type is not supported",
+		"PLATE_NOT_FOUND":             "No registration plate found in the photo",
+		"RECOGNITION_UNAVAILABLE":     "Plate recognition is unavailable, please enter the plate manually",
+		"INCIDENT_NOT_FOUND":          "Incident not found",
+		"INCIDENT_WINDOW_CLOSED":      "Incidents must be filed within 7 days of delivery",
+		"ALERT_NOT_FOUND":             "Alert not found or already acknowledged",
+		"PAYMENT_DECLINED":            "Payment was declined",
+		"PAYMENT_UNAVAILABLE":         "Online payment is not available, please tip in cash",
+		"SIGNATURE_INVALID":           "The request signature is invalid",
+		"TIP_NOT_FOUND":               "Tip not found",
+		"CASH_TIP_EXISTS":             "A cash tip has already been recorded for this session",
+		"IDEMPOTENCY_KEY_IN_USE":      "This request is still being processed",
+		"IDEMPOTENCY_KEY_REUSED":      "Idempotency-Key was already used for a different request",
+
+		"OTP_SENT":                 "OTP sent successfully",
+		"PROFILE_UPDATED":          "Profile updated successfully",
+		"PICKUP_REQUESTED":         "Pickup requested successfully",
+		"PICKUP_OTP_REGENERATED":   "Pickup OTP regenerated",
+		"PARKING_ACCEPTED":         "Parking accepted",
+		"PARKING_REJECTED":         "Parking rejected",
+		"SESSION_CANCELLED":        "Session cancelled successfully",
+		"PICKUP_CANCELLED":         "Pickup cancelled",
+		"VEHICLE_DELIVERED":        "Vehicle delivered successfully",
+		"STATUS_UPDATED":           "Status updated successfully",
+		"VEHICLE_RELEASED":         "Vehicle released",
+		"RELEASE_FEE_COLLECTED":    "Lost-ticket fee marked as paid",
+		"VEHICLE_ALREADY_ARCHIVED": "Vehicle already archived",
+		"VEHICLE_REMOVED":          "Vehicle removed",
+		"SHARE_REMOVED":            "Share removed",
+		"OWNERSHIP_VERIFIED":       "Ownership verified",
+		"KEY_TAG_ASSIGNED":         "Key tag assigned",
+		"KEY_CUSTODY_UPDATED":      "Key custody updated",
+		"VENUE_SETTINGS_UPDATED":   "Venue settings updated",
+		"VALET_ASSIGNED":           "Valet added to the venue",
+		"VALET_UNASSIGNED":         "Valet removed from the venue",
+		"ALERT_ACKNOWLEDGED":       "Alert acknowledged",
+		"ALERT_LOW_RATING":         "Ticket {ticket} rated {score}/5",
+		"ALERT_INCIDENT":           "{severity} {type} incident filed on ticket {ticket}",
+		"SMS_LOGIN_OTP":            "{otp} is your valet parking sign-in code. It expires in 5 minutes.",
+		"SMS_PICKUP_OTP":           "Your car at {venue} is being brought round. Share OTP {otp} with the valet on arrival.",
+		"SMS_VEHICLE_DELIVERED":    "Your car (ticket {ticket}) has been handed over. Thank you for visiting {venue}.",
+	},
+	Hindi: {
+		"INVALID_REQUEST":             "अनुरोध पढ़ा नहीं जा सका",
+		"VALIDATION_FAILED":           "कुछ जानकारी अधूरी या गलत है",
+		"INVALID_ID":                  "आईडी मान्य नहीं है",
+		"INVALID_REGISTRATION":        "पंजीकरण संख्या मान्य नहीं है",
+		"INVALID_UPLOAD":              "अपलोड की गई फ़ाइल पढ़ी नहीं जा सकी",
+		"AUTH_REQUIRED":               "जारी रखने के लिए कृपया साइन इन करें",
+		"TOKEN_INVALID":               "आपका सत्र समाप्त हो गया है, कृपया फिर से साइन इन करें",
+		"FORBIDDEN":                   "आपको यह करने की अनुमति नहीं है",
+		"NOT_A_MANAGER":               "यह फ़ोन नंबर वेन्यू मैनेजर के रूप में पंजीकृत नहीं है",
+		"VENUE_NOT_ASSIGNED":          "आपको कोई वेन्यू नहीं सौंपा गया है। कृपया अपने वेन्यू मैनेजर से आपको जोड़ने के लिए कहें।",
+		"OTP_INVALID":                 "ओटीपी गलत है",
+		"OTP_EXPIRED":                 "ओटीपी की समय-सीमा समाप्त हो गई है",
+		"ROUTE_NOT_FOUND":             "यह पेज मौजूद नहीं है",
+		"METHOD_NOT_ALLOWED":          "यहाँ यह कार्य समर्थित नहीं है",
+		"INTERNAL":                    "कुछ गलत हो गया, कृपया फिर से प्रयास करें",
+		"SESSION_NOT_FOUND":           "पार्किंग सत्र नहीं मिला",
+		"NO_ACTIVE_SESSION":           "कोई सक्रिय पार्किंग सत्र नहीं मिला",
+		"ACTIVE_SESSION_EXISTS":       "इस वाहन का पार्किंग सत्र पहले से सक्रिय है",
+		"INVALID_TRANSITION":          "इस सत्र को अब इस तरह बदला नहीं जा सकता",
+		"PICKUP_NOT_REQUESTED":        "ग्राहक ने अभी तक गाड़ी वापस नहीं मांगी है",
+		"PRECONDITION_FAILED":         "सत्र किसी और ने बदल दिया है, कृपया रीफ़्रेश करें",
+		"CONCURRENT_UPDATE":           "सहेजते समय कुछ बदल गया, कृपया फिर से प्रयास करें",
+		"INSPECTION_NOT_ACKNOWLEDGED": "कृपया वाहन निरीक्षण देखें और उसकी पुष्टि करें",
+		"INSPECTION_NOT_FOUND":        "कोई निरीक्षण नहीं मिला",
+		"INSPECTION_EXISTS":           "निरीक्षण पहले ही दर्ज किया जा चुका है",
+		"ALREADY_RATED":               "इस सत्र को पहले ही रेटिंग दी जा चुकी है",
+		"RATING_NOT_FOUND":            "इस सत्र को अभी रेटिंग नहीं दी गई है",
+		"LOST_TICKET_FEE_NOT_SET":     "इस वेन्यू के लिए खोए टिकट का शुल्क तय नहीं है",
+		"RELEASE_FEE_NOT_DUE":         "इस सत्र पर खोए टिकट का कोई शुल्क बाकी नहीं है",
+		"VEHICLE_NOT_FOUND":           "वाहन नहीं मिला",
+		"VEHICLE_ALREADY_REGISTERED":  "वाहन पहले से पंजीकृत है",
+		"VEHICLE_ARCHIVED":            "वाहन संग्रहीत है",
+		"VEHICLE_IN_SESSION":          "वाहन का पार्किंग सत्र सक्रिय है",
+		"REGISTRATION_MISMATCH":       "पंजीकरण संख्या इस सत्र के वाहन से मेल नहीं खाती",
+		"SHARE_NOT_FOUND":             "यह वाहन इस फ़ोन नंबर के साथ साझा नहीं है",
+		"OWNERSHIP_ALREADY_VERIFIED":  "स्वामित्व पहले ही सत्यापित हो चुका है",
+		"OWNERSHIP_REJECTED":          "स्वामित्व का दावा अस्वीकार कर दिया गया। कृपया वेन्यू से संपर्क करें।",
+		"DOCUMENT_MISMATCH":           "दस्तावेज़ संख्या दावे से मेल नहीं खाती",
+		"USER_NOT_FOUND":              "उपयोगकर्ता नहीं मिला",
+		"VALET_NOT_FOUND":             "वैले नहीं मिला",
+		"VALET_AT_OTHER_VENUE":        "यह वैले किसी दूसरे वेन्यू पर काम करता है",
+		"KEY_TAG_NOT_FOUND":           "चाबी टैग नहीं मिला",
+		"KEY_TAG_EXISTS":              "इस वेन्यू में यह चाबी टैग नंबर पहले से मौजूद है",
+		"KEY_TAG_UNAVAILABLE":         "चाबी टैग उपलब्ध नहीं है",
+		"KEY_TAG_IN_USE":              "चाबी टैग एक सक्रिय सत्र में उपयोग हो रहा है",
+		"KEY_TAG_ALREADY_ASSIGNED":    "इस सत्र में पहले से चाबी टैग है",
+		"KEY_TAG_MISMATCH":            "चाबी टैग इस सत्र की चाबियों से मेल नहीं खाता",
+		"KEY_RETURN_REQUIRED":         "सौंपी जा रही चाबी का टैग नंबर पुष्टि करें",
+		"NO_KEY_TAG":                  "इस सत्र की कोई चाबी टैग अभिरक्षा में नहीं है",
+		"KEY_TAG_REQUIRED":            "गाड़ी को पार्क चिह्नित करने से पहले चाबी पर टैग लगाएं",
+		"MEDIA_NOT_FOUND":             "फ़ोटो नहीं मिली",
+		"UPLOAD_NOT_FOUND":            "अपलोड नहीं मिला या पहले ही पूरा हो चुका है",
+		"SIGNED_URL_INVALID":          "लिंक अमान्य है या उसकी समय-सीमा समाप्त हो गई है",
+		"FILE_TOO_LARGE":              "फ़ाइल बहुत बड़ी है",
+		"REQUEST_TOO_LARGE":           "अनुरोध बहुत बड़ा है",
+		"UNSUPPORTED_MEDIA_TYPE":      "यह फ़ाइल प्रकार समर्थित नहीं है",
+		"PLATE_NOT_FOUND":             "फ़ोटो में कोई नंबर प्लेट नहीं मिली",
+		"RECOGNITION_UNAVAILABLE":     "नंबर प्लेट पहचान उपलब्ध नहीं है, कृपया नंबर स्वयं दर्ज करें",
+		"INCIDENT_NOT_FOUND":          "घटना नहीं मिली",
+		"INCIDENT_WINDOW_CLOSED":      "घटना की शिकायत डिलीवरी के 7 दिनों के भीतर करनी होगी",
+		"ALERT_NOT_FOUND":             "अलर्ट नहीं मिला या पहले ही स्वीकार किया जा चुका है",
+		"PAYMENT_DECLINED":            "भुगतान अस्वीकार हो गया",
+		"PAYMENT_UNAVAILABLE":         "ऑनलाइन भुगतान उपलब्ध नहीं है, कृपया नकद टिप दें",
+		"SIGNATURE_INVALID":           "अनुरोध का हस्ताक्षर अमान्य है",
+		"TIP_NOT_FOUND":               "टिप नहीं मिली",
+		"CASH_TIP_EXISTS":             "इस सत्र के लिए नकद टिप पहले ही दर्ज की जा चुकी है",
+		"IDEMPOTENCY_KEY_IN_USE":      "यह अनुरोध अभी संसाधित हो रहा है",
+		"IDEMPOTENCY_KEY_REUSED":      "यह Idempotency-Key किसी दूसरे अनुरोध के लिए उपयोग हो चुकी है",
+
+		"OTP_SENT":                 "ओटीपी सफलतापूर्वक भेजा गया",
+		"PROFILE_UPDATED":          "प्रोफ़ाइल सफलतापूर्वक अपडेट हुई",
+		"PICKUP_REQUESTED":         "गाड़ी वापसी का अनुरोध भेज दिया गया",
+		"PICKUP_OTP_REGENERATED":   "नया पिकअप ओटीपी जारी किया गया",
+		"PARKING_ACCEPTED":         "पार्किंग स्वीकार की गई",
+		"PARKING_REJECTED":         "पार्किंग अस्वीकार की गई",
+		"SESSION_CANCELLED":        "पार्किंग सत्र रद्द कर दिया गया",
+		"PICKUP_CANCELLED":         "गाड़ी वापसी का अनुरोध रद्द किया गया",
+		"VEHICLE_DELIVERED":        "वाहन सफलतापूर्वक सौंप दिया गया",
+		"STATUS_UPDATED":           "स्थिति सफलतापूर्वक अपडेट हुई",
+		"VEHICLE_RELEASED":         "वाहन छोड़ दिया गया",
+		"RELEASE_FEE_COLLECTED":    "खोए टिकट का शुल्क भुगतान के रूप में दर्ज हुआ",
+		"VEHICLE_ALREADY_ARCHIVED": "वाहन पहले से संग्रहीत है",
+		"VEHICLE_REMOVED":          "वाहन हटा दिया गया",
+		"SHARE_REMOVED":            "साझाकरण हटा दिया गया",
+		"OWNERSHIP_VERIFIED":       "स्वामित्व सत्यापित हुआ",
+		"KEY_TAG_ASSIGNED":         "चाबी टैग सौंपा गया",
+		"KEY_CUSTODY_UPDATED":      "चाबी की अभिरक्षा अपडेट हुई",
+		"VENUE_SETTINGS_UPDATED":   "वेन्यू सेटिंग्स अपडेट हुईं",
+		"VALET_ASSIGNED":           "वैले को वेन्यू में जोड़ा गया",
+		"VALET_UNASSIGNED":         "वैले को वेन्यू से हटाया गया",
+		"ALERT_ACKNOWLEDGED":       "अलर्ट स्वीकार किया गया",
+		"ALERT_LOW_RATING":         "टिकट {ticket} को {score}/5 रेटिंग मिली",
+		"ALERT_INCIDENT":           "टिकट {ticket} पर {severity} {type} घटना दर्ज की गई",
+		"SMS_LOGIN_OTP":            "{otp} आपका वैले पार्किंग साइन-इन कोड है। यह 5 मिनट में समाप्त हो जाएगा।",
+		"SMS_PICKUP_OTP":           "{venue} पर आपकी गाड़ी लाई जा रही है। पहुँचने पर वैले को ओटीपी {otp} बताएं।",
+		"SMS_VEHICLE_DELIVERED":    "आपकी गाड़ी (टिकट {ticket}) आपको सौंप दी गई है। {venue} आने के लिए धन्यवाद।",
+	},
+	Marathi: {
+		"INVALID_REQUEST":             "विनंती वाचता आली नाही",
+		"VALIDATION_FAILED":           "काही माहिती अपूर्ण किंवा चुकीची आहे",
+		"INVALID_ID":                  "आयडी वैध नाही",
+		"INVALID_REGISTRATION":        "नोंदणी क्रमांक वैध नाही",
+		"INVALID_UPLOAD":              "अपलोड केलेली फाइल वाचता आली नाही",
+		"AUTH_REQUIRED":               "पुढे जाण्यासाठी कृपया साइन इन करा",
+		"TOKEN_INVALID":               "तुमचे सत्र संपले आहे, कृपया पुन्हा साइन इन करा",
+		"FORBIDDEN":                   "तुम्हाला हे करण्याची परवानगी नाही",
+		"NOT_A_MANAGER":               "हा फोन नंबर वेन्यू मॅनेजर म्हणून नोंदणीकृत नाही",
+		"VENUE_NOT_ASSIGNED":          "तुम्हाला कोणताही वेन्यू दिलेला नाही. कृपया तुमच्या वेन्यू मॅनेजरला तुम्हाला जोडायला सांगा.",
+		"OTP_INVALID":                 "ओटीपी चुकीचा आहे",
+		"OTP_EXPIRED":                 "ओटीपीची मुदत संपली आहे",
+		"ROUTE_NOT_FOUND":             "हे पान अस्तित्वात नाही",
+		"METHOD_NOT_ALLOWED":          "येथे ही कृती समर्थित नाही",
+		"INTERNAL":                    "काहीतरी चुकले, कृपया पुन्हा प्रयत्न करा",
+		"SESSION_NOT_FOUND":           "पार्किंग सत्र सापडले नाही",
+		"NO_ACTIVE_SESSION":           "कोणतेही सक्रिय पार्किंग सत्र सापडले नाही",
+		"ACTIVE_SESSION_EXISTS":       "या वाहनाचे पार्किंग सत्र आधीच सक्रिय आहे",
+		"INVALID_TRANSITION":          "हे सत्र आता अशा प्रकारे बदलता येणार नाही",
+		"PICKUP_NOT_REQUESTED":        "ग्राहकाने अजून गाडी परत मागितलेली नाही",
+		"PRECONDITION_FAILED":         "सत्र दुसऱ्या कोणीतरी बदलले आहे, कृपया रीफ्रेश करा",
+		"CONCURRENT_UPDATE":           "जतन करताना काहीतरी बदलले, कृपया पुन्हा प्रयत्न करा",
+		"INSPECTION_NOT_ACKNOWLEDGED": "कृपया वाहन तपासणी पाहून तिची पुष्टी करा",
+		"INSPECTION_NOT_FOUND":        "कोणतीही तपासणी सापडली नाही",
+		"INSPECTION_EXISTS":           "तपासणी आधीच नोंदवली आहे",
+		"ALREADY_RATED":               "या सत्राला आधीच रेटिंग दिले आहे",
+		"RATING_NOT_FOUND":            "या सत्राला अजून रेटिंग दिलेले नाही",
+		"LOST_TICKET_FEE_NOT_SET":     "या वेन्यूसाठी हरवलेल्या तिकिटाचे शुल्क ठरलेले नाही",
+		"RELEASE_FEE_NOT_DUE":         "या सत्रावर हरवलेल्या तिकिटाचे कोणतेही शुल्क बाकी नाही",
+		"VEHICLE_NOT_FOUND":           "वाहन सापडले नाही",
+		"VEHICLE_ALREADY_REGISTERED":  "वाहन आधीच नोंदणीकृत आहे",
+		"VEHICLE_ARCHIVED":            "वाहन संग्रहित आहे",
+		"VEHICLE_IN_SESSION":          "वाहनाचे पार्किंग सत्र सक्रिय आहे",
+		"REGISTRATION_MISMATCH":       "नोंदणी क्रमांक या सत्राच्या वाहनाशी जुळत नाही",
+		"SHARE_NOT_FOUND":             "हे वाहन या फोन नंबरशी शेअर केलेले नाही",
+		"OWNERSHIP_ALREADY_VERIFIED":  "मालकी आधीच पडताळली आहे",
+		"OWNERSHIP_REJECTED":          "मालकीचा दावा नाकारला गेला. कृपया वेन्यूशी संपर्क साधा.",
+		"DOCUMENT_MISMATCH":           "कागदपत्र क्रमांक दाव्याशी जुळत नाही",
+		"USER_NOT_FOUND":              "वापरकर्ता सापडला नाही",
+		"VALET_NOT_FOUND":             "वॅले सापडला नाही",
+		"VALET_AT_OTHER_VENUE":        "हा वॅले दुसऱ्या वेन्यूवर काम करतो",
+		"KEY_TAG_NOT_FOUND":           "चावी टॅग सापडला नाही",
+		"KEY_TAG_EXISTS":              "या वेन्यूमध्ये हा चावी टॅग क्रमांक आधीच आहे",
+		"KEY_TAG_UNAVAILABLE":         "चावी टॅग उपलब्ध नाही",
+		"KEY_TAG_IN_USE":              "चावी टॅग एका सक्रिय सत्रात वापरात आहे",
+		"KEY_TAG_ALREADY_ASSIGNED":    "या सत्राला आधीच चावी टॅग आहे",
+		"KEY_TAG_MISMATCH":            "चावी टॅग या सत्राच्या चाव्यांशी जुळत नाही",
+		"KEY_RETURN_REQUIRED":         "दिल्या जाणाऱ्या चावीचा टॅग क्रमांक पुष्टी करा",
+		"NO_KEY_TAG":                  "या सत्राचा कोणताही चावी टॅग ताब्यात नाही",
+		"KEY_TAG_REQUIRED":            "गाडी पार्क केली म्हणून नोंदवण्यापूर्वी चावीला टॅग लावा",
+		"MEDIA_NOT_FOUND":             "फोटो सापडला नाही",
+		"UPLOAD_NOT_FOUND":            "अपलोड सापडले नाही किंवा आधीच पूर्ण झाले आहे",
+		"SIGNED_URL_INVALID":          "लिंक अवैध आहे किंवा तिची मुदत संपली आहे",
+		"FILE_TOO_LARGE":              "फाइल खूप मोठी आहे",
+		"REQUEST_TOO_LARGE":           "विनंती खूप मोठी आहे",
+		"UNSUPPORTED_MEDIA_TYPE":      "हा फाइल प्रकार समर्थित नाही",
+		"PLATE_NOT_FOUND":             "फोटोमध्ये नंबर प्लेट सापडली नाही",
+		"RECOGNITION_UNAVAILABLE":     "नंबर प्लेट ओळख उपलब्ध नाही, कृपया क्रमांक स्वतः टाका",
+		"INCIDENT_NOT_FOUND":          "घटना सापडली नाही",
+		"INCIDENT_WINDOW_CLOSED":      "घटनेची तक्रार डिलिव्हरीनंतर 7 दिवसांत करावी लागते",
+		"ALERT_NOT_FOUND":             "अलर्ट सापडला नाही किंवा आधीच स्वीकारला आहे",
+		"PAYMENT_DECLINED":            "पेमेंट नाकारले गेले",
+		"PAYMENT_UNAVAILABLE":         "ऑनलाइन पेमेंट उपलब्ध नाही, कृपया रोख टिप द्या",
+		"SIGNATURE_INVALID":           "विनंतीची स्वाक्षरी अवैध आहे",
+		"TIP_NOT_FOUND":               "टिप सापडली नाही",
+		"CASH_TIP_EXISTS":             "या सत्रासाठी रोख टिप आधीच नोंदवली आहे",
+		"IDEMPOTENCY_KEY_IN_USE":      "ही विनंती अजून प्रक्रियेत आहे",
+		"IDEMPOTENCY_KEY_REUSED":      "ही Idempotency-Key दुसऱ्या विनंतीसाठी आधीच वापरली आहे",
+
+		"OTP_SENT":                 "ओटीपी यशस्वीरित्या पाठवला",
+		"PROFILE_UPDATED":          "प्रोफाइल यशस्वीरित्या अपडेट झाली",
+		"PICKUP_REQUESTED":         "गाडी परत मागण्याची विनंती पाठवली",
+		"PICKUP_OTP_REGENERATED":   "नवीन पिकअप ओटीपी दिला",
+		"PARKING_ACCEPTED":         "पार्किंग स्वीकारले",
+		"PARKING_REJECTED":         "पार्किंग नाकारले",
+		"SESSION_CANCELLED":        "पार्किंग सत्र रद्द केले",
+		"PICKUP_CANCELLED":         "गाडी परत मागण्याची विनंती रद्द केली",
+		"VEHICLE_DELIVERED":        "वाहन यशस्वीरित्या सुपूर्द केले",
+		"STATUS_UPDATED":           "स्थिती यशस्वीरित्या अपडेट झाली",
+		"VEHICLE_RELEASED":         "वाहन सोडले",
+		"RELEASE_FEE_COLLECTED":    "हरवलेल्या तिकिटाचे शुल्क भरल्याची नोंद झाली",
+		"VEHICLE_ALREADY_ARCHIVED": "वाहन आधीच संग्रहित आहे",
+		"VEHICLE_REMOVED":          "वाहन काढले",
+		"SHARE_REMOVED":            "शेअरिंग काढले",
+		"OWNERSHIP_VERIFIED":       "मालकी पडताळली",
+		"KEY_TAG_ASSIGNED":         "चावी टॅग दिला",
+		"KEY_CUSTODY_UPDATED":      "चावीचा ताबा अपडेट झाला",
+		"VENUE_SETTINGS_UPDATED":   "वेन्यू सेटिंग्ज अपडेट झाल्या",
+		"VALET_ASSIGNED":           "वॅलेला वेन्यूमध्ये जोडले",
+		"VALET_UNASSIGNED":         "वॅलेला वेन्यूमधून काढले",
+		"ALERT_ACKNOWLEDGED":       "अलर्ट स्वीकारला",
+		"ALERT_LOW_RATING":         "तिकीट {ticket} ला {score}/5 रेटिंग मिळाले",
+		"ALERT_INCIDENT":           "तिकीट {ticket} वर {severity} {type} घटना नोंदवली",
+		"SMS_LOGIN_OTP":            "{otp} हा तुमचा वॅले पार्किंग साइन-इन कोड आहे. तो 5 मिनिटांत संपेल.",
+		"SMS_PICKUP_OTP":           "{venue} येथे तुमची गाडी आणली जात आहे. आल्यावर वॅलेला ओटीपी {otp} सांगा.",
+		"SMS_VEHICLE_DELIVERED":    "तुमची गाडी (तिकीट {ticket}) तुमच्याकडे सुपूर्द केली आहे. {venue} ला भेट दिल्याबद्दल धन्यवाद.",
+	},
+}
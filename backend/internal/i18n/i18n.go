@@ -0,0 +1,96 @@
+// Package i18n holds the catalog of user-facing messages in every language
+// the venues operate in, keyed by stable codes, and picks the language to
+// answer a request in.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang is a supported language, as an ISO 639-1 code
+type Lang string
+
+const (
+	English Lang = "en"
+	Hindi   Lang = "hi"
+	Marathi Lang = "mr"
+)
+
+// Default is used when a client accepts none of the supported languages
+const Default = English
+
+// Supported reports whether lang has a catalog
+func Supported(lang Lang) bool {
+	_, ok := catalog[lang]
+	return ok
+}
+
+// Negotiate picks the supported language a client prefers from an
+// Accept-Language header, e.g. "mr-IN, hi;q=0.8, en;q=0.5". It returns
+// Default if the header names none of them.
+func Negotiate(acceptLanguage string) Lang {
+	type choice struct {
+		lang Lang
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		// Only the primary subtag matters: hi-IN is Hindi
+		primary, _, _ := strings.Cut(tag, "-")
+		lang := Lang(strings.ToLower(primary))
+		if q > 0 && Supported(lang) {
+			choices = append(choices, choice{lang, q})
+		}
+	}
+	if len(choices) == 0 {
+		return Default
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].lang
+}
+
+// Message returns the message for key in lang, falling back to English and
+// then to the key itself
+func Message(lang Lang, key string) string {
+	if msg, ok := catalog[lang][key]; ok {
+		return msg
+	}
+	if msg, ok := catalog[English][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// Resolve picks the language for a user: their preferred language if it is
+// supported, otherwise the one negotiated from the Accept-Language header
+func Resolve(preferred, acceptLanguage string) Lang {
+	if Supported(Lang(preferred)) {
+		return Lang(preferred)
+	}
+	return Negotiate(acceptLanguage)
+}
+
+// Format returns the message for key in lang with each {name} placeholder
+// replaced by params[name]
+func Format(lang Lang, key string, params map[string]string) string {
+	msg := Message(lang, key)
+	if len(params) == 0 {
+		return msg
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
@@ -0,0 +1,245 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // Register PNG decoder
+	"net/http"
+)
+
+const (
+	MaxSourcePixels  = 25_000_000 // Rejects decompression bombs before decoding
+	MaxImageEdge     = 1600
+	ThumbnailEdge    = 320
+	OutputMediaType  = "image/jpeg"
+	jpegQuality      = 85
+	thumbnailQuality = 75
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type, must be JPEG or PNG")
+	ErrImageTooLarge   = errors.New("image dimensions too large")
+	ErrInvalidImage    = errors.New("file is not a valid image")
+)
+
+// Processed is a normalized image plus its thumbnail, both JPEG encoded
+type Processed struct {
+	Image     []byte
+	Thumbnail []byte
+	Width     int
+	Height    int
+}
+
+// ProcessImage validates an uploaded photo, applies its EXIF orientation,
+// downsizes it and generates a thumbnail. The output is re-encoded from raw
+// pixels, so EXIF and any other embedded metadata (GPS etc.) are dropped.
+func ProcessImage(data []byte) (*Processed, error) {
+	contentType := http.DetectContentType(data)
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxSourcePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	if contentType == "image/jpeg" {
+		src = applyOrientation(src, jpegOrientation(data))
+	}
+
+	full := fit(src, MaxImageEdge)
+	thumb := fit(full, ThumbnailEdge)
+
+	var fullBuf, thumbBuf bytes.Buffer
+	if err := jpeg.Encode(&fullBuf, full, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	if err := jpeg.Encode(&thumbBuf, thumb, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+
+	bounds := full.Bounds()
+	return &Processed{
+		Image:     fullBuf.Bytes(),
+		Thumbnail: thumbBuf.Bytes(),
+		Width:     bounds.Dx(),
+		Height:    bounds.Dy(),
+	}, nil
+}
+
+// fit scales img down so its longest edge is at most maxEdge. The result is
+// always an opaque RGBA image; transparent areas are flattened onto white.
+func fit(img image.Image, maxEdge int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxEdge || h > maxEdge {
+		if w >= h {
+			h = max(1, h*maxEdge/w)
+			w = maxEdge
+		} else {
+			w = max(1, w*maxEdge/h)
+			h = maxEdge
+		}
+	}
+
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+
+	if w == b.Dx() && h == b.Dy() {
+		return flat
+	}
+	return resizeArea(flat, w, h)
+}
+
+// resizeArea downsamples by averaging every source pixel that falls inside
+// each destination pixel
+func resizeArea(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for dy := 0; dy < h; dy++ {
+		y0 := dy * sh / h
+		y1 := max(y0+1, (dy+1)*sh/h)
+		for dx := 0; dx < w; dx++ {
+			x0 := dx * sw / w
+			x1 := max(x0+1, (dx+1)*sw/w)
+
+			var r, g, b, a, n uint32
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i+0] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation tag (1-8) from a JPEG, or 1 if absent
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if marker == 0xDA || size < 2 || pos+2+size > len(data) {
+			return 1 // Start of scan, metadata is over
+		}
+		segment := data[pos+4 : pos+2+size]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + size
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation transforms img so it displays upright for the given EXIF orientation
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	swap := orientation >= 5
+
+	dw, dh := w, h
+	if swap {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var nx, ny int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				nx, ny = w-1-x, y
+			case 3: // Rotated 180
+				nx, ny = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				nx, ny = x, h-1-y
+			case 5: // Transposed
+				nx, ny = y, x
+			case 6: // Rotated 90 clockwise
+				nx, ny = h-1-y, x
+			case 7: // Transversed
+				nx, ny = h-1-y, w-1-x
+			case 8: // Rotated 90 counter-clockwise
+				nx, ny = y, w-1-x
+			}
+			dst.Set(nx, ny, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
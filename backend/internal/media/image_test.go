@@ -0,0 +1,201 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{255, 0, 0, 255}
+	blue = color.RGBA{0, 0, 255, 255}
+)
+
+// exifJPEG returns a JPEG of img with an APP1 Exif segment holding the
+// given orientation, written in the given TIFF byte order
+func exifJPEG(t *testing.T, img image.Image, order binary.ByteOrder, orientation uint16) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	// TIFF header, then one IFD with a single SHORT orientation entry
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := encoded.Bytes()
+	out := append([]byte{}, data[:2]...) // SOI
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+// halves returns a w x h image whose left half is red and right half blue
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// near reports whether c is within JPEG tolerance of want
+func near(c color.Color, want color.RGBA) bool {
+	r, g, b, _ := c.RGBA()
+	diff := func(got uint32, want uint8) bool {
+		d := int(got>>8) - int(want)
+		return d > -40 && d < 40
+	}
+	return diff(r, want.R) && diff(g, want.G) && diff(b, want.B)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	img := halves(16, 8)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for orientation := uint16(1); orientation <= 8; orientation++ {
+			if got := jpegOrientation(exifJPEG(t, img, order, orientation)); got != int(orientation) {
+				t.Errorf("%s orientation %d read as %d", order, orientation, got)
+			}
+		}
+	}
+
+	var plain bytes.Buffer
+	if err := jpeg.Encode(&plain, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := jpegOrientation(plain.Bytes()); got != 1 {
+		t.Errorf("JPEG without EXIF read as %d", got)
+	}
+	if got := jpegOrientation(exifJPEG(t, img, binary.BigEndian, 9)); got != 1 {
+		t.Errorf("out-of-range orientation read as %d", got)
+	}
+	if got := jpegOrientation([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF}); got != 1 {
+		t.Errorf("truncated JPEG read as %d", got)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// Each case names where the red (left) half of a 16x8 image ends up
+	cases := []struct {
+		orientation   int
+		width, height int
+		redAt, blueAt image.Point
+	}{
+		{1, 16, 8, image.Pt(2, 4), image.Pt(13, 4)},
+		{2, 16, 8, image.Pt(13, 4), image.Pt(2, 4)},
+		{3, 16, 8, image.Pt(13, 4), image.Pt(2, 4)},
+		{4, 16, 8, image.Pt(2, 4), image.Pt(13, 4)},
+		{5, 8, 16, image.Pt(4, 2), image.Pt(4, 13)},
+		{6, 8, 16, image.Pt(4, 2), image.Pt(4, 13)},
+		{7, 8, 16, image.Pt(4, 13), image.Pt(4, 2)},
+		{8, 8, 16, image.Pt(4, 13), image.Pt(4, 2)},
+	}
+	for _, tc := range cases {
+		got := applyOrientation(halves(16, 8), tc.orientation)
+		b := got.Bounds()
+		if b.Dx() != tc.width || b.Dy() != tc.height {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", tc.orientation, b.Dx(), b.Dy(), tc.width, tc.height)
+			continue
+		}
+		if !near(got.At(tc.redAt.X, tc.redAt.Y), red) || !near(got.At(tc.blueAt.X, tc.blueAt.Y), blue) {
+			t.Errorf("orientation %d: red at %v is %v, blue at %v is %v", tc.orientation,
+				tc.redAt, got.At(tc.redAt.X, tc.redAt.Y), tc.blueAt, got.At(tc.blueAt.X, tc.blueAt.Y))
+		}
+	}
+}
+
+func TestProcessImageAppliesOrientation(t *testing.T) {
+	// A landscape photo taken with the camera turned; EXIF 6 says rotate it clockwise
+	p, err := ProcessImage(exifJPEG(t, halves(64, 32), binary.BigEndian, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Width != 32 || p.Height != 64 {
+		t.Fatalf("processed %dx%d, want 32x64", p.Width, p.Height)
+	}
+
+	out, err := jpeg.Decode(bytes.NewReader(p.Image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(out.At(16, 8), red) || !near(out.At(16, 56), blue) {
+		t.Fatalf("top is %v and bottom is %v, want red over blue", out.At(16, 8), out.At(16, 56))
+	}
+	if bytes.Contains(p.Image, []byte("Exif")) {
+		t.Fatal("EXIF kept in the processed image")
+	}
+}
+
+func TestResizeAreaAverages(t *testing.T) {
+	// 4x4 with a distinct value in each 2x2 block, plus one odd pixel
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	blocks := [2][2]uint8{{0, 100}, {200, 40}}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			v := blocks[y/2][x/2]
+			src.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	src.Set(1, 1, color.RGBA{80, 80, 80, 255}) // Top-left block averages to 20
+
+	dst := resizeArea(src, 2, 2)
+	want := [2][2]uint8{{20, 100}, {200, 40}}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			if got := dst.RGBAAt(x, y); got != (color.RGBA{want[y][x], want[y][x], want[y][x], 255}) {
+				t.Errorf("pixel (%d,%d) = %v, want %d", x, y, got, want[y][x])
+			}
+		}
+	}
+}
+
+func TestFitDimensions(t *testing.T) {
+	cases := []struct {
+		width, height, edge int
+		wantW, wantH        int
+	}{
+		{3200, 1600, 1600, 1600, 800},
+		{1200, 2400, 1600, 800, 1600},
+		{1600, 1600, 320, 320, 320},
+		{800, 600, 1600, 800, 600}, // Never upscaled
+		{4000, 2, 1600, 1600, 1},   // Keeps at least one pixel
+	}
+	for _, tc := range cases {
+		b := fit(image.NewRGBA(image.Rect(0, 0, tc.width, tc.height)), tc.edge).Bounds()
+		if b.Dx() != tc.wantW || b.Dy() != tc.wantH {
+			t.Errorf("fit %dx%d to %d: %dx%d, want %dx%d", tc.width, tc.height, tc.edge, b.Dx(), b.Dy(), tc.wantW, tc.wantH)
+		}
+	}
+
+	// Transparent pixels are flattened onto white
+	clear := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	if got := fit(clear, 10).RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("transparent pixel became %v, want white", got)
+	}
+}
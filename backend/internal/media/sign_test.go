@@ -0,0 +1,61 @@
+package media
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignKnownAnswer(t *testing.T) {
+	// HMAC-SHA256 of "download\n65f1c0ffee00000000000001\nthumb\n1767225600"
+	// under the key "media-secret", computed independently
+	expires := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	got := Sign([]byte("media-secret"), "download", "65f1c0ffee00000000000001", "thumb", expires)
+	if want := "06251adecd65ea5f202f326cdbacdd1e863d6c99acdd47fdc66073715d663d6e"; got != want {
+		t.Fatalf("signature %s, want %s", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("media-secret")
+	const id = "65f1c0ffee00000000000001"
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	param := strconv.FormatInt(expires.Unix(), 10)
+	signature := Sign(secret, "download", id, "full", expires)
+
+	if !Verify(secret, "download", id, "full", param, signature) {
+		t.Fatal("valid signature rejected")
+	}
+
+	past := time.Now().Add(-time.Minute).Truncate(time.Second)
+	cases := []struct {
+		name                              string
+		secret                            string
+		action, id, variant, expires, sig string
+	}{
+		{"expired", "media-secret", "download", id, "full", strconv.FormatInt(past.Unix(), 10), Sign(secret, "download", id, "full", past)},
+		{"wrong secret", "other-secret", "download", id, "full", param, signature},
+		{"wrong action", "media-secret", "upload", id, "full", param, signature},
+		{"wrong media", "media-secret", "download", "65f1c0ffee00000000000002", "full", param, signature},
+		{"wrong variant", "media-secret", "download", id, "thumb", param, signature},
+		{"extended expiry", "media-secret", "download", id, "full", strconv.FormatInt(expires.Add(time.Hour).Unix(), 10), signature},
+		{"malformed expiry", "media-secret", "download", id, "full", "soon", signature},
+		{"tampered signature", "media-secret", "download", id, "full", param, tamper(signature)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if Verify([]byte(tc.secret), tc.action, tc.id, tc.variant, tc.expires, tc.sig) {
+				t.Fatal("signature accepted")
+			}
+		})
+	}
+}
+
+// tamper changes the last hex digit of a signature
+func tamper(signature string) string {
+	last := "0"
+	if signature[len(signature)-1] == '0' {
+		last = "1"
+	}
+	return signature[:len(signature)-1] + last
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strings"
 
+	"valet-parking-backend/internal/apierr"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -12,6 +14,7 @@ type Claims struct {
 	UserID string `json:"user_id"`
 	Phone  string `json:"phone"`
 	Role   string `json:"role"`
+	Lang   string `json:"lang,omitempty"` // The user's preferred language, if any
 	jwt.RegisteredClaims
 }
 
@@ -19,15 +22,13 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			apierr.Abort(c, http.StatusUnauthorized, apierr.AuthRequired, "Authorization header required")
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
-			c.Abort()
+			apierr.Abort(c, http.StatusUnauthorized, apierr.AuthRequired, "Bearer token required")
 			return
 		}
 
@@ -36,15 +37,13 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			apierr.Abort(c, http.StatusUnauthorized, apierr.TokenInvalid, "Invalid token")
 			return
 		}
 
 		claims, ok := token.Claims.(*Claims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+			apierr.Abort(c, http.StatusUnauthorized, apierr.TokenInvalid, "Invalid token claims")
 			return
 		}
 
@@ -52,6 +51,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("phone", claims.Phone)
 		c.Set("role", claims.Role)
+		c.Set("lang", claims.Lang)
 
 		c.Next()
 	}
@@ -62,8 +62,7 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Role not found in token"})
-			c.Abort()
+			apierr.Abort(c, http.StatusUnauthorized, apierr.TokenInvalid, "Role not found in token")
 			return
 		}
 
@@ -75,7 +74,6 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 			}
 		}
 
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		c.Abort()
+		apierr.Abort(c, http.StatusForbidden, apierr.Forbidden, "Insufficient permissions")
 	}
 }
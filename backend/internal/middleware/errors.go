@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"valet-parking-backend/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorMiddleware renders an error a handler attached with c.Error but did
+// not respond to. API errors keep their status and code; anything else is
+// reported as an internal error without exposing its message.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		var apiErr *apierr.Error
+		if !errors.As(last.Err, &apiErr) {
+			apiErr = apierr.New(http.StatusInternalServerError, apierr.Internal, "")
+		}
+		apierr.Render(c, apiErr)
+	}
+}
+
+// NoRoute answers requests for paths the API does not serve
+func NoRoute(c *gin.Context) {
+	apierr.Abort(c, http.StatusNotFound, apierr.RouteNotFound, "")
+}
+
+// NoMethod answers requests with a method the path does not allow
+func NoMethod(c *gin.Context) {
+	apierr.Abort(c, http.StatusMethodNotAllowed, apierr.MethodNotAllowed, "")
+}
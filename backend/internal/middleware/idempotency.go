@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/apierr"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// maxReplayableBody caps the request bodies read to fingerprint a retry.
+// JSON requests are far smaller; uploads are not replayed at all.
+const maxReplayableBody = 64 << 10
+
+// redactedFieldsKey is the context key for response fields kept out of the
+// stored replay, see RedactReplay
+const redactedFieldsKey = "idempotency_redacted_fields"
+
+// claimLease is how long a request may run before a retry with its key may
+// take over, presuming it died. It is well past the handlers' own timeouts.
+const claimLease = time.Minute
+
+// Response headers worth replaying; the rest are per-response
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// recordingWriter keeps a copy of the response body as it is written
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// RedactReplay keeps the named top-level fields of the JSON response out of
+// the copy stored for replays, for secrets such as OTPs that must not sit in
+// the idempotency store. Replays answer without them.
+func RedactReplay(c *gin.Context, fields ...string) {
+	c.Set(redactedFieldsKey, fields)
+}
+
+// redact removes fields from a JSON object body. Bodies that are not JSON
+// objects are not stored at all, since the secret cannot be picked out.
+func redact(body []byte, fields []string) ([]byte, bool) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, false
+	}
+	for _, field := range fields {
+		delete(doc, field)
+	}
+	redacted, err := json.Marshal(doc)
+	return redacted, err == nil
+}
+
+// IdempotencyMiddleware makes POST requests that carry an Idempotency-Key
+// header safe to retry. The first response per key and user is stored for
+// ttl and replayed to retries with the same method, path and body, marked
+// with an Idempotent-Replayed header. Reusing a key for a different request
+// is rejected, as is a retry while the first request is still running.
+// Server errors are not stored, so those requests can be retried for real.
+// Multipart uploads are let through untouched, and other bodies over
+// maxReplayableBody are refused. It must run after AuthMiddleware; requests without a user are let through
+// untouched, since keys are only unique per user.
+func IdempotencyMiddleware(records store.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		userID := c.GetString("user_id")
+		if c.Request.Method != http.MethodPost || key == "" || userID == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			apierr.Abort(c, http.StatusBadRequest, apierr.ValidationFailed, "Idempotency-Key is too long")
+			return
+		}
+
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxReplayableBody))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierr.Abort(c, http.StatusRequestEntityTooLarge, apierr.RequestTooLarge, "Request body is too large")
+			return
+		}
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.InvalidRequest, "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		record, claimed, err := records.Claim(ctx, models.IdempotencyRecord{
+			Key:         key,
+			UserID:      userID,
+			RequestHash: requestHash,
+			LockedUntil: now.Add(claimLease),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		})
+		if err != nil {
+			apierr.Abort(c, http.StatusInternalServerError, apierr.Internal, "Failed to check idempotency key")
+			return
+		}
+
+		if !claimed {
+			switch {
+			case record.RequestHash != requestHash:
+				apierr.Abort(c, http.StatusUnprocessableEntity, apierr.IdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+			case record.Status == 0:
+				apierr.Abort(c, http.StatusConflict, apierr.IdempotencyKeyInUse, "A request with this Idempotency-Key is still in progress")
+			default:
+				for name, values := range record.Header {
+					c.Writer.Header()[name] = values
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Writer.WriteHeader(record.Status)
+				_, _ = c.Writer.Write(record.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// The handler's own timeout may have used up ctx
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer saveCancel()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			_ = records.Release(saveCtx, record.ID)
+			return
+		}
+		header := map[string][]string{}
+		for _, name := range replayedHeaders {
+			if values := writer.Header().Values(name); len(values) > 0 {
+				header[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		stored := writer.body.Bytes()
+		if fields := c.GetStringSlice(redactedFieldsKey); len(fields) > 0 {
+			var ok bool
+			if stored, ok = redact(stored, fields); !ok {
+				_ = records.Release(saveCtx, record.ID)
+				return
+			}
+		}
+		_ = records.Complete(saveCtx, record.ID, status, header, stored)
+	}
+}
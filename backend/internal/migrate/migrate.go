@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"valet-parking-backend/internal/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration is one versioned change to the database schema or data. Up must
+// be safe to re-run, since a crash between applying and recording it leaves
+// it pending.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, database *db.MongoDB) error
+}
+
+// Record marks a migration as applied in the migrations collection
+type Record struct {
+	Version   int       `bson:"_id" json:"version"`
+	Name      string    `bson:"name" json:"name"`
+	AppliedAt time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+// Each migration gets its own timeout, so long backfills don't starve the rest
+const migrationTimeout = 5 * time.Minute
+
+func collection(database *db.MongoDB) *mongo.Collection {
+	return database.Database.Collection("migrations")
+}
+
+// Applied returns the recorded migrations, oldest version first
+func Applied(database *db.MongoDB) ([]Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection(database).Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// history is where applied migrations are recorded
+type history interface {
+	applied(ctx context.Context) ([]Record, error)
+	record(ctx context.Context, m Migration) error
+}
+
+// mongoHistory keeps the history in the migrations collection
+type mongoHistory struct {
+	database *db.MongoDB
+}
+
+func (h mongoHistory) applied(ctx context.Context) ([]Record, error) {
+	return Applied(h.database)
+}
+
+// record upserts so a second instance finishing the same migration doesn't fail
+func (h mongoHistory) record(ctx context.Context, m Migration) error {
+	_, err := collection(h.database).UpdateOne(ctx,
+		bson.M{"_id": m.Version},
+		bson.M{"$set": bson.M{"name": m.Name, "applied_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Run applies every migration not yet recorded, in version order, and
+// returns how many it applied. It stops at the first failure.
+func Run(database *db.MongoDB) (int, error) {
+	return run(database, mongoHistory{database}, migrations)
+}
+
+func run(database *db.MongoDB, h history, list []Migration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	records, err := h.applied(ctx)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("reading applied migrations: %w", err)
+	}
+	done := make(map[int]bool, len(records))
+	for _, r := range records {
+		done[r.Version] = true
+	}
+
+	list = slices.Clone(list)
+	slices.SortFunc(list, func(a, b Migration) int { return a.Version - b.Version })
+
+	applied := 0
+	for _, m := range list {
+		if done[m.Version] {
+			continue
+		}
+		if err := apply(database, h, m); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		log.Printf("Applied migration %d: %s", m.Version, m.Name)
+		applied++
+	}
+	return applied, nil
+}
+
+func apply(database *db.MongoDB, h history, m Migration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	if err := m.Up(ctx, database); err != nil {
+		return err
+	}
+	return h.record(ctx, m)
+}
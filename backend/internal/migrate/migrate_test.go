@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"valet-parking-backend/internal/db"
+)
+
+// memoryHistory is a history kept in a slice
+type memoryHistory struct {
+	records []Record
+}
+
+func (h *memoryHistory) applied(ctx context.Context) ([]Record, error) {
+	return h.records, nil
+}
+
+func (h *memoryHistory) record(ctx context.Context, m Migration) error {
+	h.records = append(h.records, Record{Version: m.Version, Name: m.Name})
+	return nil
+}
+
+func (h *memoryHistory) versions() []int {
+	var versions []int
+	for _, r := range h.records {
+		versions = append(versions, r.Version)
+	}
+	return versions
+}
+
+// fakeMigrations returns migrations that note when they run, failing those
+// listed in fail
+func fakeMigrations(ran *[]int, fail map[int]bool, versions ...int) []Migration {
+	var list []Migration
+	for _, v := range versions {
+		list = append(list, Migration{
+			Version: v,
+			Name:    "test",
+			Up: func(ctx context.Context, database *db.MongoDB) error {
+				*ran = append(*ran, v)
+				if fail[v] {
+					return errors.New("boom")
+				}
+				return nil
+			},
+		})
+	}
+	return list
+}
+
+func TestRunAppliesPendingMigrationsInOrder(t *testing.T) {
+	var ran []int
+	h := &memoryHistory{records: []Record{{Version: 2}}}
+
+	applied, err := run(nil, h, fakeMigrations(&ran, nil, 3, 1, 2, 4))
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if applied != 3 || !slices.Equal(ran, []int{1, 3, 4}) {
+		t.Fatalf("applied %d, ran %v; want 3, [1 3 4]", applied, ran)
+	}
+	if !slices.Equal(h.versions(), []int{2, 1, 3, 4}) {
+		t.Fatalf("recorded %v", h.versions())
+	}
+
+	// Everything is recorded now, so a second run does nothing
+	ran = nil
+	if applied, err := run(nil, h, fakeMigrations(&ran, nil, 1, 2, 3, 4)); err != nil || applied != 0 || len(ran) != 0 {
+		t.Fatalf("second run applied %d, ran %v: %v", applied, ran, err)
+	}
+}
+
+func TestRunStopsAtFirstFailure(t *testing.T) {
+	var ran []int
+	h := &memoryHistory{}
+
+	applied, err := run(nil, h, fakeMigrations(&ran, map[int]bool{2: true}, 1, 2, 3))
+	if err == nil {
+		t.Fatal("run succeeded despite a failing migration")
+	}
+	if applied != 1 || !slices.Equal(ran, []int{1, 2}) {
+		t.Fatalf("applied %d, ran %v; want 1, [1 2]", applied, ran)
+	}
+	// The failed migration stays pending and is retried first next time
+	if !slices.Equal(h.versions(), []int{1}) {
+		t.Fatalf("recorded %v, want [1]", h.versions())
+	}
+
+	ran = nil
+	if applied, err := run(nil, h, fakeMigrations(&ran, nil, 1, 2, 3)); err != nil || applied != 2 || !slices.Equal(ran, []int{2, 3}) {
+		t.Fatalf("retry applied %d, ran %v: %v", applied, ran, err)
+	}
+}
+
+func TestMigrationVersionsIncrease(t *testing.T) {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Fatalf("migration %q has version %d, want %d: versions are never reused or reordered", m.Name, m.Version, i+1)
+		}
+	}
+}
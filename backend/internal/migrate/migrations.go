@@ -0,0 +1,207 @@
+package migrate
+
+import (
+	"context"
+
+	"valet-parking-backend/internal/db"
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrations lists every schema change in the order it is applied. Versions
+// are never reused or reordered; add new ones at the end.
+var migrations = []Migration{
+	{Version: 1, Name: "backfill vehicle registrations", Up: backfillVehicleRegistrations},
+	{Version: 2, Name: "create query indexes", Up: createQueryIndexes},
+	{Version: 3, Name: "expire otps", Up: expireOTPs},
+	{Version: 4, Name: "one active session per vehicle", Up: uniqueActiveSessions},
+	{Version: 5, Name: "version sessions", Up: versionSessions},
+	{Version: 6, Name: "index idempotency keys", Up: indexIdempotencyKeys},
+	{Version: 7, Name: "one cash tip per session", Up: uniqueCashTips},
+	{Version: 8, Name: "normalize share phones", Up: normalizeSharePhones},
+	{Version: 9, Name: "require key tags", Up: requireKeyTags},
+	{Version: 10, Name: "default low rating threshold", Up: defaultLowRatingThreshold},
+	{Version: 11, Name: "default tip settings", Up: defaultTipSettings},
+	{Version: 12, Name: "normalize user phones", Up: normalizeUserPhones},
+}
+
+// index builds a named index model over the given ascending or descending keys
+func index(name string, keys bson.D) mongo.IndexModel {
+	return mongo.IndexModel{Keys: keys, Options: options.Index().SetName(name)}
+}
+
+// uniqueIndex is index with a uniqueness constraint
+func uniqueIndex(name string, keys bson.D) mongo.IndexModel {
+	return mongo.IndexModel{Keys: keys, Options: options.Index().SetName(name).SetUnique(true)}
+}
+
+func createQueryIndexes(ctx context.Context, database *db.MongoDB) error {
+	if err := reportDuplicateUsers(ctx, database); err != nil {
+		return err
+	}
+
+	indexes := []struct {
+		coll   *mongo.Collection
+		models []mongo.IndexModel
+	}{
+		{database.Users(), []mongo.IndexModel{
+			// Login finds or creates exactly one account per phone and role
+			uniqueIndex("phone_1_role_1", bson.D{{Key: "phone", Value: 1}, {Key: "role", Value: 1}}),
+			index("venue_name_1_role_1", bson.D{{Key: "venue_name", Value: 1}, {Key: "role", Value: 1}}),
+		}},
+		{database.Vehicles(), []mongo.IndexModel{
+			index("owner_id_1", bson.D{{Key: "owner_id", Value: 1}}),
+			index("registration_number_1", bson.D{{Key: "registration_number", Value: 1}}),
+			index("shared_with.phone_1", bson.D{{Key: "shared_with.phone", Value: 1}}),
+		}},
+		{database.Sessions(), []mongo.IndexModel{
+			// Listings sort newest parked first, so each access path ends in parked_at
+			index("customer_id_1_parked_at_-1", bson.D{{Key: "customer_id", Value: 1}, {Key: "parked_at", Value: -1}}),
+			index("valet_id_1_parked_at_-1", bson.D{{Key: "valet_id", Value: 1}, {Key: "parked_at", Value: -1}}),
+			index("status_1_parked_at_-1", bson.D{{Key: "status", Value: 1}, {Key: "parked_at", Value: -1}}),
+			index("venue_name_1_status_1_parked_at_-1", bson.D{{Key: "venue_name", Value: 1}, {Key: "status", Value: 1}, {Key: "parked_at", Value: -1}}),
+			index("vehicle_id_1_status_1", bson.D{{Key: "vehicle_id", Value: 1}, {Key: "status", Value: 1}}),
+			index("ticket_number_1", bson.D{{Key: "ticket_number", Value: 1}}),
+		}},
+		{database.OTPs(), []mongo.IndexModel{
+			index("phone_1_otp_1", bson.D{{Key: "phone", Value: 1}, {Key: "otp", Value: 1}}),
+		}},
+		{database.KeyTags(), []mongo.IndexModel{
+			uniqueIndex("venue_name_1_number_1", bson.D{{Key: "venue_name", Value: 1}, {Key: "number", Value: 1}}),
+		}},
+		{database.Inspections(), []mongo.IndexModel{
+			index("session_id_1", bson.D{{Key: "session_id", Value: 1}}),
+		}},
+		{database.Incidents(), []mongo.IndexModel{
+			index("session_id_1", bson.D{{Key: "session_id", Value: 1}}),
+			index("venue_name_1_created_at_-1", bson.D{{Key: "venue_name", Value: 1}, {Key: "created_at", Value: -1}}),
+		}},
+		{database.Ratings(), []mongo.IndexModel{
+			index("session_id_1", bson.D{{Key: "session_id", Value: 1}}),
+		}},
+		{database.Tips(), []mongo.IndexModel{
+			index("session_id_1", bson.D{{Key: "session_id", Value: 1}}),
+		}},
+		{database.AuditLogs(), []mongo.IndexModel{
+			index("venue_name_1_created_at_-1", bson.D{{Key: "venue_name", Value: 1}, {Key: "created_at", Value: -1}}),
+		}},
+	}
+
+	for _, idx := range indexes {
+		if _, err := idx.coll.Indexes().CreateMany(ctx, idx.models); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireOTPs lets MongoDB delete login codes once they expire, instead of
+// leaving unused ones behind forever
+func expireOTPs(ctx context.Context, database *db.MongoDB) error {
+	_, err := database.OTPs().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// uniqueActiveSessions flags open sessions as active and lets only one active
+// session exist per vehicle. Index creation fails if a vehicle already has
+// two open sessions; close the stale one and run the migration again.
+func uniqueActiveSessions(ctx context.Context, database *db.MongoDB) error {
+	_, err := database.Sessions().UpdateMany(ctx,
+		bson.M{"status": bson.M{"$nin": models.ClosedStatuses}, "active": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"active": true}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.Sessions().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "vehicle_id", Value: 1}},
+		Options: options.Index().
+			SetName("vehicle_id_1_active").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"active": true}),
+	})
+	return err
+}
+
+// versionSessions starts sessions from before optimistic concurrency at version 1
+func versionSessions(ctx context.Context, database *db.MongoDB) error {
+	_, err := database.Sessions().UpdateMany(ctx,
+		bson.M{"version": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"version": 1}},
+	)
+	return err
+}
+
+// indexIdempotencyKeys keeps one record per user and key and lets MongoDB
+// delete them once their replay window ends
+func indexIdempotencyKeys(ctx context.Context, database *db.MongoDB) error {
+	_, err := database.IdempotencyKeys().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		uniqueIndex("user_id_1_key_1", bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}}),
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// uniqueCashTips lets valets record at most one cash tip per session, so cash
+// entries cannot be repeated to inflate payouts. Index creation fails if a
+// session already has two; remove the extra entry and run the migration again.
+func uniqueCashTips(ctx context.Context, database *db.MongoDB) error {
+	_, err := database.Tips().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "session_id", Value: 1}},
+		Options: options.Index().
+			SetName("session_id_1_cash").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"method": models.TipCash}),
+	})
+	return err
+}
+
+// requireKeyTags turns the key tag requirement on for venues configured before
+// it existed, matching what unconfigured venues get
+func requireKeyTags(ctx context.Context, database *db.MongoDB) error {
+	_, err := database.Venues().UpdateMany(ctx,
+		bson.M{"require_key_tag": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"require_key_tag": true}},
+	)
+	return err
+}
+
+// defaultLowRatingThreshold gives venues saved before low-rating alerts
+// existed the default threshold; a missing field would decode as 0, which
+// turns the alerts off
+func defaultLowRatingThreshold(ctx context.Context, database *db.MongoDB) error {
+	_, err := database.Venues().UpdateMany(ctx,
+		bson.M{"low_rating_threshold": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"low_rating_threshold": models.DefaultLowRating}},
+	)
+	return err
+}
+
+// defaultTipSettings fills in the tip parking share and currency on venues
+// saved before tips existed, each only where it is missing
+func defaultTipSettings(ctx context.Context, database *db.MongoDB) error {
+	defaults := bson.M{
+		"tip_parking_share": models.DefaultTipParkingShare,
+		"currency":          models.DefaultCurrency,
+	}
+	for field, value := range defaults {
+		_, err := database.Venues().UpdateMany(ctx,
+			bson.M{field: bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{field: value}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"valet-parking-backend/internal/db"
+	"valet-parking-backend/internal/phone"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reportDuplicateUsers fails when several accounts share a phone and role,
+// which the unique login index cannot be built over. Each such account may
+// own vehicles and sessions, so they are logged for an operator to merge
+// rather than merged here; run the migration again once they are.
+func reportDuplicateUsers(ctx context.Context, database *db.MongoDB) error {
+	cursor, err := database.Users().Aggregate(ctx, []bson.M{
+		{"$group": bson.M{
+			"_id":   bson.M{"phone": "$phone", "role": "$role"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	var duplicates []struct {
+		Key struct {
+			Role string `bson:"role"`
+		} `bson:"_id"`
+		IDs []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	// Phone numbers stay out of the logs; the IDs are enough to find them
+	for _, d := range duplicates {
+		log.Printf("Duplicate %s accounts share a phone number: %v", d.Key.Role, d.IDs)
+	}
+	return fmt.Errorf("%d phone numbers have more than one account with the same role; merge the logged accounts and run the migration again", len(duplicates))
+}
+
+// normalizeUserPhones rewrites account phones to E.164, the form login and
+// search now look them up by. Numbers that do not normalize are kept as they
+// are. Two accounts of one role whose numbers normalize alike cannot both keep
+// theirs, so, as in reportDuplicateUsers, they are logged for an operator to
+// merge and nothing changes until they are.
+func normalizeUserPhones(ctx context.Context, database *db.MongoDB) error {
+	cursor, err := database.Users().Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"phone": 1, "role": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	type account struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Phone string             `bson:"phone"`
+		Role  string             `bson:"role"`
+	}
+	type login struct{ phone, role string }
+	accounts := make(map[login][]account)
+	for cursor.Next(ctx) {
+		var a account
+		if err := cursor.Decode(&a); err != nil {
+			return err
+		}
+		normalized, err := phone.Normalize(a.Phone)
+		if err != nil {
+			normalized = a.Phone
+		}
+		key := login{normalized, a.Role}
+		accounts[key] = append(accounts[key], a)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	var updates []mongo.WriteModel
+	duplicates := 0
+	for key, same := range accounts {
+		if len(same) > 1 {
+			ids := make([]primitive.ObjectID, len(same))
+			for i, a := range same {
+				ids[i] = a.ID
+			}
+			log.Printf("Duplicate %s accounts share a phone number once normalized: %v", key.role, ids)
+			duplicates++
+			continue
+		}
+		if same[0].Phone != key.phone {
+			updates = append(updates, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": same[0].ID}).
+				SetUpdate(bson.M{"$set": bson.M{"phone": key.phone}}))
+		}
+	}
+	if duplicates > 0 {
+		return fmt.Errorf("%d phone numbers have more than one account with the same role once normalized; merge the logged accounts and run the migration again", duplicates)
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	result, err := database.Users().BulkWrite(ctx, updates)
+	if err != nil {
+		return err
+	}
+	log.Printf("Normalized phones on %d accounts", result.ModifiedCount)
+	return nil
+}
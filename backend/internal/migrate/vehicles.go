@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	"valet-parking-backend/internal/db"
+	"valet-parking-backend/internal/models"
+	"valet-parking-backend/internal/phone"
+	"valet-parking-backend/internal/plate"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// backfillVehicleRegistrations indexes the normalized registration field and
+// fills it and the ownership state in for vehicles created before they existed
+func backfillVehicleRegistrations(ctx context.Context, m *db.MongoDB) error {
+	_, err := m.Vehicles().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "normalized_registration", Value: 1}},
+		Options: options.Index().SetName("normalized_registration_1"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Vehicles from before plate ownership tracking start out unverified
+	_, err = m.Vehicles().UpdateMany(ctx,
+		bson.M{"ownership": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"ownership.status": models.OwnershipUnverified}},
+	)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := m.Vehicles().Find(ctx,
+		bson.M{"normalized_registration": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"registration_number": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID                 interface{} `bson:"_id"`
+			RegistrationNumber string      `bson:"registration_number"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": doc.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"normalized_registration": plate.Normalize(doc.RegistrationNumber)}}))
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	result, err := m.Vehicles().BulkWrite(ctx, updates)
+	if err != nil {
+		return err
+	}
+	log.Printf("Backfilled normalized registration for %d vehicles", result.ModifiedCount)
+	return nil
+}
+
+// normalizeSharePhones rewrites vehicle share phones to E.164, which is how
+// shares are now stored and matched. When two spellings of one number were
+// shared separately, the later invitation wins, as sharing again would.
+// Numbers that do not normalize are kept as they are.
+func normalizeSharePhones(ctx context.Context, m *db.MongoDB) error {
+	cursor, err := m.Vehicles().Find(ctx,
+		bson.M{"shared_with.0": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"shared_with": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID         interface{}           `bson:"_id"`
+			SharedWith []models.VehicleShare `bson:"shared_with"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+
+		changed := false
+		shares := make([]models.VehicleShare, 0, len(doc.SharedWith))
+		for _, share := range doc.SharedWith {
+			if normalized, err := phone.Normalize(share.Phone); err == nil && normalized != share.Phone {
+				share.Phone = normalized
+				changed = true
+			}
+			shares = slices.DeleteFunc(shares, func(s models.VehicleShare) bool {
+				if s.Phone == share.Phone {
+					changed = true
+					return true
+				}
+				return false
+			})
+			shares = append(shares, share)
+		}
+		if !changed {
+			continue
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": doc.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"shared_with": shares}}))
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	result, err := m.Vehicles().BulkWrite(ctx, updates)
+	if err != nil {
+		return err
+	}
+	log.Printf("Normalized share phones on %d vehicles", result.ModifiedCount)
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AlertType string
+
+const (
+	AlertLowRating AlertType = "low_rating"
+	AlertIncident  AlertType = "incident"
+)
+
+// Alert is a notice for venue managers that needs their attention
+type Alert struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	VenueName      string              `bson:"venue_name" json:"venue_name"`
+	Type           AlertType           `bson:"type" json:"type"`
+	Message        string              `bson:"message" json:"message"`    // In English; localized from Params when listed
+	Params         map[string]string   `bson:"params,omitempty" json:"-"` // Values for the alert type's message template
+	SessionID      *primitive.ObjectID `bson:"session_id,omitempty" json:"session_id,omitempty"`
+	RefID          *primitive.ObjectID `bson:"ref_id,omitempty" json:"ref_id,omitempty"` // Rating, incident, etc. that raised the alert
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+	AcknowledgedAt *time.Time          `bson:"acknowledged_at,omitempty" json:"acknowledged_at,omitempty"`
+	AcknowledgedBy *primitive.ObjectID `bson:"acknowledged_by,omitempty" json:"acknowledged_by,omitempty"`
+}
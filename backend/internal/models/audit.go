@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditAction string
+
+const (
+	AuditManualRelease         AuditAction = "session.manual_release"
+	AuditManualReleaseRejected AuditAction = "session.manual_release_rejected"
+	AuditReleaseFeeCollected   AuditAction = "session.release_fee_collected"
+	AuditVenueSettingsUpdated  AuditAction = "venue.settings_updated"
+	AuditValetAssigned         AuditAction = "venue.valet_assigned"
+	AuditValetUnassigned       AuditAction = "venue.valet_unassigned"
+	AuditCashTipRecorded       AuditAction = "tip.cash_recorded"
+)
+
+// AuditLog is an append-only record of a privileged action
+type AuditLog struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Action    AuditAction            `bson:"action" json:"action"`
+	ActorID   primitive.ObjectID     `bson:"actor_id" json:"actor_id"`
+	ActorRole Role                   `bson:"actor_role" json:"actor_role"`
+	VenueName string                 `bson:"venue_name" json:"venue_name"`
+	SessionID *primitive.ObjectID    `bson:"session_id,omitempty" json:"session_id,omitempty"`
+	Details   map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	IP        string                 `bson:"ip,omitempty" json:"ip,omitempty"`
+	CreatedAt time.Time              `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyRecord remembers the first response to a request sent with an
+// Idempotency-Key, so a retry gets the same response instead of repeating
+// the change
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty"`
+	Key         string              `bson:"key"`
+	UserID      string              `bson:"user_id"`
+	RequestHash string              `bson:"request_hash"` // Method, path and body of the first request
+	Status      int                 `bson:"status"`       // Zero while the first request is still running
+	LockedUntil time.Time           `bson:"locked_until"` // A running request that outlives this is presumed dead
+	Header      map[string][]string `bson:"header,omitempty"`
+	Body        []byte              `bson:"body,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at"`
+	ExpiresAt   time.Time           `bson:"expires_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IncidentType string
+
+const (
+	IncidentDamage      IncidentType = "damage"
+	IncidentMissingItem IncidentType = "missing_item" // Belongings missing from the car
+	IncidentMisuse      IncidentType = "misuse"       // Car driven further or faster than needed
+	IncidentDelay       IncidentType = "delay"
+	IncidentOther       IncidentType = "other"
+)
+
+type IncidentSeverity string
+
+const (
+	SeverityLow      IncidentSeverity = "low"
+	SeverityMedium   IncidentSeverity = "medium"
+	SeverityHigh     IncidentSeverity = "high"
+	SeverityCritical IncidentSeverity = "critical"
+)
+
+type IncidentStatus string
+
+const (
+	IncidentOpen          IncidentStatus = "open"          // Filed, not yet looked at
+	IncidentInvestigating IncidentStatus = "investigating" // Manager is reviewing evidence
+	IncidentResolved      IncidentStatus = "resolved"      // Claim accepted and settled
+	IncidentRejected      IncidentStatus = "rejected"      // Claim not upheld
+)
+
+// IncidentEvent is one step in an incident's triage history
+type IncidentEvent struct {
+	Status IncidentStatus     `bson:"status" json:"status"`
+	Note   string             `bson:"note,omitempty" json:"note,omitempty"`
+	By     primitive.ObjectID `bson:"by" json:"by"`
+	At     time.Time          `bson:"at" json:"at"`
+}
+
+// Incident is a complaint or damage claim filed against a session
+type Incident struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	SessionID     primitive.ObjectID   `bson:"session_id" json:"session_id"`
+	VehicleID     primitive.ObjectID   `bson:"vehicle_id" json:"vehicle_id"`
+	VenueName     string               `bson:"venue_name" json:"venue_name"`
+	Type          IncidentType         `bson:"type" json:"type"`
+	Severity      IncidentSeverity     `bson:"severity" json:"severity"`
+	Status        IncidentStatus       `bson:"status" json:"status"`
+	Description   string               `bson:"description" json:"description"`
+	Photos        []string             `bson:"photos,omitempty" json:"photos,omitempty"` // Media IDs
+	InspectionIDs []primitive.ObjectID `bson:"inspection_ids,omitempty" json:"inspection_ids,omitempty"`
+	ReportedBy    primitive.ObjectID   `bson:"reported_by" json:"reported_by"`
+	ReporterRole  Role                 `bson:"reporter_role" json:"reporter_role"`
+	Resolution    string               `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	History       []IncidentEvent      `bson:"history" json:"history"`
+	CreatedAt     time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time            `bson:"updated_at" json:"updated_at"`
+}
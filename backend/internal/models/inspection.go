@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InspectionStage string
+
+const (
+	InspectionCheckIn  InspectionStage = "check_in"  // Recorded when the valet takes the car
+	InspectionCheckOut InspectionStage = "check_out" // Recorded when the car is handed back
+)
+
+type DamageType string
+
+const (
+	DamageScratch DamageType = "scratch"
+	DamageDent    DamageType = "dent"
+	DamageCrack   DamageType = "crack"
+	DamageBroken  DamageType = "broken"
+	DamageMissing DamageType = "missing"
+	DamageOther   DamageType = "other"
+)
+
+// DamageMarker pins a damage observation on the vehicle diagram.
+// X and Y are relative diagram coordinates between 0 and 1.
+type DamageMarker struct {
+	Zone     string     `bson:"zone" json:"zone"` // e.g., "front_bumper", "left_rear_door"
+	Type     DamageType `bson:"type" json:"type"`
+	X        float64    `bson:"x" json:"x"`
+	Y        float64    `bson:"y" json:"y"`
+	Severity string     `bson:"severity,omitempty" json:"severity,omitempty"` // minor, moderate, major
+	Note     string     `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+type Inspection struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	SessionID      primitive.ObjectID  `bson:"session_id" json:"session_id"`
+	VehicleID      primitive.ObjectID  `bson:"vehicle_id" json:"vehicle_id"`
+	Stage          InspectionStage     `bson:"stage" json:"stage"`
+	InspectorID    primitive.ObjectID  `bson:"inspector_id" json:"inspector_id"`
+	DamageMarkers  []DamageMarker      `bson:"damage_markers" json:"damage_markers"`
+	FuelLevel      *int                `bson:"fuel_level,omitempty" json:"fuel_level,omitempty"` // Percent of tank
+	OdometerKm     *int                `bson:"odometer_km,omitempty" json:"odometer_km,omitempty"`
+	Photos         []string            `bson:"photos,omitempty" json:"photos,omitempty"`
+	Notes          string              `bson:"notes,omitempty" json:"notes,omitempty"`
+	AcknowledgedAt *time.Time          `bson:"acknowledged_at,omitempty" json:"acknowledged_at,omitempty"`
+	AcknowledgedBy *primitive.ObjectID `bson:"acknowledged_by,omitempty" json:"acknowledged_by,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type KeyTagStatus string
+
+const (
+	KeyTagAvailable KeyTagStatus = "available" // On its hook, free to assign
+	KeyTagInUse     KeyTagStatus = "in_use"    // Holding the keys of an active session
+	KeyTagRetired   KeyTagStatus = "retired"   // Lost or taken out of circulation
+)
+
+// KeyTag is a physical numbered tag in a venue's key inventory
+type KeyTag struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	VenueName string              `bson:"venue_name" json:"venue_name"`
+	Number    string              `bson:"number" json:"number"`
+	Location  string              `bson:"location" json:"location"` // Hook or key box the tag lives on, e.g. "Box 2 - Hook 14"
+	Status    KeyTagStatus        `bson:"status" json:"status"`
+	SessionID *primitive.ObjectID `bson:"session_id,omitempty" json:"session_id,omitempty"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// SessionKey records which key tag holds a session's keys and where they are
+type SessionKey struct {
+	TagID      primitive.ObjectID  `bson:"tag_id" json:"tag_id"`
+	Number     string              `bson:"number" json:"number"`
+	Location   string              `bson:"location" json:"location"`
+	HolderID   *primitive.ObjectID `bson:"holder_id,omitempty" json:"holder_id,omitempty"` // Valet currently carrying the keys, if not on the hook
+	AssignedAt time.Time           `bson:"assigned_at" json:"assigned_at"`
+	ReturnedAt *time.Time          `bson:"returned_at,omitempty" json:"returned_at,omitempty"`
+}
+
+type KeyCustodyAction string
+
+const (
+	KeyAssigned KeyCustodyAction = "assigned" // Tag put on the keys at check-in
+	KeyHandoff  KeyCustodyAction = "handoff"  // Keys passed to another valet
+	KeyStored   KeyCustodyAction = "stored"   // Keys put back on a hook or in a box
+	KeyReturned KeyCustodyAction = "returned" // Keys handed back to the customer
+	KeyReleased KeyCustodyAction = "released" // Tag freed without a delivery, e.g. session cancelled
+)
+
+// KeyCustodyEntry is one step in the chain of custody of a session's keys
+type KeyCustodyEntry struct {
+	Action    KeyCustodyAction    `bson:"action" json:"action"`
+	FromValet *primitive.ObjectID `bson:"from_valet,omitempty" json:"from_valet,omitempty"`
+	ToValet   *primitive.ObjectID `bson:"to_valet,omitempty" json:"to_valet,omitempty"`
+	Location  string              `bson:"location,omitempty" json:"location,omitempty"`
+	Note      string              `bson:"note,omitempty" json:"note,omitempty"`
+	By        primitive.ObjectID  `bson:"by" json:"by"`
+	At        time.Time           `bson:"at" json:"at"`
+}
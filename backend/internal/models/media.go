@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MediaKind string
+
+const (
+	MediaKindVehicle    MediaKind = "vehicle"    // Owner-provided vehicle photos
+	MediaKindInspection MediaKind = "inspection" // Valet photos taken during an inspection
+	MediaKindIncident   MediaKind = "incident"   // Evidence attached to an incident report
+)
+
+type MediaStatus string
+
+const (
+	MediaStatusPending MediaStatus = "pending" // Upload slot issued, bytes not received yet
+	MediaStatusReady   MediaStatus = "ready"
+)
+
+type Media struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID      primitive.ObjectID `bson:"owner_id" json:"owner_id"` // Uploader
+	Kind         MediaKind          `bson:"kind" json:"kind"`
+	Status       MediaStatus        `bson:"status" json:"status"`
+	Key          string             `bson:"key,omitempty" json:"-"`
+	ThumbnailKey string             `bson:"thumbnail_key,omitempty" json:"-"`
+	ContentType  string             `bson:"content_type,omitempty" json:"content_type,omitempty"`
+	Width        int                `bson:"width,omitempty" json:"width,omitempty"`
+	Height       int                `bson:"height,omitempty" json:"height,omitempty"`
+	Size         int                `bson:"size,omitempty" json:"size,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UploadedAt   *time.Time         `bson:"uploaded_at,omitempty" json:"uploaded_at,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RatingTags are the quick-pick labels a customer can attach to a rating
+var RatingTags = map[string]bool{
+	"quick":        true,
+	"friendly":     true,
+	"careful":      true,
+	"professional": true,
+	"slow":         true,
+	"rude":         true,
+	"careless":     true,
+	"car_dirty":    true,
+	"seat_moved":   true,
+}
+
+// Rating is a customer's feedback on a delivered session. It counts towards
+// both the valet who parked the car and the one who delivered it.
+type Rating struct {
+	ID         primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	SessionID  primitive.ObjectID   `bson:"session_id" json:"session_id"`
+	CustomerID primitive.ObjectID   `bson:"customer_id" json:"customer_id"`
+	VenueName  string               `bson:"venue_name" json:"venue_name"`
+	ValetIDs   []primitive.ObjectID `bson:"valet_ids" json:"valet_ids"`
+	Score      int                  `bson:"score" json:"score"` // 1-5
+	Tags       []string             `bson:"tags,omitempty" json:"tags,omitempty"`
+	Comment    string               `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt  time.Time            `bson:"created_at" json:"created_at"`
+}
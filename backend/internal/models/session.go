@@ -18,25 +18,87 @@ const (
 	StatusAvailable     SessionStatus = "available"      // Car is ready for pickup
 	StatusDelivered     SessionStatus = "delivered"      // Car delivered to customer
 	StatusCancelled     SessionStatus = "cancelled"      // Request cancelled
+	StatusRejected      SessionStatus = "rejected"       // Customer rejected the parking request
 	StatusInTransit     SessionStatus = "in_transit"
 )
 
+// ClosedStatuses are the terminal states; any other status means the session is active
+var ClosedStatuses = []SessionStatus{StatusDelivered, StatusCancelled, StatusRejected}
+
+type RejectionReason string
+
+const (
+	RejectNotMyVehicle RejectionReason = "not_my_vehicle" // Valet linked the car to the wrong customer
+	RejectNotRequested RejectionReason = "not_requested"  // Customer did not ask for valet parking
+	RejectChangedMind  RejectionReason = "changed_mind"
+	RejectOther        RejectionReason = "other"
+)
+
 type ParkingSession struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	TicketNumber string             `bson:"ticket_number" json:"ticket_number"`
-	VehicleID    primitive.ObjectID `bson:"vehicle_id" json:"vehicle_id"`
-	CustomerID   primitive.ObjectID `bson:"customer_id" json:"customer_id"`
-	ValetID      primitive.ObjectID `bson:"valet_id" json:"valet_id"`
-	VenueName    string             `bson:"venue_name" json:"venue_name"`
-	ParkingSpot  string             `bson:"parking_spot,omitempty" json:"parking_spot,omitempty"` // e.g., "A-15", "Level 2 - Spot 34"
-	Status       SessionStatus      `bson:"status" json:"status"`
-	ParkedAt     time.Time          `bson:"parked_at" json:"parked_at"`
-	RequestedAt  *time.Time         `bson:"requested_at,omitempty" json:"requested_at,omitempty"`
-	DeliveredAt  *time.Time         `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
-	PickupOTP    string             `bson:"pickup_otp,omitempty" json:"pickup_otp,omitempty"`
-	OTPExpiresAt *time.Time         `bson:"otp_expires_at,omitempty" json:"otp_expires_at,omitempty"`
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	TicketNumber string              `bson:"ticket_number" json:"ticket_number"`
+	VehicleID    primitive.ObjectID  `bson:"vehicle_id" json:"vehicle_id"`
+	CustomerID   primitive.ObjectID  `bson:"customer_id" json:"customer_id"`
+	ValetID      primitive.ObjectID  `bson:"valet_id" json:"valet_id"`
+	VenueName    string              `bson:"venue_name" json:"venue_name"`
+	ParkingSpot  string              `bson:"parking_spot,omitempty" json:"parking_spot,omitempty"` // e.g., "A-15", "Level 2 - Spot 34"
+	Status       SessionStatus       `bson:"status" json:"status"`
+	ParkedAt     time.Time           `bson:"parked_at" json:"parked_at"`
+	RequestedAt  *time.Time          `bson:"requested_at,omitempty" json:"requested_at,omitempty"`
+	RequestedBy  *primitive.ObjectID `bson:"requested_by,omitempty" json:"requested_by,omitempty"` // Owner or shared driver who asked for the car
+	DeliveredAt  *time.Time          `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	DeliveredBy  *primitive.ObjectID `bson:"delivered_by,omitempty" json:"delivered_by,omitempty"` // Valet who handed the car back
+	RatedAt      *time.Time          `bson:"rated_at,omitempty" json:"rated_at,omitempty"`
+	PickupOTP    string              `bson:"pickup_otp,omitempty" json:"pickup_otp,omitempty"`
+	OTPExpiresAt *time.Time          `bson:"otp_expires_at,omitempty" json:"otp_expires_at,omitempty"`
+	OTPRollovers int                 `bson:"otp_rollovers,omitempty" json:"otp_rollovers,omitempty"` // Times the pickup OTP was reissued
+	KeyTag       *SessionKey         `bson:"key_tag,omitempty" json:"key_tag,omitempty"`
+	KeyCustody   []KeyCustodyEntry   `bson:"key_custody,omitempty" json:"key_custody,omitempty"`
+	Release      *ManualRelease      `bson:"manual_release,omitempty" json:"manual_release,omitempty"` // Set when delivered without the pickup OTP
+	Rejection    *Rejection          `bson:"rejection,omitempty" json:"rejection,omitempty"`
+	Version      int64               `bson:"version" json:"version"`    // Bumped by every update, for optimistic concurrency
+	Active       bool                `bson:"active,omitempty" json:"-"` // Set while open; backs the one-active-session-per-vehicle index
+}
+
+// Rejection records why a customer turned down a parking request
+type Rejection struct {
+	Reason     RejectionReason    `bson:"reason" json:"reason"`
+	Note       string             `bson:"note,omitempty" json:"note,omitempty"`
+	RejectedBy primitive.ObjectID `bson:"rejected_by" json:"rejected_by"`
+	RejectedAt time.Time          `bson:"rejected_at" json:"rejected_at"`
 }
 
+// IDDocument is the identity proof checked during a manual release. Only the
+// last digits of the document number are kept.
+type IDDocument struct {
+	Type         string `bson:"type" json:"type"`
+	NumberMasked string `bson:"number_masked" json:"number_masked"`
+	HolderName   string `bson:"holder_name" json:"holder_name"`
+}
+
+// ManualRelease records a manager-authorized delivery without the pickup OTP,
+// e.g. when the customer lost their ticket or their phone is dead
+type ManualRelease struct {
+	Reason       string              `bson:"reason" json:"reason"`
+	ApprovedBy   primitive.ObjectID  `bson:"approved_by" json:"approved_by"`
+	Registration string              `bson:"registration" json:"registration"` // Plate as stated by the claimant
+	IDDocument   IDDocument          `bson:"id_document" json:"id_document"`
+	FeeAmount    int64               `bson:"fee_amount" json:"fee_amount"`
+	FeeCurrency  string              `bson:"fee_currency,omitempty" json:"fee_currency,omitempty"`
+	FeeStatus    FeeStatus           `bson:"fee_status,omitempty" json:"fee_status,omitempty"` // Set only when a fee is charged
+	FeePaidAt    *time.Time          `bson:"fee_paid_at,omitempty" json:"fee_paid_at,omitempty"`
+	FeePaidTo    *primitive.ObjectID `bson:"fee_paid_to,omitempty" json:"fee_paid_to,omitempty"` // Manager who collected the fee
+	ReleasedAt   time.Time           `bson:"released_at" json:"released_at"`
+}
+
+// FeeStatus tracks whether a lost-ticket fee has been collected
+type FeeStatus string
+
+const (
+	FeeDue  FeeStatus = "due"
+	FeePaid FeeStatus = "paid"
+)
+
 // SessionWithDetails includes vehicle and user details for API responses
 type SessionWithDetails struct {
 	ParkingSession `bson:",inline"`
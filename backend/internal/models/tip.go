@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TipMethod string
+
+const (
+	TipCash   TipMethod = "cash"   // Handed to the valet, recorded by them
+	TipOnline TipMethod = "online" // Charged through the payment provider
+)
+
+type TipStatus string
+
+const (
+	TipRecorded TipStatus = "recorded" // Cash tip, already with the valet
+	TipPending  TipStatus = "pending"  // Online payment awaiting confirmation
+	TipCaptured TipStatus = "captured" // Online payment received, owed to valets
+	TipFailed   TipStatus = "failed"   // Online payment declined
+)
+
+type TipShareRole string
+
+const (
+	TipShareParking   TipShareRole = "parking"   // Valet who parked the car
+	TipShareRetrieval TipShareRole = "retrieval" // Valet who brought it back
+)
+
+// TipShare is one valet's portion of a tip
+type TipShare struct {
+	ValetID primitive.ObjectID `bson:"valet_id" json:"valet_id"`
+	Role    TipShareRole       `bson:"role" json:"role"`
+	Amount  int64              `bson:"amount" json:"amount"`
+}
+
+// Tip is money a customer gave for a delivered session, split between the valets
+type Tip struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SessionID  primitive.ObjectID `bson:"session_id" json:"session_id"`
+	VenueName  string             `bson:"venue_name" json:"venue_name"`
+	CustomerID primitive.ObjectID `bson:"customer_id" json:"customer_id"`
+	Amount     int64              `bson:"amount" json:"amount"` // In the smallest currency unit, e.g. paise
+	Currency   string             `bson:"currency" json:"currency"`
+	Method     TipMethod          `bson:"method" json:"method"`
+	Status     TipStatus          `bson:"status" json:"status"`
+	PaymentRef string             `bson:"payment_ref,omitempty" json:"payment_ref,omitempty"`
+	Shares     []TipShare         `bson:"shares" json:"shares"`
+	RecordedBy primitive.ObjectID `bson:"recorded_by" json:"recorded_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
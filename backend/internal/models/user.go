@@ -11,15 +11,17 @@ type Role string
 const (
 	RoleCustomer Role = "customer"
 	RoleValet    Role = "valet"
+	RoleManager  Role = "manager" // Venue manager; authorizes overrides such as manual releases
 )
 
 type User struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Phone     string             `bson:"phone" json:"phone"`
-	Name      string             `bson:"name" json:"name"`
-	Role      Role               `bson:"role" json:"role"`
-	VenueName string             `bson:"venue_name,omitempty" json:"venue_name,omitempty"` // Only for valets
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Phone             string             `bson:"phone" json:"phone"`
+	Name              string             `bson:"name" json:"name"`
+	Role              Role               `bson:"role" json:"role"`
+	VenueName         string             `bson:"venue_name,omitempty" json:"venue_name,omitempty"`                 // Only for valets and managers
+	PreferredLanguage string             `bson:"preferred_language,omitempty" json:"preferred_language,omitempty"` // e.g. "hi"; unset means negotiate per request
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
 }
 
 type OTPStore struct {
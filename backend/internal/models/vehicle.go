@@ -14,14 +14,76 @@ const (
 	VehicleTypeThreeWheel VehicleType = "three_wheeler"
 )
 
+type OwnershipStatus string
+
+const (
+	OwnershipUnverified OwnershipStatus = "unverified" // No proof submitted yet
+	OwnershipPending    OwnershipStatus = "pending"    // Registration certificate submitted, awaiting verification
+	OwnershipVerified   OwnershipStatus = "verified"   // A valet checked the registration certificate
+	OwnershipDisputed   OwnershipStatus = "disputed"   // Another customer registered the same plate
+	OwnershipRejected   OwnershipStatus = "rejected"   // Lost an ownership dispute
+)
+
+// Ownership records a customer's claim to a registration plate
+type Ownership struct {
+	Status         OwnershipStatus     `bson:"status" json:"status"`
+	DocumentNumber string              `bson:"document_number,omitempty" json:"-"`                       // Registration certificate number; never sent to clients
+	DocumentPhoto  string              `bson:"document_photo,omitempty" json:"document_photo,omitempty"` // Media ID
+	ClaimedAt      *time.Time          `bson:"claimed_at,omitempty" json:"claimed_at,omitempty"`
+	VerifiedAt     *time.Time          `bson:"verified_at,omitempty" json:"verified_at,omitempty"`
+	VerifiedBy     *primitive.ObjectID `bson:"verified_by,omitempty" json:"verified_by,omitempty"`
+	Note           string              `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+type DisputeStatus string
+
+const (
+	DisputeOpen     DisputeStatus = "open"
+	DisputeResolved DisputeStatus = "resolved"
+)
+
+// PlateDispute tracks a registration number claimed by more than one customer
+type PlateDispute struct {
+	ID                primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	NormalizedReg     string               `bson:"normalized_registration" json:"normalized_registration"`
+	VehicleIDs        []primitive.ObjectID `bson:"vehicle_ids" json:"vehicle_ids"`
+	Status            DisputeStatus        `bson:"status" json:"status"`
+	ResolvedVehicleID *primitive.ObjectID  `bson:"resolved_vehicle_id,omitempty" json:"resolved_vehicle_id,omitempty"`
+	ResolvedBy        *primitive.ObjectID  `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	ResolvedAt        *time.Time           `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	CreatedAt         time.Time            `bson:"created_at" json:"created_at"`
+}
+
+type SharePermission string
+
+const (
+	ShareAcceptParking SharePermission = "accept_parking" // Accept or reject a valet's parking request
+	ShareRequestPickup SharePermission = "request_pickup" // Request, cancel and get the OTP for pickup
+)
+
+// VehicleShare grants another customer, identified by phone, access to a vehicle.
+// Every share can see the vehicle's sessions; permissions add actions on top.
+type VehicleShare struct {
+	Phone       string            `bson:"phone" json:"phone"`
+	Name        string            `bson:"name,omitempty" json:"name,omitempty"`
+	Permissions []SharePermission `bson:"permissions" json:"permissions"`
+	InvitedAt   time.Time         `bson:"invited_at" json:"invited_at"`
+}
+
 type Vehicle struct {
 	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	OwnerID            primitive.ObjectID `bson:"owner_id" json:"owner_id"`
 	RegistrationNumber string             `bson:"registration_number" json:"registration_number"`
+	NormalizedReg      string             `bson:"normalized_registration" json:"normalized_registration"` // Upper case, no separators; used for matching
 	Make               string             `bson:"make" json:"make"`
 	Model              string             `bson:"model" json:"model"`
 	Color              string             `bson:"color" json:"color"`
 	VehicleType        VehicleType        `bson:"vehicle_type" json:"vehicle_type"`
 	Photos             []string           `bson:"photos,omitempty" json:"photos,omitempty"`
+	Ownership          Ownership          `bson:"ownership" json:"ownership"`
+	SharedWith         []VehicleShare     `bson:"shared_with,omitempty" json:"shared_with,omitempty"`
+	IsDefault          bool               `bson:"is_default" json:"is_default"`
 	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          *time.Time         `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	ArchivedAt         *time.Time         `bson:"archived_at,omitempty" json:"archived_at,omitempty"` // Set when the owner removes the vehicle
 }
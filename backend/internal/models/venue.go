@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OTPRolloverPolicy string
+
+const (
+	OTPRolloverAuto   OTPRolloverPolicy = "auto"   // A fresh OTP is issued when an expired one is presented
+	OTPRolloverManual OTPRolloverPolicy = "manual" // Customer must regenerate the OTP from the app
+)
+
+const (
+	DefaultPickupOTPTTL    = 30 * time.Minute
+	DefaultMaxOTPRollovers = 3
+	DefaultCurrency        = "INR"
+	DefaultLowRating       = 2
+	DefaultTipParkingShare = 50
+)
+
+// Venue holds per-venue operational settings. Venues are keyed by name, which
+// is what valets and sessions reference.
+type Venue struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name                string             `bson:"name" json:"name"`
+	PickupOTPTTLMinutes int                `bson:"pickup_otp_ttl_minutes" json:"pickup_otp_ttl_minutes"`
+	OTPRolloverPolicy   OTPRolloverPolicy  `bson:"otp_rollover_policy" json:"otp_rollover_policy"`
+	MaxOTPRollovers     int                `bson:"max_otp_rollovers" json:"max_otp_rollovers"`
+	Currency            string             `bson:"currency" json:"currency"`
+	LostTicketFee       int64              `bson:"lost_ticket_fee" json:"lost_ticket_fee"`           // In the smallest currency unit, e.g. paise
+	LowRatingThreshold  int                `bson:"low_rating_threshold" json:"low_rating_threshold"` // Ratings at or below this alert managers; 0 disables
+	TipParkingShare     int                `bson:"tip_parking_share" json:"tip_parking_share"`       // Percent of a tip for the parking valet; the retrieving valet gets the rest
+	RequireKeyTag       bool               `bson:"require_key_tag" json:"require_key_tag"`           // Cars cannot be marked parked until their keys are tagged
+	UpdatedAt           time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// DefaultVenue returns the settings used for a venue that has not been configured
+func DefaultVenue(name string) Venue {
+	return Venue{
+		Name:                name,
+		PickupOTPTTLMinutes: int(DefaultPickupOTPTTL / time.Minute),
+		OTPRolloverPolicy:   OTPRolloverAuto,
+		MaxOTPRollovers:     DefaultMaxOTPRollovers,
+		Currency:            DefaultCurrency,
+		LowRatingThreshold:  DefaultLowRating,
+		TipParkingShare:     DefaultTipParkingShare,
+		RequireKeyTag:       true,
+	}
+}
+
+// SplitTip divides a tip between the parking and retrieving valets according
+// to the venue's split rule. Rounding leftovers go to the retrieving valet.
+func (v Venue) SplitTip(amount int64, parkingValet, retrievingValet primitive.ObjectID) []TipShare {
+	if parkingValet == retrievingValet {
+		return []TipShare{{ValetID: retrievingValet, Role: TipShareRetrieval, Amount: amount}}
+	}
+
+	parking := amount * int64(v.TipParkingShare) / 100
+	var shares []TipShare
+	if parking > 0 {
+		shares = append(shares, TipShare{ValetID: parkingValet, Role: TipShareParking, Amount: parking})
+	}
+	if amount-parking > 0 {
+		shares = append(shares, TipShare{ValetID: retrievingValet, Role: TipShareRetrieval, Amount: amount - parking})
+	}
+	return shares
+}
+
+// PickupOTPTTL returns how long a pickup OTP stays valid at this venue
+func (v Venue) PickupOTPTTL() time.Duration {
+	if v.PickupOTPTTLMinutes <= 0 {
+		return DefaultPickupOTPTTL
+	}
+	return time.Duration(v.PickupOTPTTLMinutes) * time.Minute
+}
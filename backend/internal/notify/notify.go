@@ -0,0 +1,9 @@
+// Package notify sends text messages to users' phones through an SMS gateway.
+package notify
+
+import "context"
+
+// Sender delivers SMS. Implementations must be safe for concurrent use.
+type Sender interface {
+	SendSMS(ctx context.Context, phone, text string) error
+}
@@ -0,0 +1,80 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPProvider charges through a payment gateway that accepts a JSON POST of
+// the charge with a bearer token. The gateway answers with the provider
+// reference and a captured or pending status, or 402 when the card is
+// declined; pending charges are settled later through the payment webhook.
+type HTTPProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPProvider(url, token string) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type httpCharge struct {
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	CustomerID  string `json:"customer_id"`
+	Description string `json:"description"`
+	Reference   string `json:"reference"`
+}
+
+type httpResult struct {
+	ProviderRef string `json:"provider_ref"`
+	Status      Status `json:"status"`
+}
+
+func (p *HTTPProvider) Charge(ctx context.Context, charge Charge) (Result, error) {
+	body, err := json.Marshal(httpCharge(charge))
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPaymentRequired {
+		return Result{}, ErrDeclined
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Result{}, fmt.Errorf("payment gateway returned %s: %s", resp.Status, msg)
+	}
+
+	var parsed httpResult
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Result{}, fmt.Errorf("payment gateway response: %w", err)
+	}
+	if parsed.Status != StatusCaptured && parsed.Status != StatusPending {
+		return Result{}, fmt.Errorf("payment gateway returned status %q", parsed.Status)
+	}
+	return Result{ProviderRef: parsed.ProviderRef, Status: parsed.Status}, nil
+}
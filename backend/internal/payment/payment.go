@@ -0,0 +1,63 @@
+// Package payment charges customers through an external payment provider.
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var ErrDeclined = errors.New("payment declined")
+
+type Status string
+
+const (
+	StatusCaptured Status = "captured" // Money has been taken
+	StatusPending  Status = "pending"  // Provider will confirm asynchronously
+	StatusFailed   Status = "failed"   // Provider gave up on a pending charge
+)
+
+// Charge describes money to collect from a customer
+type Charge struct {
+	Amount      int64  // In the smallest currency unit, e.g. paise
+	Currency    string // ISO 4217
+	CustomerID  string
+	Description string
+	Reference   string // Our own ID for the charge, for reconciliation
+}
+
+// Result is the provider's answer to a charge
+type Result struct {
+	ProviderRef string
+	Status      Status
+}
+
+// Provider collects payments. Implementations must be safe for concurrent use.
+type Provider interface {
+	Charge(ctx context.Context, charge Charge) (Result, error)
+}
+
+// Confirmation is the provider's webhook settling a pending charge
+type Confirmation struct {
+	Reference   string `json:"reference"` // Charge.Reference
+	ProviderRef string `json:"provider_ref"`
+	Status      Status `json:"status"` // captured or failed
+}
+
+// Sign returns the hex HMAC-SHA256 of a webhook body under the shared secret
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a webhook signature produced by Sign. An empty secret
+// verifies nothing, so webhooks stay closed until one is configured.
+func Verify(secret []byte, body []byte, signature string) bool {
+	if len(secret) == 0 {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,25 @@
+package payment
+
+import (
+	"context"
+	"errors"
+)
+
+// StubProvider accepts every charge without moving money. Use it for
+// development and tests where no payment provider is configured. Charges are
+// captured immediately unless Status says otherwise, e.g. pending to exercise
+// webhook confirmation.
+type StubProvider struct {
+	Status Status
+}
+
+func (s StubProvider) Charge(ctx context.Context, charge Charge) (Result, error) {
+	if charge.Amount <= 0 {
+		return Result{}, errors.New("payment: amount must be positive")
+	}
+	status := s.Status
+	if status == "" {
+		status = StatusCaptured
+	}
+	return Result{ProviderRef: "stub_" + charge.Reference, Status: status}, nil
+}
@@ -0,0 +1,65 @@
+// Package phone normalizes phone numbers to E.164, so a number compares
+// equal however it was typed.
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalid = errors.New("phone number is not valid")
+
+// nationalPrefix is the country calling code assumed for numbers typed
+// without one; the service runs in India
+const nationalPrefix = "91"
+
+// Normalize returns the E.164 form of a number, e.g. "+919812345678".
+// Spaces and the separators '-', '.', '(' and ')' are ignored, a leading 00
+// is an international prefix, and ten-digit national numbers, with or without
+// a trunk 0, get the Indian country code.
+func Normalize(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	international := strings.HasPrefix(s, "+")
+	if international {
+		s = s[1:]
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalid
+		}
+	}
+	digits := b.String()
+
+	switch {
+	case international:
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case len(digits) == 11 && digits[0] == '0':
+		digits = nationalPrefix + digits[1:]
+	case len(digits) == 10:
+		digits = nationalPrefix + digits
+	}
+
+	// E.164 allows at most 15 digits, and no country code starts with 0
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalid
+	}
+	return "+" + digits, nil
+}
+
+// Equal reports whether two numbers normalize to the same E.164 form. Numbers
+// that do not normalize only equal themselves.
+func Equal(a, b string) bool {
+	if a == b {
+		return true
+	}
+	na, errA := Normalize(a)
+	nb, errB := Normalize(b)
+	return errA == nil && errB == nil && na == nb
+}
@@ -0,0 +1,45 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"+919812345678", "+919812345678"},
+		{"+91 98123 45678", "+919812345678"},
+		{"+91-98123-45678", "+919812345678"},
+		{"9812345678", "+919812345678"},
+		{"09812345678", "+919812345678"},
+		{"(098) 1234.5678", "+919812345678"},
+		{"00919812345678", "+919812345678"},
+		{"+14155552671", "+14155552671"},
+		{"  +44 20 7946 0958 ", "+442079460958"},
+	}
+	for _, tc := range tests {
+		got, err := Normalize(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	for _, in := range []string{"", "+", "12345", "+0919812345678", "98123x45678", "+1234567890123456", "phone"} {
+		if got, err := Normalize(in); err != ErrInvalid {
+			t.Errorf("Normalize(%q) = %q, %v; want ErrInvalid", in, got, err)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !Equal("98123 45678", "+919812345678") {
+		t.Error("national and E.164 spellings should be equal")
+	}
+	if Equal("9812345678", "9812345679") {
+		t.Error("different numbers should not be equal")
+	}
+	if !Equal("n/a", "n/a") || Equal("n/a", "N/A") {
+		t.Error("invalid numbers should only equal themselves")
+	}
+}
@@ -0,0 +1,115 @@
+// Package plate normalizes and validates vehicle registration numbers.
+package plate
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrEmpty         = errors.New("registration number is required")
+	ErrInvalidFormat = errors.New("registration number format is not valid")
+	ErrUnknownRegion = errors.New("registration number has an unknown state code")
+)
+
+// Validator checks and formats normalized plates for one country
+type Validator interface {
+	// Validate returns nil if the normalized plate is a valid registration
+	Validate(normalized string) error
+	// Format returns the conventional display form of a valid normalized plate
+	Format(normalized string) string
+}
+
+var validators = map[string]Validator{
+	"IN": indiaValidator{},
+}
+
+// Normalize canonicalizes a registration number for storage and matching:
+// upper case with whitespace and separators such as '-', '.' and '/' removed.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Validate checks a normalized plate against the rules for country. Countries
+// without a dedicated validator only get a basic sanity check.
+func Validate(country, normalized string) error {
+	if normalized == "" {
+		return ErrEmpty
+	}
+	if v, ok := validators[strings.ToUpper(country)]; ok {
+		return v.Validate(normalized)
+	}
+	if len(normalized) < 2 || len(normalized) > 12 {
+		return ErrInvalidFormat
+	}
+	return nil
+}
+
+// Format returns the display form of a normalized plate
+func Format(country, normalized string) string {
+	if v, ok := validators[strings.ToUpper(country)]; ok && v.Validate(normalized) == nil {
+		return v.Format(normalized)
+	}
+	return normalized
+}
+
+// indiaValidator covers the standard state series (MH 12 AB 1234) and the
+// Bharat series (22 BH 1234 AB)
+type indiaValidator struct{}
+
+var (
+	indiaStandard = regexp.MustCompile(`^([A-Z]{2})(\d{1,2})([A-Z]{0,3})(\d{1,4})$`)
+	indiaBharat   = regexp.MustCompile(`^(\d{2})(BH)(\d{4})([A-Z]{1,2})$`)
+)
+
+// State and union territory codes, including retired ones still on the road
+var indiaStateCodes = map[string]bool{
+	"AN": true, "AP": true, "AR": true, "AS": true, "BR": true, "CG": true,
+	"CH": true, "DD": true, "DL": true, "DN": true, "GA": true, "GJ": true,
+	"HP": true, "HR": true, "JH": true, "JK": true, "KA": true, "KL": true,
+	"LA": true, "LD": true, "MH": true, "ML": true, "MN": true, "MP": true,
+	"MZ": true, "NL": true, "OD": true, "OR": true, "PB": true, "PY": true,
+	"RJ": true, "SK": true, "TG": true, "TN": true, "TR": true, "TS": true,
+	"UA": true, "UK": true, "UP": true, "WB": true,
+}
+
+func (indiaValidator) Validate(normalized string) error {
+	if indiaBharat.MatchString(normalized) {
+		return nil
+	}
+
+	m := indiaStandard.FindStringSubmatch(normalized)
+	if m == nil {
+		return ErrInvalidFormat
+	}
+	if !indiaStateCodes[m[1]] {
+		return ErrUnknownRegion
+	}
+	return nil
+}
+
+func (indiaValidator) Format(normalized string) string {
+	m := indiaBharat.FindStringSubmatch(normalized)
+	if m == nil {
+		m = indiaStandard.FindStringSubmatch(normalized)
+	}
+	if m == nil {
+		return normalized
+	}
+
+	var parts []string
+	for _, p := range m[1:] {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
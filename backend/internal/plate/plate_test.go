@@ -0,0 +1,95 @@
+package plate
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"MH12AB1234", "MH12AB1234"},
+		{"mh12ab1234", "MH12AB1234"},
+		{"MH 12 AB 1234", "MH12AB1234"},
+		{"  mh-12-ab-1234 ", "MH12AB1234"},
+		{"KA.01/MJ.42", "KA01MJ42"},
+		{"22 bh 1234 aa", "22BH1234AA"},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := Normalize(tc.in); got != tc.want {
+			t.Errorf("Normalize(%q) = %q; want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestValidateIndia(t *testing.T) {
+	tests := []struct {
+		in      string
+		display string
+	}{
+		// Standard state series
+		{"MH12AB1234", "MH 12 AB 1234"},
+		{"mh 12 ab 1234", "MH 12 AB 1234"},
+		{"DL3CAF0001", "DL 3 CAF 0001"},
+		{"KA01M5", "KA 01 M 5"},
+		{"TN091234", "TN 09 1234"},
+		{"ka-05-mj-42", "KA 05 MJ 42"},
+		// Bharat series
+		{"22BH1234AA", "22 BH 1234 AA"},
+		{"21 bh 0001 c", "21 BH 0001 C"},
+	}
+	for _, tc := range tests {
+		normalized := Normalize(tc.in)
+		if err := Validate("IN", normalized); err != nil {
+			t.Errorf("Validate(%q) = %v; want nil", tc.in, err)
+			continue
+		}
+		if got := Format("in", normalized); got != tc.display {
+			t.Errorf("Format(%q) = %q; want %q", tc.in, got, tc.display)
+		}
+	}
+}
+
+func TestValidateIndiaRejects(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error
+	}{
+		{"", ErrEmpty},
+		{"  - ", ErrEmpty},
+		{"XX12AB1234", ErrUnknownRegion},
+		{"ZZ 01 A 1", ErrUnknownRegion},
+		{"MH12AB12345", ErrInvalidFormat},
+		{"MH123AB1234", ErrInvalidFormat},
+		{"MH12ABCD1234", ErrInvalidFormat},
+		{"MHAB1234", ErrInvalidFormat},
+		{"MH12AB", ErrInvalidFormat},
+		{"1234", ErrInvalidFormat},
+		{"22BH123AA", ErrInvalidFormat},
+		{"22BH1234ABC", ErrInvalidFormat},
+		{"2BH1234AA", ErrInvalidFormat},
+		{"22BH1234", ErrInvalidFormat},
+	}
+	for _, tc := range tests {
+		normalized := Normalize(tc.in)
+		if err := Validate("IN", normalized); err != tc.want {
+			t.Errorf("Validate(%q) = %v; want %v", tc.in, err, tc.want)
+		}
+		if got := Format("IN", normalized); got != normalized {
+			t.Errorf("Format(%q) = %q; want it unchanged", tc.in, got)
+		}
+	}
+}
+
+func TestValidateOtherCountries(t *testing.T) {
+	if err := Validate("GB", Normalize("AB12 CDE")); err != nil {
+		t.Errorf("Validate(GB) = %v; want nil", err)
+	}
+	if got := Format("GB", "AB12CDE"); got != "AB12CDE" {
+		t.Errorf("Format(GB) = %q; want it unchanged", got)
+	}
+	for _, in := range []string{"A", "ABCDEFGHIJKLM"} {
+		if err := Validate("GB", in); err != ErrInvalidFormat {
+			t.Errorf("Validate(GB, %q) = %v; want ErrInvalidFormat", in, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound           = errors.New("blob not found")
+	ErrPresignUnsupported = errors.New("presigned URLs not supported by this store")
+)
+
+// BlobStore stores opaque binary objects under string keys
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// PresignGet returns a URL that allows reading the object directly from the
+	// store until expiry. Stores that cannot do this return ErrPresignUnsupported.
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RatingFilter selects ratings. Zero-valued fields are ignored.
+type RatingFilter struct {
+	SessionID primitive.ObjectID
+	VenueName string
+	ValetID   primitive.ObjectID // Among the rated valets
+	CreatedAt *TimeRange
+}
+
+// ValetRatingStats summarises the ratings a valet received
+type ValetRatingStats struct {
+	ValetID primitive.ObjectID
+	Average float64
+	Count   int
+	Low     int // Ratings at or below the low-rating threshold
+}
+
+type RatingRepository interface {
+	Insert(ctx context.Context, rating models.Rating) error
+	FindOne(ctx context.Context, filter RatingFilter) (models.Rating, error)
+	Find(ctx context.Context, filter RatingFilter) ([]models.Rating, error)
+	// ScoreCounts counts a venue's ratings per score
+	ScoreCounts(ctx context.Context, venueName string, created TimeRange) (map[int]int, error)
+	// ValetScores summarises a venue's ratings per valet, best average first
+	ValetScores(ctx context.Context, venueName string, created TimeRange, lowThreshold int) ([]ValetRatingStats, error)
+}
+
+// ValetTipTotals sums a valet's shares of recorded and captured tips
+type ValetTipTotals struct {
+	ValetID primitive.ObjectID
+	Tips    int
+	Cash    int64
+	Online  int64
+}
+
+type TipRepository interface {
+	// Insert fails with ErrDuplicate if the session already has a cash tip
+	Insert(ctx context.Context, tip models.Tip) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Tip, error)
+	// ListBySession returns a session's tips, oldest first
+	ListBySession(ctx context.Context, sessionID primitive.ObjectID) ([]models.Tip, error)
+	// SetStatus records the outcome of a tip payment; an empty paymentRef is left alone
+	SetStatus(ctx context.Context, id primitive.ObjectID, status models.TipStatus, paymentRef string) error
+	// Settle moves a pending tip to captured or failed once the provider
+	// confirms it. It returns ErrNotFound unless the tip is still pending.
+	Settle(ctx context.Context, id primitive.ObjectID, status models.TipStatus, paymentRef string) error
+	// ValetTotals sums tip shares per valet at a venue, highest online total
+	// first. A non-zero valetID limits the result to that valet's shares.
+	ValetTotals(ctx context.Context, venueName string, created TimeRange, valetID primitive.ObjectID) ([]ValetTipTotals, error)
+}
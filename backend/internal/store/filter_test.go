@@ -0,0 +1,493 @@
+package store
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The memory store answers queries with the matches methods, the MongoDB
+// store with the bson methods. These tests run both over the same stored
+// documents, field by field, and check they agree. Documents are edited as
+// BSON so that data written before a field existed can be tested too. The
+// memory store's transaction rollback is covered in transaction_test.go.
+
+type sessionFilterCase struct {
+	name   string
+	filter SessionFilter
+	edit   func(doc bson.M) // Changes the stored document before matching
+	want   bool
+}
+
+func TestSessionFilterParity(t *testing.T) {
+	parked := time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC)
+	delivered := parked.Add(3 * time.Hour)
+	tagID, deliveredBy := primitive.NewObjectID(), primitive.NewObjectID()
+	session := models.ParkingSession{
+		ID:           primitive.NewObjectID(),
+		TicketNumber: "T-1001",
+		VehicleID:    primitive.NewObjectID(),
+		CustomerID:   primitive.NewObjectID(),
+		ValetID:      primitive.NewObjectID(),
+		VenueName:    "Grand Hotel",
+		Status:       models.StatusAvailable,
+		ParkedAt:     parked,
+		DeliveredAt:  &delivered,
+		DeliveredBy:  &deliveredBy,
+		PickupOTP:    "123456",
+		KeyTag:       &models.SessionKey{TagID: tagID, Number: "17", AssignedAt: parked},
+		Version:      3,
+		Active:       true,
+	}
+	other := primitive.NewObjectID()
+	version := func(v int64) *int64 { return &v }
+	unset := func(fields ...string) func(bson.M) {
+		return func(doc bson.M) {
+			for _, f := range fields {
+				delete(doc, f)
+			}
+		}
+	}
+
+	cases := []sessionFilterCase{
+		{name: "empty", filter: SessionFilter{}, want: true},
+		{name: "id", filter: SessionFilter{ID: session.ID}, want: true},
+		{name: "other id", filter: SessionFilter{ID: other}, want: false},
+		{name: "ids", filter: SessionFilter{IDs: []primitive.ObjectID{other, session.ID}}, want: true},
+		{name: "ids without it", filter: SessionFilter{IDs: []primitive.ObjectID{other}}, want: false},
+		{name: "empty ids", filter: SessionFilter{IDs: []primitive.ObjectID{}}, want: false},
+		{name: "vehicle", filter: SessionFilter{VehicleID: session.VehicleID}, want: true},
+		{name: "other vehicle", filter: SessionFilter{VehicleID: other}, want: false},
+		{name: "customer", filter: SessionFilter{CustomerID: session.CustomerID}, want: true},
+		{name: "other customer", filter: SessionFilter{CustomerID: other}, want: false},
+		{name: "valet", filter: SessionFilter{ValetID: session.ValetID}, want: true},
+		{name: "other valet", filter: SessionFilter{ValetID: other}, want: false},
+		{name: "delivered by", filter: SessionFilter{DeliveredBy: deliveredBy}, want: true},
+		{name: "delivered by other", filter: SessionFilter{DeliveredBy: other}, want: false},
+		{name: "delivered by, not delivered", filter: SessionFilter{DeliveredBy: deliveredBy}, edit: unset("delivered_by"), want: false},
+		{name: "venue", filter: SessionFilter{VenueName: "Grand Hotel"}, want: true},
+		{name: "other venue", filter: SessionFilter{VenueName: "Sea View"}, want: false},
+		{name: "ticket", filter: SessionFilter{TicketNumber: "T-1001"}, want: true},
+		{name: "other ticket", filter: SessionFilter{TicketNumber: "T-1002"}, want: false},
+		{name: "statuses", filter: SessionFilter{Statuses: []models.SessionStatus{models.StatusParked, models.StatusAvailable}}, want: true},
+		{name: "other statuses", filter: SessionFilter{Statuses: []models.SessionStatus{models.StatusParked}}, want: false},
+		{name: "empty statuses", filter: SessionFilter{Statuses: []models.SessionStatus{}}, want: false},
+		{name: "not statuses", filter: SessionFilter{NotStatuses: models.ClosedStatuses}, want: true},
+		{name: "excluded status", filter: SessionFilter{NotStatuses: []models.SessionStatus{models.StatusAvailable}}, want: false},
+		{name: "access as customer", filter: SessionFilter{Access: &CustomerAccess{CustomerID: session.CustomerID}}, want: true},
+		{name: "access as stranger", filter: SessionFilter{Access: &CustomerAccess{CustomerID: other}}, want: false},
+		{name: "access through a share", filter: SessionFilter{Access: &CustomerAccess{CustomerID: other, VehicleIDs: []primitive.ObjectID{session.VehicleID}}}, want: true},
+		{name: "access through another share", filter: SessionFilter{Access: &CustomerAccess{CustomerID: other, VehicleIDs: []primitive.ObjectID{other}}}, want: false},
+		{name: "pickup otp", filter: SessionFilter{PickupOTP: "123456"}, want: true},
+		{name: "wrong pickup otp", filter: SessionFilter{PickupOTP: "654321"}, want: false},
+		{name: "pickup otp cleared", filter: SessionFilter{PickupOTP: "123456"}, edit: unset("pickup_otp"), want: false},
+		{name: "parked in range", filter: SessionFilter{ParkedAt: &TimeRange{From: parked, To: parked.Add(time.Hour)}}, want: true},
+		{name: "parked at range end", filter: SessionFilter{ParkedAt: &TimeRange{From: parked.Add(-time.Hour), To: parked}}, want: false},
+		{name: "parked since", filter: SessionFilter{ParkedAt: &TimeRange{From: parked.Add(-time.Hour)}}, want: true},
+		{name: "parked before range", filter: SessionFilter{ParkedAt: &TimeRange{From: parked.Add(time.Minute)}}, want: false},
+		{name: "delivered in range", filter: SessionFilter{DeliveredAt: &TimeRange{From: parked, To: delivered.Add(time.Minute)}}, want: true},
+		{name: "delivered out of range", filter: SessionFilter{DeliveredAt: &TimeRange{From: parked, To: delivered}}, want: false},
+		{name: "delivered range, not delivered", filter: SessionFilter{DeliveredAt: &TimeRange{From: parked}}, edit: unset("delivered_at"), want: false},
+		{name: "no key tag", filter: SessionFilter{NoKeyTag: true}, want: false},
+		{name: "no key tag, none assigned", filter: SessionFilter{NoKeyTag: true}, edit: unset("key_tag"), want: true},
+		{name: "keys out", filter: SessionFilter{KeyOut: tagID}, want: true},
+		{name: "keys out on another tag", filter: SessionFilter{KeyOut: other}, want: false},
+		{name: "keys out, returned", filter: SessionFilter{KeyOut: tagID}, edit: func(doc bson.M) {
+			doc["key_tag"].(bson.M)["returned_at"] = primitive.NewDateTimeFromTime(delivered)
+		}, want: false},
+		{name: "keys out, no tag", filter: SessionFilter{KeyOut: tagID}, edit: unset("key_tag"), want: false},
+		{name: "not rated", filter: SessionFilter{NotRated: true}, want: true},
+		{name: "not rated, rated", filter: SessionFilter{NotRated: true}, edit: func(doc bson.M) {
+			doc["rated_at"] = primitive.NewDateTimeFromTime(delivered)
+		}, want: false},
+		{name: "version", filter: SessionFilter{Version: version(3)}, want: true},
+		{name: "stale version", filter: SessionFilter{Version: version(2)}, want: false},
+		// Sessions stored before versioning have no version field and are read
+		// as version 0, which is the version handlers then ask to update
+		{name: "version 0, legacy document", filter: SessionFilter{Version: version(0)}, edit: unset("version"), want: true},
+		{name: "version 1, legacy document", filter: SessionFilter{Version: version(1)}, edit: unset("version"), want: false},
+		{name: "versions", filter: SessionFilter{Versions: []int64{2, 3}}, want: true},
+		{name: "stale versions", filter: SessionFilter{Versions: []int64{1, 2}}, want: false},
+		{name: "no versions", filter: SessionFilter{Versions: []int64{}}, want: false},
+		{name: "versions with 0, legacy document", filter: SessionFilter{Versions: []int64{0, 4}}, edit: unset("version"), want: true},
+		{name: "version among versions", filter: SessionFilter{Version: version(3), Versions: []int64{3, 4}}, want: true},
+		{name: "version not among versions", filter: SessionFilter{Version: version(3), Versions: []int64{4}}, want: false},
+		{name: "after an older cursor", filter: SessionFilter{After: &SessionCursor{ParkedAt: parked.Add(time.Hour), ID: other}}, want: true},
+		{name: "after a newer cursor", filter: SessionFilter{After: &SessionCursor{ParkedAt: parked.Add(-time.Hour), ID: other}}, want: false},
+		{name: "after a cursor at the same time", filter: SessionFilter{After: &SessionCursor{ParkedAt: parked, ID: maxObjectID}}, want: true},
+		{name: "after itself", filter: SessionFilter{After: &SessionCursor{ParkedAt: parked, ID: session.ID}}, want: false},
+		{name: "access and cursor", filter: SessionFilter{
+			Access: &CustomerAccess{CustomerID: other, VehicleIDs: []primitive.ObjectID{session.VehicleID}},
+			After:  &SessionCursor{ParkedAt: parked.Add(time.Hour), ID: other},
+		}, want: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := toDocument(t, session)
+			if tc.edit != nil {
+				tc.edit(doc)
+			}
+			var stored models.ParkingSession
+			fromDocument(t, doc, &stored)
+
+			memory := tc.filter.matches(&stored)
+			mongo := mql(t, toDocument(t, tc.filter.bson()), doc)
+			if memory != mongo {
+				t.Fatalf("memory store matches %v, MongoDB query %v: %v", memory, mongo, tc.filter.bson())
+			}
+			if memory != tc.want {
+				t.Fatalf("matched %v, want %v", memory, tc.want)
+			}
+		})
+	}
+}
+
+type vehicleFilterCase struct {
+	name   string
+	filter VehicleFilter
+	edit   func(doc bson.M)
+	want   bool
+}
+
+func TestVehicleFilterParity(t *testing.T) {
+	vehicle := models.Vehicle{
+		ID:                 primitive.NewObjectID(),
+		OwnerID:            primitive.NewObjectID(),
+		RegistrationNumber: "MH 12 AB 1234",
+		NormalizedReg:      "MH12AB1234",
+		Make:               "Maruti",
+		Model:              "Swift",
+		Color:              "Pearl White",
+		VehicleType:        models.VehicleTypeCar,
+		Photos:             []string{"photo-1", "photo-2"},
+		Ownership:          models.Ownership{Status: models.OwnershipPending},
+		SharedWith: []models.VehicleShare{
+			{Phone: "+919800000001", Permissions: []models.SharePermission{models.ShareAcceptParking}},
+			{Phone: "+919800000002", Permissions: []models.SharePermission{models.ShareRequestPickup}},
+		},
+		CreatedAt: time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC),
+	}
+	other := primitive.NewObjectID()
+	set := func(field string, value any) func(bson.M) {
+		return func(doc bson.M) { doc[field] = value }
+	}
+	ownership := func(status models.OwnershipStatus) func(bson.M) {
+		return func(doc bson.M) { doc["ownership"] = bson.M{"status": string(status)} }
+	}
+	archived := set("archived_at", primitive.NewDateTimeFromTime(vehicle.CreatedAt))
+
+	cases := []vehicleFilterCase{
+		{name: "empty", filter: VehicleFilter{}, want: true},
+		{name: "id", filter: VehicleFilter{ID: vehicle.ID}, want: true},
+		{name: "other id", filter: VehicleFilter{ID: other}, want: false},
+		{name: "ids", filter: VehicleFilter{IDs: []primitive.ObjectID{vehicle.ID}}, want: true},
+		{name: "ids without it", filter: VehicleFilter{IDs: []primitive.ObjectID{other}}, want: false},
+		{name: "empty ids", filter: VehicleFilter{IDs: []primitive.ObjectID{}}, want: false},
+		{name: "not id", filter: VehicleFilter{NotID: other}, want: true},
+		{name: "not its id", filter: VehicleFilter{NotID: vehicle.ID}, want: false},
+		{name: "id and ids", filter: VehicleFilter{ID: vehicle.ID, IDs: []primitive.ObjectID{other}}, want: false},
+		{name: "owner", filter: VehicleFilter{OwnerID: vehicle.OwnerID}, want: true},
+		{name: "other owner", filter: VehicleFilter{OwnerID: other}, want: false},
+		{name: "not owner", filter: VehicleFilter{NotOwnerID: vehicle.OwnerID}, want: false},
+		{name: "owners", filter: VehicleFilter{OwnerIDs: []primitive.ObjectID{other, vehicle.OwnerID}}, want: true},
+		{name: "empty owners", filter: VehicleFilter{OwnerIDs: []primitive.ObjectID{}}, want: false},
+		{name: "plate", filter: VehicleFilter{NormalizedReg: "MH12AB1234"}, want: true},
+		{name: "other plate", filter: VehicleFilter{NormalizedReg: "MH12AB123"}, want: false},
+		{name: "plate wins over substring", filter: VehicleFilter{NormalizedReg: "MH12AB1234", RegContains: "ZZ"}, want: true},
+		{name: "plate substring", filter: VehicleFilter{RegContains: "AB12"}, want: true},
+		{name: "plate substring missing", filter: VehicleFilter{RegContains: "AB99"}, want: false},
+		{name: "plate substring is literal", filter: VehicleFilter{RegContains: "MH.2"}, want: false},
+		{name: "make ignores case", filter: VehicleFilter{Make: "maru"}, want: true},
+		{name: "other make", filter: VehicleFilter{Make: "Tata"}, want: false},
+		{name: "model", filter: VehicleFilter{Model: "SWIFT"}, want: true},
+		{name: "other model", filter: VehicleFilter{Model: "Dzire"}, want: false},
+		{name: "colour", filter: VehicleFilter{Color: "white"}, want: true},
+		{name: "other colour", filter: VehicleFilter{Color: "red"}, want: false},
+		{name: "photo", filter: VehicleFilter{Photo: "photo-2"}, want: true},
+		{name: "other photo", filter: VehicleFilter{Photo: "photo-3"}, want: false},
+		{name: "shared", filter: VehicleFilter{SharedWith: "+919800000002"}, want: true},
+		{name: "not shared", filter: VehicleFilter{SharedWith: "+919800000003"}, want: false},
+		{name: "shared with permission", filter: VehicleFilter{SharedWith: "+919800000002", SharePermission: models.ShareRequestPickup}, want: true},
+		// The permission must be on the same share as the phone
+		{name: "permission on another share", filter: VehicleFilter{SharedWith: "+919800000001", SharePermission: models.ShareRequestPickup}, want: false},
+		{name: "shared, no shares", filter: VehicleFilter{SharedWith: "+919800000001"}, edit: func(doc bson.M) { delete(doc, "shared_with") }, want: false},
+		{name: "active", filter: VehicleFilter{Active: true}, want: true},
+		{name: "active, archived", filter: VehicleFilter{Active: true}, edit: archived, want: false},
+		{name: "unresolved", filter: VehicleFilter{Unresolved: true}, want: true},
+		{name: "unresolved, verified", filter: VehicleFilter{Unresolved: true}, edit: ownership(models.OwnershipVerified), want: false},
+		{name: "unresolved, rejected", filter: VehicleFilter{Unresolved: true}, edit: ownership(models.OwnershipRejected), want: false},
+		{name: "not rejected", filter: VehicleFilter{NotRejected: true}, edit: ownership(models.OwnershipVerified), want: true},
+		{name: "not rejected, rejected", filter: VehicleFilter{NotRejected: true}, edit: ownership(models.OwnershipRejected), want: false},
+		{name: "unresolved and not rejected, rejected", filter: VehicleFilter{Unresolved: true, NotRejected: true}, edit: ownership(models.OwnershipRejected), want: false},
+		// Vehicles stored before ownership claims existed have no ownership
+		{name: "unresolved, legacy document", filter: VehicleFilter{Unresolved: true}, edit: func(doc bson.M) { delete(doc, "ownership") }, want: true},
+		{name: "not rejected, legacy document", filter: VehicleFilter{NotRejected: true}, edit: func(doc bson.M) { delete(doc, "ownership") }, want: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := toDocument(t, vehicle)
+			if tc.edit != nil {
+				tc.edit(doc)
+			}
+			var stored models.Vehicle
+			fromDocument(t, doc, &stored)
+
+			memory := tc.filter.matches(&stored)
+			mongo := mql(t, toDocument(t, tc.filter.bson()), doc)
+			if memory != mongo {
+				t.Fatalf("memory store matches %v, MongoDB query %v: %v", memory, mongo, tc.filter.bson())
+			}
+			if memory != tc.want {
+				t.Fatalf("matched %v, want %v", memory, tc.want)
+			}
+		})
+	}
+}
+
+// maxObjectID sorts after every generated ID
+var maxObjectID = primitive.ObjectID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// toDocument converts v to the document MongoDB would store for it
+func toDocument(t *testing.T, v any) bson.M {
+	t.Helper()
+	data, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatalf("encode %T: %v", v, err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode %T: %v", v, err)
+	}
+	return normalize(doc).(bson.M)
+}
+
+// fromDocument decodes a stored document into v
+func fromDocument(t *testing.T, doc bson.M, v any) {
+	t.Helper()
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("encode document: %v", err)
+	}
+	if err := bson.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode %T: %v", v, err)
+	}
+}
+
+// normalize turns every embedded document into a bson.M
+func normalize(v any) any {
+	switch v := v.(type) {
+	case bson.D:
+		m := bson.M{}
+		for _, e := range v {
+			m[e.Key] = normalize(e.Value)
+		}
+		return m
+	case bson.M:
+		m := bson.M{}
+		for k, e := range v {
+			m[k] = normalize(e)
+		}
+		return m
+	case bson.A:
+		a := make(bson.A, len(v))
+		for i, e := range v {
+			a[i] = normalize(e)
+		}
+		return a
+	}
+	return v
+}
+
+// mql evaluates a MongoDB query filter against a document. It covers the
+// operators the store's filters use and fails the test on any other.
+func mql(t *testing.T, filter, doc bson.M) bool {
+	t.Helper()
+	for key, cond := range filter {
+		switch key {
+		case "$and", "$or":
+			any, all := false, true
+			for _, clause := range cond.(bson.A) {
+				ok := mql(t, clause.(bson.M), doc)
+				any, all = any || ok, all && ok
+			}
+			if (key == "$and" && !all) || (key == "$or" && !any) {
+				return false
+			}
+		default:
+			if !fieldMatches(t, lookup(doc, strings.Split(key, ".")), cond) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lookup returns the values at a dotted path. Arrays are searched element by
+// element, and an array value is returned along with its elements.
+func lookup(value any, path []string) []any {
+	if len(path) == 0 {
+		if array, ok := value.(bson.A); ok {
+			return append([]any{value}, array...)
+		}
+		return []any{value}
+	}
+	switch v := value.(type) {
+	case bson.M:
+		next, ok := v[path[0]]
+		if !ok {
+			return nil
+		}
+		return lookup(next, path[1:])
+	case bson.A:
+		var values []any
+		for _, e := range v {
+			if _, ok := e.(bson.M); ok {
+				values = append(values, lookup(e, path)...)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func fieldMatches(t *testing.T, values []any, cond any) bool {
+	t.Helper()
+	ops, ok := cond.(bson.M)
+	if !ok || !isOperators(ops) {
+		return equalsAny(values, cond)
+	}
+
+	for op, arg := range ops {
+		var ok bool
+		switch op {
+		case "$eq":
+			ok = equalsAny(values, arg)
+		case "$ne":
+			ok = !equalsAny(values, arg)
+		case "$in", "$nin":
+			for _, want := range arg.(bson.A) {
+				ok = ok || equalsAny(values, want)
+			}
+			if op == "$nin" {
+				ok = !ok
+			}
+		case "$exists":
+			ok = (len(values) > 0) == arg.(bool)
+		case "$lt", "$lte", "$gt", "$gte":
+			for _, v := range values {
+				c, comparable := compare(v, arg)
+				if comparable && ((op == "$lt" && c < 0) || (op == "$lte" && c <= 0) || (op == "$gt" && c > 0) || (op == "$gte" && c >= 0)) {
+					ok = true
+				}
+			}
+		case "$regex":
+			pattern := arg.(string)
+			if options, _ := ops["$options"].(string); strings.Contains(options, "i") {
+				pattern = "(?i)" + pattern
+			}
+			re := regexp.MustCompile(pattern)
+			for _, v := range values {
+				if s, isString := v.(string); isString && re.MatchString(s) {
+					ok = true
+				}
+			}
+		case "$options":
+			ok = true
+		case "$elemMatch":
+			for _, v := range values {
+				if element, isDoc := v.(bson.M); isDoc && mql(t, arg.(bson.M), element) {
+					ok = true
+				}
+			}
+		default:
+			t.Fatalf("mql: unsupported operator %s", op)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isOperators(m bson.M) bool {
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return len(m) > 0
+}
+
+// equalsAny reports whether any value equals want. A null want also matches
+// a missing field, as in MongoDB.
+func equalsAny(values []any, want any) bool {
+	if want == nil && len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if c, ok := compare(v, want); ok && c == 0 {
+			return true
+		}
+		if reflect.DeepEqual(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare orders two BSON values of the same kind
+func compare(a, b any) (int, bool) {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+		return 0, false
+	}
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case primitive.ObjectID:
+		if y, ok := b.(primitive.ObjectID); ok {
+			return bytes.Compare(x[:], y[:]), true
+		}
+	case primitive.DateTime:
+		if y, ok := b.(primitive.DateTime); ok {
+			return compare(int64(x), int64(y))
+		}
+	case bool:
+		if y, ok := b.(bool); ok && x == y {
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
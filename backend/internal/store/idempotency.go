@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IdempotencyRepository interface {
+	// Claim stores record unless the user already has an unexpired record for
+	// the same key, in which case it returns that one and false. A record
+	// still in progress past its LockedUntil is taken over, since the request
+	// that claimed it died without completing or releasing it.
+	Claim(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error)
+	// Complete saves the response to a claimed request
+	Complete(ctx context.Context, id primitive.ObjectID, status int, header map[string][]string, body []byte) error
+	// Release drops a claim so the request can be retried from scratch
+	Release(ctx context.Context, id primitive.ObjectID) error
+}
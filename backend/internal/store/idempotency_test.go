@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"valet-parking-backend/internal/models"
+)
+
+func TestIdempotencyClaimTakesOverDeadRequests(t *testing.T) {
+	ctx := context.Background()
+	records := NewMemory().Idempotency()
+
+	now := time.Now()
+	claim := func(lockedUntil time.Time) (models.IdempotencyRecord, bool) {
+		t.Helper()
+		record, claimed, err := records.Claim(ctx, models.IdempotencyRecord{
+			Key:         "key-1",
+			UserID:      "user-1",
+			RequestHash: "hash",
+			LockedUntil: lockedUntil,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		return record, claimed
+	}
+
+	// The first request's lease has already run out, as if it crashed
+	first, claimed := claim(now.Add(-time.Second))
+	if !claimed {
+		t.Fatal("first claim was refused")
+	}
+	second, claimed := claim(now.Add(time.Minute))
+	if !claimed || second.ID == first.ID {
+		t.Fatalf("retry after a lapsed lease did not take over: claimed %v", claimed)
+	}
+
+	// A live lease holds the key
+	if existing, claimed := claim(now.Add(time.Minute)); claimed || existing.ID != second.ID {
+		t.Fatalf("retry during a live lease: claimed %v, got record %s", claimed, existing.ID.Hex())
+	}
+
+	// A completed response is replayed however old its lease
+	if err := records.Complete(ctx, second.ID, 201, nil, []byte("{}")); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if existing, claimed := claim(now.Add(time.Minute)); claimed || existing.Status != 201 {
+		t.Fatalf("retry after completion: claimed %v, status %d", claimed, existing.Status)
+	}
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InspectionRepository interface {
+	Insert(ctx context.Context, inspection models.Inspection) error
+	FindByStage(ctx context.Context, sessionID primitive.ObjectID, stage models.InspectionStage) (models.Inspection, error)
+	// ListBySession returns a session's inspections, oldest first
+	ListBySession(ctx context.Context, sessionID primitive.ObjectID) ([]models.Inspection, error)
+	// FindByIDs returns the given inspections, oldest first
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Inspection, error)
+	Acknowledge(ctx context.Context, id, by primitive.ObjectID, at time.Time) error
+	// SessionIDsWithPhoto returns the sessions whose inspections include a media ID
+	SessionIDsWithPhoto(ctx context.Context, mediaID string) ([]primitive.ObjectID, error)
+}
+
+type MediaRepository interface {
+	Insert(ctx context.Context, m models.Media) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Media, error)
+	// FindWithStatus returns a media object only if it is in the given state
+	FindWithStatus(ctx context.Context, id primitive.ObjectID, status models.MediaStatus) (models.Media, error)
+	// CountReady counts the given objects that are ready, of a kind and uploaded by owner
+	CountReady(ctx context.Context, ids []primitive.ObjectID, ownerID primitive.ObjectID, kind models.MediaKind) (int64, error)
+	// MarkReady stores the processed details of an uploaded object
+	MarkReady(ctx context.Context, m models.Media) error
+}
+
+// IncidentFilter selects incidents. Zero-valued fields are ignored.
+type IncidentFilter struct {
+	ID        primitive.ObjectID
+	SessionID primitive.ObjectID
+	VenueName string
+	Status    models.IncidentStatus
+	Severity  models.IncidentSeverity
+}
+
+// IncidentUpdate is one triage step: Event is appended to the history and
+// the other non-zero fields are set
+type IncidentUpdate struct {
+	Status     models.IncidentStatus
+	Severity   models.IncidentSeverity
+	Resolution string
+	Event      models.IncidentEvent
+	UpdatedAt  time.Time
+}
+
+type IncidentRepository interface {
+	Insert(ctx context.Context, incident models.Incident) error
+	FindOne(ctx context.Context, filter IncidentFilter) (models.Incident, error)
+	// Find returns matching incidents, newest first; a limit of 0 means no limit
+	Find(ctx context.Context, filter IncidentFilter, limit int64) ([]models.Incident, error)
+	// Update applies a triage step if the incident is still in fromStatus and reports whether it was
+	Update(ctx context.Context, id primitive.ObjectID, fromStatus models.IncidentStatus, update IncidentUpdate) (bool, error)
+	// SessionIDsWithPhoto returns the sessions whose incidents include a media ID
+	SessionIDsWithPhoto(ctx context.Context, mediaID string) ([]primitive.ObjectID, error)
+}
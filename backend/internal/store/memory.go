@@ -0,0 +1,287 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NewMemory returns an empty store that keeps all data in process. It
+// behaves like the MongoDB store, including atomic conditional updates, and
+// is meant for tests and local development.
+func NewMemory() *Store {
+	users := &memoryUsers{}
+	vehicles := &memoryVehicles{}
+	s := &Store{
+		users:         users,
+		otps:          &memoryOTPs{},
+		vehicles:      vehicles,
+		plateDisputes: &memoryPlateDisputes{},
+		sessions:      &memorySessions{vehicles: vehicles, users: users},
+		venues:        &memoryVenues{},
+		inspections:   &memoryInspections{},
+		media:         &memoryMedia{},
+		keyTags:       &memoryKeyTags{},
+		auditLogs:     &memoryAuditLogs{},
+		ratings:       &memoryRatings{},
+		alerts:        &memoryAlerts{},
+		tips:          &memoryTips{},
+		incidents:     &memoryIncidents{},
+		idempotency:   &memoryIdempotency{},
+	}
+	s.transact = (&memoryTransactions{}).run
+	return s
+}
+
+// memoryTransactions runs transactions one at a time. Writes made through a
+// transaction's ctx are journaled, and if fn fails exactly those are undone;
+// writes made outside the transaction meanwhile are kept.
+type memoryTransactions struct {
+	mu sync.Mutex
+}
+
+func (t *memoryTransactions) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	j := &journal{}
+	if err := fn(context.WithValue(ctx, journalKey{}, j)); err != nil {
+		j.rollback()
+		return err
+	}
+	return nil
+}
+
+type journalKey struct{}
+
+// journal records how to undo each write made inside a transaction
+type journal struct {
+	mu   sync.Mutex
+	undo []func()
+}
+
+// record adds undo to the journal of the transaction ctx belongs to, if any
+func record(ctx context.Context, undo func()) {
+	if j, ok := ctx.Value(journalKey{}).(*journal); ok {
+		j.mu.Lock()
+		j.undo = append(j.undo, undo)
+		j.mu.Unlock()
+	}
+}
+
+// rollback undoes the recorded writes, latest first
+func (j *journal) rollback() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := len(j.undo) - 1; i >= 0; i-- {
+		j.undo[i]()
+	}
+	j.undo = nil
+}
+
+// clone deep-copies a document by round-tripping it through BSON, so stored
+// documents never share memory with callers and values are truncated to what
+// MongoDB would store (e.g. millisecond timestamps)
+func clone[T any](doc T) T {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		panic("store: cannot encode document: " + err.Error())
+	}
+	var out T
+	if err := bson.Unmarshal(data, &out); err != nil {
+		panic("store: cannot decode document: " + err.Error())
+	}
+	return out
+}
+
+func withID[T any](doc T) T {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		panic("store: cannot encode document: " + err.Error())
+	}
+	var raw bson.M
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		panic("store: cannot decode document: " + err.Error())
+	}
+	if _, ok := raw["_id"]; !ok {
+		raw["_id"] = primitive.NewObjectID()
+	}
+	data, err = bson.Marshal(raw)
+	if err != nil {
+		panic("store: cannot encode document: " + err.Error())
+	}
+	var out T
+	if err := bson.Unmarshal(data, &out); err != nil {
+		panic("store: cannot decode document: " + err.Error())
+	}
+	return out
+}
+
+// table is a collection of documents in insertion order. All access goes
+// through the mutex so a match-and-update is atomic, like a single MongoDB
+// update. Writes swap in a new row rather than changing one in place, so a
+// transaction can tell whether a row still holds what it wrote.
+type table[T any] struct {
+	mu   sync.RWMutex
+	rows []*T
+}
+
+// insert stores a copy of doc, generating an _id when it has none like the
+// MongoDB driver does
+func (t *table[T]) insert(ctx context.Context, doc T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(ctx, withID(doc))
+}
+
+// add appends row; t.mu must be held
+func (t *table[T]) add(ctx context.Context, row T) {
+	added := &row
+	t.rows = append(t.rows, added)
+	t.journal(ctx, func() {
+		t.rows = slices.DeleteFunc(t.rows, func(r *T) bool { return r == added })
+	})
+}
+
+// replace puts next in place of the row at i; t.mu must be held. Undoing it
+// restores the old row unless another write has replaced next since.
+func (t *table[T]) replace(ctx context.Context, i int, next T) {
+	old, replaced := t.rows[i], &next
+	t.rows[i] = replaced
+	t.journal(ctx, func() {
+		if i := slices.Index(t.rows, replaced); i >= 0 {
+			t.rows[i] = old
+		}
+	})
+}
+
+// remove deletes the matching rows; t.mu must be held. Undoing it puts them
+// back where they were.
+func (t *table[T]) remove(ctx context.Context, match func(*T) bool) {
+	type removedRow struct {
+		at  int
+		row *T
+	}
+	var removed []removedRow
+	for i, row := range t.rows {
+		if match(row) {
+			removed = append(removed, removedRow{i, row})
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	t.rows = slices.DeleteFunc(t.rows, match)
+	t.journal(ctx, func() {
+		for _, r := range removed {
+			t.rows = slices.Insert(t.rows, min(r.at, len(t.rows)), r.row)
+		}
+	})
+}
+
+// journal records undo, run under t.mu, if ctx is in a transaction
+func (t *table[T]) journal(ctx context.Context, undo func()) {
+	record(ctx, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		undo()
+	})
+}
+
+// find returns copies of all matching documents in insertion order
+func (t *table[T]) find(match func(*T) bool) []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var docs []T
+	for _, row := range t.rows {
+		if match(row) {
+			docs = append(docs, clone(*row))
+		}
+	}
+	return docs
+}
+
+func (t *table[T]) count(match func(*T) bool) int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var n int64
+	for _, row := range t.rows {
+		if match(row) {
+			n++
+		}
+	}
+	return n
+}
+
+// update applies change to the first matching document, or every matching
+// document when many is set, and returns how many matched
+func (t *table[T]) update(ctx context.Context, match func(*T) bool, change func(*T), many bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for i, row := range t.rows {
+		if !match(row) {
+			continue
+		}
+		changed := clone(*row)
+		change(&changed)
+		t.replace(ctx, i, changed)
+		n++
+		if !many {
+			break
+		}
+	}
+	return n
+}
+
+// upsert updates the first matching document or inserts the one built by create
+func (t *table[T]) upsert(ctx context.Context, match func(*T) bool, change func(*T), create func() T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, row := range t.rows {
+		if match(row) {
+			changed := clone(*row)
+			change(&changed)
+			t.replace(ctx, i, changed)
+			return
+		}
+	}
+	row := create()
+	change(&row)
+	t.add(ctx, clone(row))
+}
+
+func (t *table[T]) delete(ctx context.Context, match func(*T) bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.remove(ctx, match)
+}
+
+// first returns the first of docs or ErrNotFound
+func first[T any](docs []T) (T, error) {
+	if len(docs) == 0 {
+		var zero T
+		return zero, ErrNotFound
+	}
+	return docs[0], nil
+}
+
+// limited truncates docs to limit when limit is positive
+func limited[T any](docs []T, limit int64) []T {
+	if limit > 0 && int64(len(docs)) > limit {
+		return docs[:limit]
+	}
+	return docs
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	return slices.Contains(ids, id)
+}
+
+// idMatches applies an optional ID condition: a zero want matches everything
+func idMatches(want, got primitive.ObjectID) bool {
+	return want.IsZero() || want == got
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryRatings struct {
+	table[models.Rating]
+}
+
+func (f RatingFilter) matches(r *models.Rating) bool {
+	return idMatches(f.SessionID, r.SessionID) &&
+		(f.VenueName == "" || f.VenueName == r.VenueName) &&
+		(f.ValetID.IsZero() || containsID(r.ValetIDs, f.ValetID)) &&
+		(f.CreatedAt == nil || f.CreatedAt.contains(r.CreatedAt))
+}
+
+func (r *memoryRatings) Insert(ctx context.Context, rating models.Rating) error {
+	r.insert(ctx, rating)
+	return nil
+}
+
+func (r *memoryRatings) FindOne(ctx context.Context, filter RatingFilter) (models.Rating, error) {
+	return first(r.find(filter.matches))
+}
+
+func (r *memoryRatings) Find(ctx context.Context, filter RatingFilter) ([]models.Rating, error) {
+	return r.find(filter.matches), nil
+}
+
+func (r *memoryRatings) ScoreCounts(ctx context.Context, venueName string, created TimeRange) (map[int]int, error) {
+	counts := make(map[int]int)
+	for _, rating := range r.find(RatingFilter{VenueName: venueName, CreatedAt: &created}.matches) {
+		counts[rating.Score]++
+	}
+	return counts, nil
+}
+
+func (r *memoryRatings) ValetScores(ctx context.Context, venueName string, created TimeRange, lowThreshold int) ([]ValetRatingStats, error) {
+	var stats []ValetRatingStats
+	totals := make(map[primitive.ObjectID]int)
+	index := make(map[primitive.ObjectID]int)
+	for _, rating := range r.find(RatingFilter{VenueName: venueName, CreatedAt: &created}.matches) {
+		for _, valetID := range rating.ValetIDs {
+			i, ok := index[valetID]
+			if !ok {
+				i = len(stats)
+				index[valetID] = i
+				stats = append(stats, ValetRatingStats{ValetID: valetID})
+			}
+			stats[i].Count++
+			totals[valetID] += rating.Score
+			if rating.Score <= lowThreshold {
+				stats[i].Low++
+			}
+		}
+	}
+	for i := range stats {
+		stats[i].Average = float64(totals[stats[i].ValetID]) / float64(stats[i].Count)
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Average > stats[j].Average })
+	return stats, nil
+}
+
+type memoryTips struct {
+	table[models.Tip]
+}
+
+func (r *memoryTips) Insert(ctx context.Context, tip models.Tip) error {
+	// Check and insert under one lock, like the unique index on cash tips
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tip.Method == models.TipCash && slices.ContainsFunc(r.rows, func(t *models.Tip) bool {
+		return t.Method == models.TipCash && t.SessionID == tip.SessionID
+	}) {
+		return ErrDuplicate
+	}
+	r.add(ctx, withID(tip))
+	return nil
+}
+
+func (r *memoryTips) FindByID(ctx context.Context, id primitive.ObjectID) (models.Tip, error) {
+	return first(r.find(func(t *models.Tip) bool { return t.ID == id }))
+}
+
+func (r *memoryTips) ListBySession(ctx context.Context, sessionID primitive.ObjectID) ([]models.Tip, error) {
+	tips := r.find(func(t *models.Tip) bool { return t.SessionID == sessionID })
+	sort.SliceStable(tips, func(i, j int) bool { return tips[i].CreatedAt.Before(tips[j].CreatedAt) })
+	return tips, nil
+}
+
+func (r *memoryTips) SetStatus(ctx context.Context, id primitive.ObjectID, status models.TipStatus, paymentRef string) error {
+	r.update(ctx, func(t *models.Tip) bool { return t.ID == id }, func(t *models.Tip) {
+		t.Status = status
+		if paymentRef != "" {
+			t.PaymentRef = paymentRef
+		}
+	}, false)
+	return nil
+}
+
+func (r *memoryTips) Settle(ctx context.Context, id primitive.ObjectID, status models.TipStatus, paymentRef string) error {
+	n := r.update(ctx, func(t *models.Tip) bool { return t.ID == id && t.Status == models.TipPending }, func(t *models.Tip) {
+		t.Status = status
+		if paymentRef != "" {
+			t.PaymentRef = paymentRef
+		}
+	}, false)
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *memoryTips) ValetTotals(ctx context.Context, venueName string, created TimeRange, valetID primitive.ObjectID) ([]ValetTipTotals, error) {
+	tips := r.find(func(t *models.Tip) bool {
+		return t.VenueName == venueName &&
+			created.contains(t.CreatedAt) &&
+			(t.Status == models.TipRecorded || t.Status == models.TipCaptured)
+	})
+
+	var totals []ValetTipTotals
+	index := make(map[primitive.ObjectID]int)
+	for _, tip := range tips {
+		for _, share := range tip.Shares {
+			if !idMatches(valetID, share.ValetID) {
+				continue
+			}
+			i, ok := index[share.ValetID]
+			if !ok {
+				i = len(totals)
+				index[share.ValetID] = i
+				totals = append(totals, ValetTipTotals{ValetID: share.ValetID})
+			}
+			totals[i].Tips++
+			switch tip.Method {
+			case models.TipCash:
+				totals[i].Cash += share.Amount
+			case models.TipOnline:
+				totals[i].Online += share.Amount
+			}
+		}
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Online > totals[j].Online })
+	return totals, nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryIdempotency struct {
+	table[models.IdempotencyRecord]
+}
+
+func (r *memoryIdempotency) Claim(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	// Look up and insert under one lock, like the unique index on user and key
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, row := range r.rows {
+		if row.UserID == record.UserID && row.Key == record.Key && row.ExpiresAt.After(now) &&
+			(row.Status != 0 || row.LockedUntil.After(now)) {
+			return clone(*row), false, nil
+		}
+	}
+
+	r.remove(ctx, func(row *models.IdempotencyRecord) bool {
+		return row.UserID == record.UserID && row.Key == record.Key
+	})
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	r.add(ctx, clone(record))
+	return record, true, nil
+}
+
+func (r *memoryIdempotency) Complete(ctx context.Context, id primitive.ObjectID, status int, header map[string][]string, body []byte) error {
+	r.update(ctx, func(rec *models.IdempotencyRecord) bool { return rec.ID == id }, func(rec *models.IdempotencyRecord) {
+		rec.Status = status
+		rec.Header = header
+		rec.Body = body
+	}, false)
+	return nil
+}
+
+func (r *memoryIdempotency) Release(ctx context.Context, id primitive.ObjectID) error {
+	r.delete(ctx, func(rec *models.IdempotencyRecord) bool { return rec.ID == id })
+	return nil
+}
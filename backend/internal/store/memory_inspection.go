@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence of each
+func uniqueIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	var out []primitive.ObjectID
+	for _, id := range ids {
+		if !containsID(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+type memoryInspections struct {
+	table[models.Inspection]
+}
+
+func sortOldestInspections(inspections []models.Inspection) []models.Inspection {
+	sort.SliceStable(inspections, func(i, j int) bool { return inspections[i].CreatedAt.Before(inspections[j].CreatedAt) })
+	return inspections
+}
+
+func (r *memoryInspections) Insert(ctx context.Context, inspection models.Inspection) error {
+	r.insert(ctx, inspection)
+	return nil
+}
+
+func (r *memoryInspections) FindByStage(ctx context.Context, sessionID primitive.ObjectID, stage models.InspectionStage) (models.Inspection, error) {
+	return first(r.find(func(i *models.Inspection) bool {
+		return i.SessionID == sessionID && i.Stage == stage
+	}))
+}
+
+func (r *memoryInspections) ListBySession(ctx context.Context, sessionID primitive.ObjectID) ([]models.Inspection, error) {
+	return sortOldestInspections(r.find(func(i *models.Inspection) bool { return i.SessionID == sessionID })), nil
+}
+
+func (r *memoryInspections) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Inspection, error) {
+	return sortOldestInspections(r.find(func(i *models.Inspection) bool { return containsID(ids, i.ID) })), nil
+}
+
+func (r *memoryInspections) Acknowledge(ctx context.Context, id, by primitive.ObjectID, at time.Time) error {
+	r.update(ctx, func(i *models.Inspection) bool { return i.ID == id }, func(i *models.Inspection) {
+		i.AcknowledgedAt = &at
+		i.AcknowledgedBy = &by
+	}, false)
+	return nil
+}
+
+func (r *memoryInspections) SessionIDsWithPhoto(ctx context.Context, mediaID string) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, i := range r.find(func(i *models.Inspection) bool { return slices.Contains(i.Photos, mediaID) }) {
+		ids = append(ids, i.SessionID)
+	}
+	return uniqueIDs(ids), nil
+}
+
+type memoryMedia struct {
+	table[models.Media]
+}
+
+func (r *memoryMedia) Insert(ctx context.Context, m models.Media) error {
+	r.insert(ctx, m)
+	return nil
+}
+
+func (r *memoryMedia) FindByID(ctx context.Context, id primitive.ObjectID) (models.Media, error) {
+	return first(r.find(func(m *models.Media) bool { return m.ID == id }))
+}
+
+func (r *memoryMedia) FindWithStatus(ctx context.Context, id primitive.ObjectID, status models.MediaStatus) (models.Media, error) {
+	return first(r.find(func(m *models.Media) bool { return m.ID == id && m.Status == status }))
+}
+
+func (r *memoryMedia) CountReady(ctx context.Context, ids []primitive.ObjectID, ownerID primitive.ObjectID, kind models.MediaKind) (int64, error) {
+	return r.count(func(m *models.Media) bool {
+		return containsID(ids, m.ID) && m.OwnerID == ownerID && m.Kind == kind && m.Status == models.MediaStatusReady
+	}), nil
+}
+
+func (r *memoryMedia) MarkReady(ctx context.Context, ready models.Media) error {
+	r.update(ctx, func(m *models.Media) bool { return m.ID == ready.ID }, func(m *models.Media) {
+		m.Status = models.MediaStatusReady
+		m.Key = ready.Key
+		m.ThumbnailKey = ready.ThumbnailKey
+		m.ContentType = ready.ContentType
+		m.Width = ready.Width
+		m.Height = ready.Height
+		m.Size = ready.Size
+		m.UploadedAt = ready.UploadedAt
+	}, false)
+	return nil
+}
+
+type memoryIncidents struct {
+	table[models.Incident]
+}
+
+func (f IncidentFilter) matches(i *models.Incident) bool {
+	return idMatches(f.ID, i.ID) &&
+		idMatches(f.SessionID, i.SessionID) &&
+		(f.VenueName == "" || f.VenueName == i.VenueName) &&
+		(f.Status == "" || f.Status == i.Status) &&
+		(f.Severity == "" || f.Severity == i.Severity)
+}
+
+func (r *memoryIncidents) Insert(ctx context.Context, incident models.Incident) error {
+	r.insert(ctx, incident)
+	return nil
+}
+
+func (r *memoryIncidents) FindOne(ctx context.Context, filter IncidentFilter) (models.Incident, error) {
+	return first(r.find(filter.matches))
+}
+
+func (r *memoryIncidents) Find(ctx context.Context, filter IncidentFilter, limit int64) ([]models.Incident, error) {
+	incidents := r.find(filter.matches)
+	sort.SliceStable(incidents, func(i, j int) bool { return incidents[i].CreatedAt.After(incidents[j].CreatedAt) })
+	return limited(incidents, limit), nil
+}
+
+func (r *memoryIncidents) Update(ctx context.Context, id primitive.ObjectID, fromStatus models.IncidentStatus, update IncidentUpdate) (bool, error) {
+	n := r.update(ctx, IncidentFilter{ID: id, Status: fromStatus}.matches, func(i *models.Incident) {
+		if update.Status != "" {
+			i.Status = update.Status
+		}
+		if update.Severity != "" {
+			i.Severity = update.Severity
+		}
+		if update.Resolution != "" {
+			i.Resolution = update.Resolution
+		}
+		i.UpdatedAt = update.UpdatedAt
+		i.History = append(i.History, update.Event)
+	}, false)
+	return n > 0, nil
+}
+
+func (r *memoryIncidents) SessionIDsWithPhoto(ctx context.Context, mediaID string) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, i := range r.find(func(i *models.Incident) bool { return slices.Contains(i.Photos, mediaID) }) {
+		ids = append(ids, i.SessionID)
+	}
+	return uniqueIDs(ids), nil
+}
@@ -0,0 +1,264 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySessions struct {
+	table[models.ParkingSession]
+	vehicles *memoryVehicles // Joined by FindWithDetails
+	users    *memoryUsers
+}
+
+func (f SessionFilter) matches(s *models.ParkingSession) bool {
+	if !idMatches(f.ID, s.ID) || (f.ID.IsZero() && f.IDs != nil && !containsID(f.IDs, s.ID)) {
+		return false
+	}
+	if !idMatches(f.VehicleID, s.VehicleID) || !idMatches(f.CustomerID, s.CustomerID) || !idMatches(f.ValetID, s.ValetID) {
+		return false
+	}
+	if !f.DeliveredBy.IsZero() && (s.DeliveredBy == nil || *s.DeliveredBy != f.DeliveredBy) {
+		return false
+	}
+	if f.VenueName != "" && f.VenueName != s.VenueName {
+		return false
+	}
+	if f.TicketNumber != "" && f.TicketNumber != s.TicketNumber {
+		return false
+	}
+	if f.Statuses != nil && !slices.Contains(f.Statuses, s.Status) {
+		return false
+	}
+	if slices.Contains(f.NotStatuses, s.Status) {
+		return false
+	}
+	if f.Access != nil && s.CustomerID != f.Access.CustomerID && !containsID(f.Access.VehicleIDs, s.VehicleID) {
+		return false
+	}
+	if f.PickupOTP != "" && f.PickupOTP != s.PickupOTP {
+		return false
+	}
+	if f.ParkedAt != nil && !f.ParkedAt.contains(s.ParkedAt) {
+		return false
+	}
+	if f.DeliveredAt != nil && (s.DeliveredAt == nil || !f.DeliveredAt.contains(*s.DeliveredAt)) {
+		return false
+	}
+	if f.NoKeyTag && s.KeyTag != nil {
+		return false
+	}
+	if !f.KeyOut.IsZero() && (s.KeyTag == nil || s.KeyTag.TagID != f.KeyOut || s.KeyTag.ReturnedAt != nil) {
+		return false
+	}
+	if f.NotRated && s.RatedAt != nil {
+		return false
+	}
+	if versions, ok := f.versions(); ok && !slices.Contains(versions, s.Version) {
+		return false
+	}
+	if f.After != nil && !newerParked(*f.After, CursorAfter(*s)) {
+		return false
+	}
+	return true
+}
+
+// apply mirrors the MongoDB update: fields are unset first, then set,
+// incremented and pushed
+func (u SessionUpdate) apply(s *models.ParkingSession) {
+	if u.ClearPickup {
+		s.RequestedAt = nil
+		s.RequestedBy = nil
+	}
+	if u.ClearPickup || u.ClearOTP {
+		s.PickupOTP = ""
+		s.OTPExpiresAt = nil
+		s.OTPRollovers = 0
+	}
+	if u.ResetOTPRollovers {
+		s.OTPRollovers = 0
+	}
+	if u.ClearKeyHolder && s.KeyTag != nil {
+		s.KeyTag.HolderID = nil
+	}
+	if u.ClearRatedAt {
+		s.RatedAt = nil
+	}
+
+	if u.Status != "" {
+		s.Status = u.Status
+		s.Active = !slices.Contains(models.ClosedStatuses, u.Status)
+	}
+	if u.ParkingSpot != "" {
+		s.ParkingSpot = u.ParkingSpot
+	}
+	if u.RequestedAt != nil {
+		s.RequestedAt = u.RequestedAt
+	}
+	if u.RequestedBy != nil {
+		s.RequestedBy = u.RequestedBy
+	}
+	if u.PickupOTP != "" {
+		s.PickupOTP = u.PickupOTP
+	}
+	if u.OTPExpiresAt != nil {
+		s.OTPExpiresAt = u.OTPExpiresAt
+	}
+	if u.DeliveredAt != nil {
+		s.DeliveredAt = u.DeliveredAt
+	}
+	if u.DeliveredBy != nil {
+		s.DeliveredBy = u.DeliveredBy
+	}
+	if u.KeyTag != nil {
+		key := *u.KeyTag
+		s.KeyTag = &key
+	}
+	if s.KeyTag == nil && (u.KeyHolder != nil || u.KeyLocation != "" || u.KeyReturnedAt != nil) {
+		s.KeyTag = &models.SessionKey{}
+	}
+	if u.KeyHolder != nil {
+		s.KeyTag.HolderID = u.KeyHolder
+	}
+	if u.KeyLocation != "" {
+		s.KeyTag.Location = u.KeyLocation
+	}
+	if u.KeyReturnedAt != nil {
+		s.KeyTag.ReturnedAt = u.KeyReturnedAt
+	}
+	if u.Release != nil {
+		s.Release = u.Release
+	}
+	if u.Rejection != nil {
+		s.Rejection = u.Rejection
+	}
+	if u.RatedAt != nil {
+		s.RatedAt = u.RatedAt
+	}
+
+	if u.IncOTPRollovers {
+		s.OTPRollovers++
+	}
+	s.Version++
+	if u.Custody != nil {
+		s.KeyCustody = append(s.KeyCustody, *u.Custody)
+	}
+}
+
+func (r *memorySessions) Insert(ctx context.Context, session models.ParkingSession) error {
+	session.Active = !slices.Contains(models.ClosedStatuses, session.Status)
+
+	// Check and insert under one lock, like the unique index on active sessions
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session.Active && slices.ContainsFunc(r.rows, func(s *models.ParkingSession) bool {
+		return s.Active && s.VehicleID == session.VehicleID
+	}) {
+		return ErrDuplicate
+	}
+	r.add(ctx, withID(session))
+	return nil
+}
+
+func (r *memorySessions) FindByID(ctx context.Context, id primitive.ObjectID) (models.ParkingSession, error) {
+	return first(r.find(SessionFilter{ID: id}.matches))
+}
+
+// newerParked reports whether a comes before b in the newest-parked-first order
+func newerParked(a, b SessionCursor) bool {
+	if !a.ParkedAt.Equal(b.ParkedAt) {
+		return a.ParkedAt.After(b.ParkedAt)
+	}
+	return a.ID.Hex() > b.ID.Hex()
+}
+
+func (r *memorySessions) FindOne(ctx context.Context, filter SessionFilter) (models.ParkingSession, error) {
+	sessions, _ := r.Find(ctx, filter, 1)
+	return first(sessions)
+}
+
+func (r *memorySessions) Find(ctx context.Context, filter SessionFilter, limit int64) ([]models.ParkingSession, error) {
+	sessions := r.find(filter.matches)
+	sort.SliceStable(sessions, func(i, j int) bool { return newerParked(CursorAfter(sessions[i]), CursorAfter(sessions[j])) })
+	return limited(sessions, limit), nil
+}
+
+func (r *memorySessions) FindWithDetails(ctx context.Context, filter SessionFilter, limit int64) ([]models.SessionWithDetails, error) {
+	sessions, _ := r.Find(ctx, filter, limit)
+
+	details := make([]models.SessionWithDetails, 0, len(sessions))
+	for _, s := range sessions {
+		d := models.SessionWithDetails{ParkingSession: s}
+		if v, err := r.vehicles.FindByID(ctx, s.VehicleID); err == nil {
+			d.Vehicle = &v
+		}
+		if u, err := r.users.FindByID(ctx, s.CustomerID); err == nil {
+			d.Customer = &u
+		}
+		if u, err := r.users.FindByID(ctx, s.ValetID); err == nil {
+			d.Valet = &u
+		}
+		details = append(details, d)
+	}
+	return details, nil
+}
+
+func (r *memorySessions) Count(ctx context.Context, filter SessionFilter) (int64, error) {
+	return r.count(filter.matches), nil
+}
+
+func (r *memorySessions) Update(ctx context.Context, filter SessionFilter, update SessionUpdate) (bool, error) {
+	return r.update(ctx, filter.matches, update.apply, false) > 0, nil
+}
+
+func (r *memorySessions) Apply(ctx context.Context, filter SessionFilter, update SessionUpdate) (models.ParkingSession, error) {
+	var updated models.ParkingSession
+	n := r.update(ctx, filter.matches, func(s *models.ParkingSession) {
+		update.apply(s)
+		updated = clone(*s)
+	}, false)
+	if n == 0 {
+		return updated, ErrNotFound
+	}
+	return updated, nil
+}
+
+func (r *memorySessions) CustomerVisits(ctx context.Context, venueName string) (map[primitive.ObjectID]int, error) {
+	visits := make(map[primitive.ObjectID]int)
+	for _, s := range r.find(SessionFilter{VenueName: venueName}.matches) {
+		visits[s.CustomerID]++
+	}
+	return visits, nil
+}
+
+func (r *memorySessions) ValetStats(ctx context.Context, venueName string, parked TimeRange) ([]ValetSessionStats, error) {
+	var stats []ValetSessionStats
+	index := make(map[primitive.ObjectID]int)
+	for _, s := range r.find(SessionFilter{VenueName: venueName, ParkedAt: &parked}.matches) {
+		i, ok := index[s.ValetID]
+		if !ok {
+			i = len(stats)
+			index[s.ValetID] = i
+			stats = append(stats, ValetSessionStats{
+				ValetID: s.ValetID,
+				Reasons: make(map[models.RejectionReason]int),
+			})
+		}
+		stats[i].Total++
+		if s.Status == models.StatusRejected {
+			stats[i].Rejected++
+			// Rejections recorded without a reason count as other
+			reason := models.RejectOther
+			if s.Rejection != nil && s.Rejection.Reason != "" {
+				reason = s.Rejection.Reason
+			}
+			stats[i].Reasons[reason]++
+		}
+	}
+	return stats, nil
+}
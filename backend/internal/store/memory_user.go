@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUsers struct {
+	table[models.User]
+}
+
+func (f UserFilter) matches(u *models.User) bool {
+	return idMatches(f.ID, u.ID) &&
+		(f.IDs == nil || containsID(f.IDs, u.ID)) &&
+		(f.Phone == "" || f.Phone == u.Phone) &&
+		(f.Role == "" || f.Role == u.Role) &&
+		(f.VenueName == "" || f.VenueName == u.VenueName)
+}
+
+func (r *memoryUsers) Insert(ctx context.Context, user models.User) error {
+	r.insert(ctx, user)
+	return nil
+}
+
+func (r *memoryUsers) FindByID(ctx context.Context, id primitive.ObjectID) (models.User, error) {
+	return r.FindOne(ctx, UserFilter{ID: id})
+}
+
+func (r *memoryUsers) FindOne(ctx context.Context, filter UserFilter) (models.User, error) {
+	return first(r.find(filter.matches))
+}
+
+func (r *memoryUsers) Find(ctx context.Context, filter UserFilter) ([]models.User, error) {
+	return r.find(filter.matches), nil
+}
+
+func (r *memoryUsers) Count(ctx context.Context, filter UserFilter) (int64, error) {
+	return r.count(filter.matches), nil
+}
+
+func (r *memoryUsers) UpdateProfile(ctx context.Context, id primitive.ObjectID, name, language string) error {
+	r.update(ctx, UserFilter{ID: id}.matches, func(u *models.User) {
+		u.Name = name
+		if language != "" {
+			u.PreferredLanguage = language
+		}
+	}, false)
+	return nil
+}
+
+func (r *memoryUsers) SetVenue(ctx context.Context, id primitive.ObjectID, venueName string) error {
+	r.update(ctx, UserFilter{ID: id}.matches, func(u *models.User) {
+		u.VenueName = venueName
+	}, false)
+	return nil
+}
+
+type memoryOTPs struct {
+	table[models.OTPStore]
+}
+
+func (r *memoryOTPs) Insert(ctx context.Context, otp models.OTPStore) error {
+	r.insert(ctx, otp)
+	return nil
+}
+
+func (r *memoryOTPs) FindByCode(ctx context.Context, phone, code string) (models.OTPStore, error) {
+	return first(r.find(func(o *models.OTPStore) bool {
+		return o.Phone == phone && o.OTP == code
+	}))
+}
+
+func (r *memoryOTPs) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.delete(ctx, func(o *models.OTPStore) bool { return o.ID == id })
+	return nil
+}
+
+func (r *memoryOTPs) DeleteByPhone(ctx context.Context, phone string) error {
+	r.delete(ctx, func(o *models.OTPStore) bool { return o.Phone == phone })
+	return nil
+}
@@ -0,0 +1,260 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryVehicles struct {
+	table[models.Vehicle]
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (f VehicleFilter) matches(v *models.Vehicle) bool {
+	if !idMatches(f.ID, v.ID) || (f.IDs != nil && !containsID(f.IDs, v.ID)) || (!f.NotID.IsZero() && f.NotID == v.ID) {
+		return false
+	}
+	if !idMatches(f.OwnerID, v.OwnerID) || (f.OwnerIDs != nil && !containsID(f.OwnerIDs, v.OwnerID)) || (!f.NotOwnerID.IsZero() && f.NotOwnerID == v.OwnerID) {
+		return false
+	}
+	if f.NormalizedReg != "" && f.NormalizedReg != v.NormalizedReg {
+		return false
+	}
+	if f.NormalizedReg == "" && f.RegContains != "" && !strings.Contains(v.NormalizedReg, f.RegContains) {
+		return false
+	}
+	if (f.Make != "" && !containsFold(v.Make, f.Make)) ||
+		(f.Model != "" && !containsFold(v.Model, f.Model)) ||
+		(f.Color != "" && !containsFold(v.Color, f.Color)) {
+		return false
+	}
+	if f.Photo != "" && !slices.Contains(v.Photos, f.Photo) {
+		return false
+	}
+	if f.SharedWith != "" {
+		shared := slices.ContainsFunc(v.SharedWith, func(s models.VehicleShare) bool {
+			return s.Phone == f.SharedWith && (f.SharePermission == "" || slices.Contains(s.Permissions, f.SharePermission))
+		})
+		if !shared {
+			return false
+		}
+	}
+	if f.Active && v.ArchivedAt != nil {
+		return false
+	}
+	status := v.Ownership.Status
+	if f.Unresolved && (status == models.OwnershipVerified || status == models.OwnershipRejected) {
+		return false
+	}
+	if f.NotRejected && status == models.OwnershipRejected {
+		return false
+	}
+	return true
+}
+
+func (u VehicleUpdate) apply(v *models.Vehicle) {
+	if u.RegistrationNumber != "" {
+		v.RegistrationNumber = u.RegistrationNumber
+	}
+	if u.NormalizedReg != "" {
+		v.NormalizedReg = u.NormalizedReg
+	}
+	if u.Make != "" {
+		v.Make = u.Make
+	}
+	if u.Model != "" {
+		v.Model = u.Model
+	}
+	if u.Color != "" {
+		v.Color = u.Color
+	}
+	if u.VehicleType != "" {
+		v.VehicleType = u.VehicleType
+	}
+	if u.Photos != nil {
+		v.Photos = *u.Photos
+	}
+	if u.Ownership != nil {
+		v.Ownership = *u.Ownership
+	}
+	if u.OwnershipStatus != "" {
+		v.Ownership.Status = u.OwnershipStatus
+	}
+	if u.OwnershipNote != "" {
+		v.Ownership.Note = u.OwnershipNote
+	}
+	if u.IsDefault != nil {
+		v.IsDefault = *u.IsDefault
+	}
+	if u.UpdatedAt != nil {
+		v.UpdatedAt = u.UpdatedAt
+	}
+	if u.ArchivedAt != nil {
+		v.ArchivedAt = u.ArchivedAt
+	}
+	if u.Restore {
+		v.ArchivedAt = nil
+	}
+}
+
+func (r *memoryVehicles) Insert(ctx context.Context, vehicle models.Vehicle) error {
+	r.insert(ctx, vehicle)
+	return nil
+}
+
+func (r *memoryVehicles) FindByID(ctx context.Context, id primitive.ObjectID) (models.Vehicle, error) {
+	return r.FindOne(ctx, VehicleFilter{ID: id})
+}
+
+func (r *memoryVehicles) FindOne(ctx context.Context, filter VehicleFilter) (models.Vehicle, error) {
+	return first(r.find(filter.matches))
+}
+
+func (r *memoryVehicles) Find(ctx context.Context, filter VehicleFilter, order VehicleOrder, limit int64) ([]models.Vehicle, error) {
+	vehicles := r.find(filter.matches)
+	switch order {
+	case VehicleOrderDefaultFirst:
+		sort.SliceStable(vehicles, func(i, j int) bool {
+			if vehicles[i].IsDefault != vehicles[j].IsDefault {
+				return vehicles[i].IsDefault
+			}
+			return vehicles[i].CreatedAt.After(vehicles[j].CreatedAt)
+		})
+	case VehicleOrderOldestFirst:
+		sort.SliceStable(vehicles, func(i, j int) bool {
+			return vehicles[i].CreatedAt.Before(vehicles[j].CreatedAt)
+		})
+	}
+	return limited(vehicles, limit), nil
+}
+
+func (r *memoryVehicles) Count(ctx context.Context, filter VehicleFilter) (int64, error) {
+	return r.count(filter.matches), nil
+}
+
+// score is the VehicleRank score of a vehicle
+func (rank VehicleRank) score(v *models.Vehicle) int {
+	score := 0
+	switch {
+	case rank.Plate == "":
+	case v.NormalizedReg == rank.Plate:
+		score += 100
+	case strings.HasSuffix(v.NormalizedReg, rank.Plate):
+		score += 70
+	default:
+		score += 40
+	}
+	if rank.Make != "" && strings.EqualFold(v.Make, rank.Make) {
+		score += 10
+	}
+	if rank.Model != "" && strings.EqualFold(v.Model, rank.Model) {
+		score += 10
+	}
+	if rank.Color != "" && strings.EqualFold(v.Color, rank.Color) {
+		score += 10
+	}
+	return score + visitBonus(rank.Visits[v.OwnerID])
+}
+
+func (r *memoryVehicles) Search(ctx context.Context, filter VehicleFilter, rank VehicleRank, skip, limit int64) ([]RankedVehicle, error) {
+	var ranked []RankedVehicle
+	for _, v := range r.find(filter.matches) {
+		ranked = append(ranked, RankedVehicle{Vehicle: v, Score: rank.score(&v)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].NormalizedReg < ranked[j].NormalizedReg
+	})
+	ranked = ranked[min(int(skip), len(ranked)):]
+	return limited(ranked, limit), nil
+}
+
+func (r *memoryVehicles) Update(ctx context.Context, id primitive.ObjectID, update VehicleUpdate) error {
+	r.update(ctx, VehicleFilter{ID: id}.matches, update.apply, false)
+	return nil
+}
+
+func (r *memoryVehicles) UpdateMany(ctx context.Context, filter VehicleFilter, update VehicleUpdate) error {
+	r.update(ctx, filter.matches, update.apply, true)
+	return nil
+}
+
+func (r *memoryVehicles) PutShare(ctx context.Context, id primitive.ObjectID, share models.VehicleShare) error {
+	r.update(ctx, VehicleFilter{ID: id}.matches, func(v *models.Vehicle) {
+		v.SharedWith = slices.DeleteFunc(v.SharedWith, func(s models.VehicleShare) bool { return s.Phone == share.Phone })
+		v.SharedWith = append(v.SharedWith, share)
+	}, false)
+	return nil
+}
+
+func (r *memoryVehicles) RemoveShare(ctx context.Context, id primitive.ObjectID, phone string) (bool, error) {
+	removed := false
+	r.update(ctx, VehicleFilter{ID: id}.matches, func(v *models.Vehicle) {
+		n := len(v.SharedWith)
+		v.SharedWith = slices.DeleteFunc(v.SharedWith, func(s models.VehicleShare) bool { return s.Phone == phone })
+		removed = len(v.SharedWith) < n
+	}, false)
+	return removed, nil
+}
+
+type memoryPlateDisputes struct {
+	table[models.PlateDispute]
+}
+
+func (r *memoryPlateDisputes) AddClaims(ctx context.Context, normalizedReg string, vehicleIDs []primitive.ObjectID) error {
+	r.upsert(ctx,
+		func(d *models.PlateDispute) bool {
+			return d.NormalizedReg == normalizedReg && d.Status == models.DisputeOpen
+		},
+		func(d *models.PlateDispute) {
+			for _, id := range vehicleIDs {
+				if !containsID(d.VehicleIDs, id) {
+					d.VehicleIDs = append(d.VehicleIDs, id)
+				}
+			}
+		},
+		func() models.PlateDispute {
+			return models.PlateDispute{
+				ID:            primitive.NewObjectID(),
+				NormalizedReg: normalizedReg,
+				Status:        models.DisputeOpen,
+				CreatedAt:     time.Now(),
+			}
+		},
+	)
+	return nil
+}
+
+func (r *memoryPlateDisputes) Resolve(ctx context.Context, normalizedReg string, vehicleID, by primitive.ObjectID, at time.Time) error {
+	r.update(ctx,
+		func(d *models.PlateDispute) bool {
+			return d.NormalizedReg == normalizedReg && d.Status == models.DisputeOpen
+		},
+		func(d *models.PlateDispute) {
+			d.Status = models.DisputeResolved
+			d.ResolvedVehicleID = &vehicleID
+			d.ResolvedBy = &by
+			d.ResolvedAt = &at
+		},
+		true,
+	)
+	return nil
+}
+
+func (r *memoryPlateDisputes) Find(ctx context.Context, status models.DisputeStatus, limit int64) ([]models.PlateDispute, error) {
+	disputes := r.find(func(d *models.PlateDispute) bool { return d.Status == status })
+	sort.SliceStable(disputes, func(i, j int) bool { return disputes[i].CreatedAt.After(disputes[j].CreatedAt) })
+	return limited(disputes, limit), nil
+}
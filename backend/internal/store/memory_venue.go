@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryVenues struct {
+	table[models.Venue]
+}
+
+func (r *memoryVenues) FindByName(ctx context.Context, name string) (models.Venue, error) {
+	return first(r.find(func(v *models.Venue) bool { return v.Name == name }))
+}
+
+func (r *memoryVenues) SaveSettings(ctx context.Context, venue models.Venue) error {
+	r.upsert(ctx,
+		func(v *models.Venue) bool { return v.Name == venue.Name },
+		func(v *models.Venue) {
+			id := v.ID
+			*v = venue
+			v.ID = id
+		},
+		func() models.Venue { return models.Venue{ID: primitive.NewObjectID()} },
+	)
+	return nil
+}
+
+type memoryKeyTags struct {
+	table[models.KeyTag]
+}
+
+func (f KeyTagFilter) matches(t *models.KeyTag) bool {
+	return idMatches(f.ID, t.ID) &&
+		(f.VenueName == "" || f.VenueName == t.VenueName) &&
+		(f.Number == "" || f.Number == t.Number) &&
+		(f.Status == "" || f.Status == t.Status)
+}
+
+func (r *memoryKeyTags) Insert(ctx context.Context, tag models.KeyTag) error {
+	r.insert(ctx, tag)
+	return nil
+}
+
+func (r *memoryKeyTags) FindOne(ctx context.Context, filter KeyTagFilter) (models.KeyTag, error) {
+	return first(r.find(filter.matches))
+}
+
+func (r *memoryKeyTags) Find(ctx context.Context, filter KeyTagFilter) ([]models.KeyTag, error) {
+	tags := r.find(filter.matches)
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].Number < tags[j].Number })
+	return tags, nil
+}
+
+func (r *memoryKeyTags) Count(ctx context.Context, filter KeyTagFilter) (int64, error) {
+	return r.count(filter.matches), nil
+}
+
+func (r *memoryKeyTags) Claim(ctx context.Context, id, sessionID primitive.ObjectID) (bool, error) {
+	n := r.update(ctx, KeyTagFilter{ID: id, Status: models.KeyTagAvailable}.matches, func(t *models.KeyTag) {
+		t.Status = models.KeyTagInUse
+		t.SessionID = &sessionID
+		t.UpdatedAt = time.Now()
+	}, false)
+	return n > 0, nil
+}
+
+func (r *memoryKeyTags) Free(ctx context.Context, id primitive.ObjectID) error {
+	r.update(ctx, KeyTagFilter{ID: id, Status: models.KeyTagInUse}.matches, func(t *models.KeyTag) {
+		t.Status = models.KeyTagAvailable
+		t.SessionID = nil
+		t.UpdatedAt = time.Now()
+	}, false)
+	return nil
+}
+
+func (r *memoryKeyTags) UpdateDetails(ctx context.Context, id primitive.ObjectID, location string, status models.KeyTagStatus) (bool, error) {
+	n := r.update(ctx,
+		func(t *models.KeyTag) bool { return t.ID == id && t.Status != models.KeyTagInUse },
+		func(t *models.KeyTag) {
+			if location != "" {
+				t.Location = location
+			}
+			if status != "" {
+				t.Status = status
+			}
+			t.UpdatedAt = time.Now()
+		},
+		false,
+	)
+	return n > 0, nil
+}
+
+type memoryAuditLogs struct {
+	table[models.AuditLog]
+}
+
+func (r *memoryAuditLogs) Insert(ctx context.Context, entry models.AuditLog) error {
+	r.insert(ctx, entry)
+	return nil
+}
+
+func (r *memoryAuditLogs) Find(ctx context.Context, filter AuditLogFilter, limit int64) ([]models.AuditLog, error) {
+	entries := r.find(func(e *models.AuditLog) bool {
+		return (filter.VenueName == "" || filter.VenueName == e.VenueName) &&
+			(filter.Action == "" || filter.Action == e.Action) &&
+			(filter.SessionID.IsZero() || (e.SessionID != nil && *e.SessionID == filter.SessionID))
+	})
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+	return limited(entries, limit), nil
+}
+
+type memoryAlerts struct {
+	table[models.Alert]
+}
+
+func (r *memoryAlerts) Insert(ctx context.Context, alert models.Alert) error {
+	r.insert(ctx, alert)
+	return nil
+}
+
+func (r *memoryAlerts) Find(ctx context.Context, filter AlertFilter, limit int64) ([]models.Alert, error) {
+	alerts := r.find(func(a *models.Alert) bool {
+		return (filter.VenueName == "" || filter.VenueName == a.VenueName) &&
+			(filter.Type == "" || filter.Type == a.Type) &&
+			(!filter.OpenOnly || a.AcknowledgedAt == nil)
+	})
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].CreatedAt.After(alerts[j].CreatedAt) })
+	return limited(alerts, limit), nil
+}
+
+func (r *memoryAlerts) Acknowledge(ctx context.Context, id primitive.ObjectID, venueName string, by primitive.ObjectID, at time.Time) (bool, error) {
+	n := r.update(ctx,
+		func(a *models.Alert) bool {
+			return a.ID == id && a.VenueName == venueName && a.AcknowledgedAt == nil
+		},
+		func(a *models.Alert) {
+			a.AcknowledgedAt = &at
+			a.AcknowledgedBy = &by
+		},
+		false,
+	)
+	return n > 0, nil
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"valet-parking-backend/internal/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewMongo returns a store backed by a MongoDB database
+func NewMongo(database *db.MongoDB) *Store {
+	return &Store{
+		users:         &mongoUsers{coll: database.Users()},
+		otps:          &mongoOTPs{coll: database.OTPs()},
+		vehicles:      &mongoVehicles{coll: database.Vehicles()},
+		plateDisputes: &mongoPlateDisputes{coll: database.PlateDisputes()},
+		sessions:      &mongoSessions{coll: database.Sessions(), vehicles: database.Vehicles().Name(), users: database.Users().Name()},
+		venues:        &mongoVenues{coll: database.Venues()},
+		inspections:   &mongoInspections{coll: database.Inspections()},
+		media:         &mongoMedia{coll: database.Media()},
+		keyTags:       &mongoKeyTags{coll: database.KeyTags()},
+		auditLogs:     &mongoAuditLogs{coll: database.AuditLogs()},
+		ratings:       &mongoRatings{coll: database.Ratings()},
+		alerts:        &mongoAlerts{coll: database.Alerts()},
+		tips:          &mongoTips{coll: database.Tips()},
+		incidents:     &mongoIncidents{coll: database.Incidents()},
+		idempotency:   &mongoIdempotency{coll: database.IdempotencyKeys()},
+		transact:      mongoTransaction(database.Client),
+	}
+}
+
+// mongoTransaction runs fn in a multi-document transaction, which needs a
+// replica set or sharded cluster
+func mongoTransaction(client *mongo.Client) func(ctx context.Context, fn func(ctx context.Context) error) error {
+	return func(ctx context.Context, fn func(ctx context.Context) error) error {
+		session, err := client.StartSession()
+		if err != nil {
+			return err
+		}
+		defer session.EndSession(ctx)
+
+		_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			return nil, fn(sc)
+		})
+		return err
+	}
+}
+
+// writeErr maps a duplicate key violation to ErrDuplicate
+func writeErr(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// findOne decodes the first matching document, mapping no match to ErrNotFound
+func findOne[T any](ctx context.Context, coll *mongo.Collection, filter bson.M, opts ...*options.FindOneOptions) (T, error) {
+	var doc T
+	err := coll.FindOne(ctx, filter, opts...).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return doc, ErrNotFound
+	}
+	return doc, err
+}
+
+// findAll decodes every matching document
+func findAll[T any](ctx context.Context, coll *mongo.Collection, filter bson.M, opts ...*options.FindOptions) ([]T, error) {
+	cursor, err := coll.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []T
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// aggregate decodes every row of an aggregation
+func aggregate[T any](ctx context.Context, coll *mongo.Collection, pipeline []bson.M) ([]T, error) {
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []T
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// distinctIDs returns the distinct ObjectID values of a field
+func distinctIDs(ctx context.Context, coll *mongo.Collection, field string, filter bson.M) ([]primitive.ObjectID, error) {
+	values, err := coll.Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// timeWindow builds a half-open range condition
+func timeWindow(r TimeRange) bson.M {
+	window := bson.M{"$gte": r.From}
+	if !r.To.IsZero() {
+		window["$lt"] = r.To
+	}
+	return window
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoRatings struct {
+	coll *mongo.Collection
+}
+
+func (f RatingFilter) bson() bson.M {
+	filter := bson.M{}
+	if !f.SessionID.IsZero() {
+		filter["session_id"] = f.SessionID
+	}
+	if f.VenueName != "" {
+		filter["venue_name"] = f.VenueName
+	}
+	if !f.ValetID.IsZero() {
+		filter["valet_ids"] = f.ValetID
+	}
+	if f.CreatedAt != nil {
+		filter["created_at"] = timeWindow(*f.CreatedAt)
+	}
+	return filter
+}
+
+func (r *mongoRatings) Insert(ctx context.Context, rating models.Rating) error {
+	_, err := r.coll.InsertOne(ctx, rating)
+	return err
+}
+
+func (r *mongoRatings) FindOne(ctx context.Context, filter RatingFilter) (models.Rating, error) {
+	return findOne[models.Rating](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoRatings) Find(ctx context.Context, filter RatingFilter) ([]models.Rating, error) {
+	return findAll[models.Rating](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoRatings) ScoreCounts(ctx context.Context, venueName string, created TimeRange) (map[int]int, error) {
+	rows, err := aggregate[struct {
+		Score int `bson:"_id"`
+		Count int `bson:"count"`
+	}](ctx, r.coll, []bson.M{
+		{"$match": RatingFilter{VenueName: venueName, CreatedAt: &created}.bson()},
+		{"$group": bson.M{"_id": "$score", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.Score] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *mongoRatings) ValetScores(ctx context.Context, venueName string, created TimeRange, lowThreshold int) ([]ValetRatingStats, error) {
+	rows, err := aggregate[struct {
+		ValetID primitive.ObjectID `bson:"_id"`
+		Average float64            `bson:"average"`
+		Count   int                `bson:"count"`
+		Low     int                `bson:"low"`
+	}](ctx, r.coll, []bson.M{
+		{"$match": RatingFilter{VenueName: venueName, CreatedAt: &created}.bson()},
+		{"$unwind": "$valet_ids"},
+		{"$group": bson.M{
+			"_id":     "$valet_ids",
+			"average": bson.M{"$avg": "$score"},
+			"count":   bson.M{"$sum": 1},
+			"low": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$lte": bson.A{"$score", lowThreshold}}, 1, 0},
+			}},
+		}},
+		{"$sort": bson.M{"average": -1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]ValetRatingStats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, ValetRatingStats{
+			ValetID: row.ValetID,
+			Average: row.Average,
+			Count:   row.Count,
+			Low:     row.Low,
+		})
+	}
+	return stats, nil
+}
+
+type mongoTips struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoTips) Insert(ctx context.Context, tip models.Tip) error {
+	_, err := r.coll.InsertOne(ctx, tip)
+	return writeErr(err)
+}
+
+func (r *mongoTips) FindByID(ctx context.Context, id primitive.ObjectID) (models.Tip, error) {
+	return findOne[models.Tip](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoTips) ListBySession(ctx context.Context, sessionID primitive.ObjectID) ([]models.Tip, error) {
+	return findAll[models.Tip](ctx, r.coll, bson.M{"session_id": sessionID}, options.Find().SetSort(oldestFirst))
+}
+
+func (r *mongoTips) SetStatus(ctx context.Context, id primitive.ObjectID, status models.TipStatus, paymentRef string) error {
+	set := bson.M{"status": status}
+	if paymentRef != "" {
+		set["payment_ref"] = paymentRef
+	}
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (r *mongoTips) Settle(ctx context.Context, id primitive.ObjectID, status models.TipStatus, paymentRef string) error {
+	set := bson.M{"status": status}
+	if paymentRef != "" {
+		set["payment_ref"] = paymentRef
+	}
+	result, err := r.coll.UpdateOne(ctx, bson.M{"_id": id, "status": models.TipPending}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoTips) ValetTotals(ctx context.Context, venueName string, created TimeRange, valetID primitive.ObjectID) ([]ValetTipTotals, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"venue_name": venueName,
+			"created_at": timeWindow(created),
+			"status":     bson.M{"$in": []models.TipStatus{models.TipRecorded, models.TipCaptured}},
+		}},
+		{"$unwind": "$shares"},
+	}
+	if !valetID.IsZero() {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"shares.valet_id": valetID}})
+	}
+
+	onlyMethod := func(method models.TipMethod) bson.M {
+		return bson.M{"$sum": bson.M{
+			"$cond": bson.A{bson.M{"$eq": bson.A{"$method", method}}, "$shares.amount", 0},
+		}}
+	}
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{
+			"_id":    "$shares.valet_id",
+			"tips":   bson.M{"$sum": 1},
+			"cash":   onlyMethod(models.TipCash),
+			"online": onlyMethod(models.TipOnline),
+		}},
+		bson.M{"$sort": bson.M{"online": -1}},
+	)
+
+	rows, err := aggregate[struct {
+		ValetID primitive.ObjectID `bson:"_id"`
+		Tips    int                `bson:"tips"`
+		Cash    int64              `bson:"cash"`
+		Online  int64              `bson:"online"`
+	}](ctx, r.coll, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]ValetTipTotals, 0, len(rows))
+	for _, row := range rows {
+		totals = append(totals, ValetTipTotals{
+			ValetID: row.ValetID,
+			Tips:    row.Tips,
+			Cash:    row.Cash,
+			Online:  row.Online,
+		})
+	}
+	return totals, nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoIdempotency struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoIdempotency) Claim(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	key := bson.M{"user_id": record.UserID, "key": record.Key}
+
+	// The TTL monitor only runs once a minute, so drop a lapsed record
+	// ourselves, along with a claim whose request died mid-way
+	now := time.Now()
+	lapsed := bson.M{"user_id": record.UserID, "key": record.Key, "$or": []bson.M{
+		{"expires_at": bson.M{"$lte": now}},
+		{"status": 0, "locked_until": bson.M{"$not": bson.M{"$gt": now}}},
+	}}
+	if _, err := r.coll.DeleteOne(ctx, lapsed); err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, record)
+	if err == nil {
+		return record, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	existing, err := findOne[models.IdempotencyRecord](ctx, r.coll, key)
+	return existing, false, err
+}
+
+func (r *mongoIdempotency) Complete(ctx context.Context, id primitive.ObjectID, status int, header map[string][]string, body []byte) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": status, "header": header, "body": body}},
+	)
+	return err
+}
+
+func (r *mongoIdempotency) Release(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
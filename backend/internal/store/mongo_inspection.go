@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var oldestFirst = bson.D{{Key: "created_at", Value: 1}}
+
+type mongoInspections struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoInspections) Insert(ctx context.Context, inspection models.Inspection) error {
+	_, err := r.coll.InsertOne(ctx, inspection)
+	return err
+}
+
+func (r *mongoInspections) FindByStage(ctx context.Context, sessionID primitive.ObjectID, stage models.InspectionStage) (models.Inspection, error) {
+	return findOne[models.Inspection](ctx, r.coll, bson.M{
+		"session_id": sessionID,
+		"stage":      stage,
+	})
+}
+
+func (r *mongoInspections) ListBySession(ctx context.Context, sessionID primitive.ObjectID) ([]models.Inspection, error) {
+	return findAll[models.Inspection](ctx, r.coll, bson.M{"session_id": sessionID}, options.Find().SetSort(oldestFirst))
+}
+
+func (r *mongoInspections) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Inspection, error) {
+	return findAll[models.Inspection](ctx, r.coll, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetSort(oldestFirst))
+}
+
+func (r *mongoInspections) Acknowledge(ctx context.Context, id, by primitive.ObjectID, at time.Time) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"acknowledged_at": at,
+			"acknowledged_by": by,
+		}},
+	)
+	return err
+}
+
+func (r *mongoInspections) SessionIDsWithPhoto(ctx context.Context, mediaID string) ([]primitive.ObjectID, error) {
+	return distinctIDs(ctx, r.coll, "session_id", bson.M{"photos": mediaID})
+}
+
+type mongoMedia struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoMedia) Insert(ctx context.Context, m models.Media) error {
+	_, err := r.coll.InsertOne(ctx, m)
+	return err
+}
+
+func (r *mongoMedia) FindByID(ctx context.Context, id primitive.ObjectID) (models.Media, error) {
+	return findOne[models.Media](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoMedia) FindWithStatus(ctx context.Context, id primitive.ObjectID, status models.MediaStatus) (models.Media, error) {
+	return findOne[models.Media](ctx, r.coll, bson.M{"_id": id, "status": status})
+}
+
+func (r *mongoMedia) CountReady(ctx context.Context, ids []primitive.ObjectID, ownerID primitive.ObjectID, kind models.MediaKind) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.M{
+		"_id":      bson.M{"$in": ids},
+		"owner_id": ownerID,
+		"kind":     kind,
+		"status":   models.MediaStatusReady,
+	})
+}
+
+func (r *mongoMedia) MarkReady(ctx context.Context, m models.Media) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": m.ID},
+		bson.M{
+			"$set": bson.M{
+				"status":        models.MediaStatusReady,
+				"key":           m.Key,
+				"thumbnail_key": m.ThumbnailKey,
+				"content_type":  m.ContentType,
+				"width":         m.Width,
+				"height":        m.Height,
+				"size":          m.Size,
+				"uploaded_at":   m.UploadedAt,
+			},
+		},
+	)
+	return err
+}
+
+type mongoIncidents struct {
+	coll *mongo.Collection
+}
+
+func (f IncidentFilter) bson() bson.M {
+	filter := bson.M{}
+	if !f.ID.IsZero() {
+		filter["_id"] = f.ID
+	}
+	if !f.SessionID.IsZero() {
+		filter["session_id"] = f.SessionID
+	}
+	if f.VenueName != "" {
+		filter["venue_name"] = f.VenueName
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.Severity != "" {
+		filter["severity"] = f.Severity
+	}
+	return filter
+}
+
+func (r *mongoIncidents) Insert(ctx context.Context, incident models.Incident) error {
+	_, err := r.coll.InsertOne(ctx, incident)
+	return err
+}
+
+func (r *mongoIncidents) FindOne(ctx context.Context, filter IncidentFilter) (models.Incident, error) {
+	return findOne[models.Incident](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoIncidents) Find(ctx context.Context, filter IncidentFilter, limit int64) ([]models.Incident, error) {
+	opts := options.Find().SetSort(newestFirst)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	return findAll[models.Incident](ctx, r.coll, filter.bson(), opts)
+}
+
+func (r *mongoIncidents) Update(ctx context.Context, id primitive.ObjectID, fromStatus models.IncidentStatus, update IncidentUpdate) (bool, error) {
+	set := bson.M{"updated_at": update.UpdatedAt}
+	if update.Status != "" {
+		set["status"] = update.Status
+	}
+	if update.Severity != "" {
+		set["severity"] = update.Severity
+	}
+	if update.Resolution != "" {
+		set["resolution"] = update.Resolution
+	}
+
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "status": fromStatus},
+		bson.M{
+			"$set":  set,
+			"$push": bson.M{"history": update.Event},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoIncidents) SessionIDsWithPhoto(ctx context.Context, mediaID string) ([]primitive.ObjectID, error) {
+	return distinctIDs(ctx, r.coll, "session_id", bson.M{"photos": mediaID})
+}
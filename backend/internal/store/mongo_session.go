@@ -0,0 +1,349 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoSessions struct {
+	coll     *mongo.Collection
+	vehicles string // Collection names joined by FindWithDetails
+	users    string
+}
+
+var newestParkedFirst = bson.D{{Key: "parked_at", Value: -1}, {Key: "_id", Value: -1}}
+
+func (f SessionFilter) bson() bson.M {
+	filter := bson.M{}
+
+	if !f.ID.IsZero() {
+		filter["_id"] = f.ID
+	} else if f.IDs != nil {
+		filter["_id"] = bson.M{"$in": f.IDs}
+	}
+	if !f.VehicleID.IsZero() {
+		filter["vehicle_id"] = f.VehicleID
+	}
+	if !f.CustomerID.IsZero() {
+		filter["customer_id"] = f.CustomerID
+	}
+	if !f.ValetID.IsZero() {
+		filter["valet_id"] = f.ValetID
+	}
+	if !f.DeliveredBy.IsZero() {
+		filter["delivered_by"] = f.DeliveredBy
+	}
+	if f.VenueName != "" {
+		filter["venue_name"] = f.VenueName
+	}
+	if f.TicketNumber != "" {
+		filter["ticket_number"] = f.TicketNumber
+	}
+
+	status := bson.M{}
+	if f.Statuses != nil {
+		status["$in"] = f.Statuses
+	}
+	if f.NotStatuses != nil {
+		status["$nin"] = f.NotStatuses
+	}
+	if len(status) > 0 {
+		filter["status"] = status
+	}
+
+	if f.Access != nil {
+		if len(f.Access.VehicleIDs) == 0 {
+			filter["customer_id"] = f.Access.CustomerID
+		} else {
+			filter["$or"] = []bson.M{
+				{"customer_id": f.Access.CustomerID},
+				{"vehicle_id": bson.M{"$in": f.Access.VehicleIDs}},
+			}
+		}
+	}
+
+	if f.PickupOTP != "" {
+		filter["pickup_otp"] = f.PickupOTP
+	}
+	if f.ParkedAt != nil {
+		filter["parked_at"] = timeWindow(*f.ParkedAt)
+	}
+	if f.DeliveredAt != nil {
+		filter["delivered_at"] = timeWindow(*f.DeliveredAt)
+	}
+	if f.NoKeyTag {
+		filter["key_tag"] = bson.M{"$exists": false}
+	}
+	if !f.KeyOut.IsZero() {
+		filter["key_tag.tag_id"] = f.KeyOut
+		filter["key_tag.returned_at"] = bson.M{"$exists": false}
+	}
+	if f.NotRated {
+		filter["rated_at"] = bson.M{"$exists": false}
+	}
+	if versions, ok := f.versions(); ok {
+		if len(versions) == 1 && versions[0] != 0 {
+			filter["version"] = versions[0]
+		} else {
+			in := bson.A{}
+			for _, v := range versions {
+				in = append(in, v)
+				if v == 0 {
+					// Sessions from before versioning have no version and read as 0
+					in = append(in, nil)
+				}
+			}
+			filter["version"] = bson.M{"$in": in}
+		}
+	}
+	if f.After != nil {
+		// Kept apart from Access, which may also need $or
+		filter["$and"] = []bson.M{{"$or": []bson.M{
+			{"parked_at": bson.M{"$lt": f.After.ParkedAt}},
+			{"parked_at": f.After.ParkedAt, "_id": bson.M{"$lt": f.After.ID}},
+		}}}
+	}
+	return filter
+}
+
+func (u SessionUpdate) bson() bson.M {
+	unset := bson.M{}
+	if u.ClearPickup {
+		unset["requested_at"] = ""
+		unset["requested_by"] = ""
+	}
+	if u.ClearPickup || u.ClearOTP {
+		unset["pickup_otp"] = ""
+		unset["otp_expires_at"] = ""
+		unset["otp_rollovers"] = ""
+	}
+	if u.ResetOTPRollovers {
+		unset["otp_rollovers"] = ""
+	}
+	if u.ClearKeyHolder {
+		unset["key_tag.holder_id"] = ""
+	}
+	if u.ClearRatedAt {
+		unset["rated_at"] = ""
+	}
+
+	set := bson.M{}
+	if u.Status != "" {
+		set["status"] = u.Status
+		if slices.Contains(models.ClosedStatuses, u.Status) {
+			unset["active"] = ""
+		} else {
+			set["active"] = true
+		}
+	}
+	if u.ParkingSpot != "" {
+		set["parking_spot"] = u.ParkingSpot
+	}
+	if u.RequestedAt != nil {
+		set["requested_at"] = *u.RequestedAt
+	}
+	if u.RequestedBy != nil {
+		set["requested_by"] = *u.RequestedBy
+	}
+	if u.PickupOTP != "" {
+		set["pickup_otp"] = u.PickupOTP
+	}
+	if u.OTPExpiresAt != nil {
+		set["otp_expires_at"] = *u.OTPExpiresAt
+	}
+	if u.DeliveredAt != nil {
+		set["delivered_at"] = *u.DeliveredAt
+	}
+	if u.DeliveredBy != nil {
+		set["delivered_by"] = *u.DeliveredBy
+	}
+	if u.KeyTag != nil {
+		set["key_tag"] = *u.KeyTag
+	}
+	if u.KeyHolder != nil {
+		set["key_tag.holder_id"] = *u.KeyHolder
+	}
+	if u.KeyLocation != "" {
+		set["key_tag.location"] = u.KeyLocation
+	}
+	if u.KeyReturnedAt != nil {
+		set["key_tag.returned_at"] = *u.KeyReturnedAt
+	}
+	if u.Release != nil {
+		set["manual_release"] = *u.Release
+	}
+	if u.Rejection != nil {
+		set["rejection"] = *u.Rejection
+	}
+	if u.RatedAt != nil {
+		set["rated_at"] = *u.RatedAt
+	}
+
+	// MongoDB rejects an update that both changes and unsets a field
+	for field := range set {
+		delete(unset, field)
+	}
+	if u.IncOTPRollovers {
+		delete(unset, "otp_rollovers")
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	inc := bson.M{"version": 1}
+	if u.IncOTPRollovers {
+		inc["otp_rollovers"] = 1
+	}
+	update["$inc"] = inc
+	if u.Custody != nil {
+		update["$push"] = bson.M{"key_custody": *u.Custody}
+	}
+	return update
+}
+
+func (r *mongoSessions) Insert(ctx context.Context, session models.ParkingSession) error {
+	session.Active = !slices.Contains(models.ClosedStatuses, session.Status)
+	_, err := r.coll.InsertOne(ctx, session)
+	return writeErr(err)
+}
+
+func (r *mongoSessions) FindByID(ctx context.Context, id primitive.ObjectID) (models.ParkingSession, error) {
+	return findOne[models.ParkingSession](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoSessions) FindOne(ctx context.Context, filter SessionFilter) (models.ParkingSession, error) {
+	return findOne[models.ParkingSession](ctx, r.coll, filter.bson(), options.FindOne().SetSort(newestParkedFirst))
+}
+
+func (r *mongoSessions) Find(ctx context.Context, filter SessionFilter, limit int64) ([]models.ParkingSession, error) {
+	opts := options.Find().SetSort(newestParkedFirst)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	return findAll[models.ParkingSession](ctx, r.coll, filter.bson(), opts)
+}
+
+func (r *mongoSessions) FindWithDetails(ctx context.Context, filter SessionFilter, limit int64) ([]models.SessionWithDetails, error) {
+	pipeline := []bson.M{
+		{"$match": filter.bson()},
+		{"$sort": newestParkedFirst},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+
+	// Join each reference in the same round trip; a missing document leaves the field unset
+	join := func(from, localField, as string) []bson.M {
+		return []bson.M{
+			{"$lookup": bson.M{"from": from, "localField": localField, "foreignField": "_id", "as": as}},
+			{"$unwind": bson.M{"path": "$" + as, "preserveNullAndEmptyArrays": true}},
+		}
+	}
+	pipeline = append(pipeline, join(r.vehicles, "vehicle_id", "vehicle")...)
+	pipeline = append(pipeline, join(r.users, "customer_id", "customer")...)
+	pipeline = append(pipeline, join(r.users, "valet_id", "valet")...)
+
+	return aggregate[models.SessionWithDetails](ctx, r.coll, pipeline)
+}
+
+func (r *mongoSessions) Count(ctx context.Context, filter SessionFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoSessions) Update(ctx context.Context, filter SessionFilter, update SessionUpdate) (bool, error) {
+	result, err := r.coll.UpdateOne(ctx, filter.bson(), update.bson())
+	if err != nil {
+		return false, writeErr(err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoSessions) Apply(ctx context.Context, filter SessionFilter, update SessionUpdate) (models.ParkingSession, error) {
+	var session models.ParkingSession
+	err := r.coll.FindOneAndUpdate(ctx, filter.bson(), update.bson(),
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return session, ErrNotFound
+	}
+	return session, writeErr(err)
+}
+
+func (r *mongoSessions) CustomerVisits(ctx context.Context, venueName string) (map[primitive.ObjectID]int, error) {
+	rows, err := aggregate[struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Count int                `bson:"count"`
+	}](ctx, r.coll, []bson.M{
+		{"$match": bson.M{"venue_name": venueName}},
+		{"$group": bson.M{"_id": "$customer_id", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	visits := make(map[primitive.ObjectID]int, len(rows))
+	for _, row := range rows {
+		visits[row.ID] = row.Count
+	}
+	return visits, nil
+}
+
+func (r *mongoSessions) ValetStats(ctx context.Context, venueName string, parked TimeRange) ([]ValetSessionStats, error) {
+	rows, err := aggregate[struct {
+		ValetID  primitive.ObjectID       `bson:"_id"`
+		Total    int                      `bson:"total"`
+		Rejected int                      `bson:"rejected"`
+		Reasons  []models.RejectionReason `bson:"reasons"`
+	}](ctx, r.coll, []bson.M{
+		{"$match": bson.M{
+			"venue_name": venueName,
+			"parked_at":  timeWindow(parked),
+		}},
+		{"$group": bson.M{
+			"_id":   "$valet_id",
+			"total": bson.M{"$sum": 1},
+			"rejected": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.StatusRejected}}, 1, 0},
+			}},
+			// One entry per session, empty unless it was rejected, so sessions
+			// without a rejection reason cannot be miscounted
+			"reasons": bson.M{"$push": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$status", models.StatusRejected}},
+				bson.M{"$ifNull": bson.A{"$rejection.reason", models.RejectOther}},
+				"",
+			}}},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]ValetSessionStats, 0, len(rows))
+	for _, row := range rows {
+		entry := ValetSessionStats{
+			ValetID:  row.ValetID,
+			Total:    row.Total,
+			Rejected: row.Rejected,
+			Reasons:  make(map[models.RejectionReason]int),
+		}
+		for _, reason := range row.Reasons {
+			if reason != "" {
+				entry.Reasons[reason]++
+			}
+		}
+		stats = append(stats, entry)
+	}
+	return stats, nil
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoUsers struct {
+	coll *mongo.Collection
+}
+
+func (f UserFilter) bson() bson.M {
+	filter := bson.M{}
+	if !f.ID.IsZero() {
+		filter["_id"] = f.ID
+	}
+	if f.IDs != nil {
+		filter["_id"] = bson.M{"$in": f.IDs}
+	}
+	if f.Phone != "" {
+		filter["phone"] = f.Phone
+	}
+	if f.Role != "" {
+		filter["role"] = f.Role
+	}
+	if f.VenueName != "" {
+		filter["venue_name"] = f.VenueName
+	}
+	return filter
+}
+
+func (r *mongoUsers) Insert(ctx context.Context, user models.User) error {
+	_, err := r.coll.InsertOne(ctx, user)
+	return err
+}
+
+func (r *mongoUsers) FindByID(ctx context.Context, id primitive.ObjectID) (models.User, error) {
+	return findOne[models.User](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoUsers) FindOne(ctx context.Context, filter UserFilter) (models.User, error) {
+	return findOne[models.User](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoUsers) Find(ctx context.Context, filter UserFilter) ([]models.User, error) {
+	return findAll[models.User](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoUsers) Count(ctx context.Context, filter UserFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoUsers) UpdateProfile(ctx context.Context, id primitive.ObjectID, name, language string) error {
+	set := bson.M{"name": name}
+	if language != "" {
+		set["preferred_language"] = language
+	}
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (r *mongoUsers) SetVenue(ctx context.Context, id primitive.ObjectID, venueName string) error {
+	update := bson.M{"$set": bson.M{"venue_name": venueName}}
+	if venueName == "" {
+		update = bson.M{"$unset": bson.M{"venue_name": ""}}
+	}
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+type mongoOTPs struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoOTPs) Insert(ctx context.Context, otp models.OTPStore) error {
+	_, err := r.coll.InsertOne(ctx, otp)
+	return err
+}
+
+func (r *mongoOTPs) FindByCode(ctx context.Context, phone, code string) (models.OTPStore, error) {
+	return findOne[models.OTPStore](ctx, r.coll, bson.M{"phone": phone, "otp": code})
+}
+
+func (r *mongoOTPs) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *mongoOTPs) DeleteByPhone(ctx context.Context, phone string) error {
+	_, err := r.coll.DeleteMany(ctx, bson.M{"phone": phone})
+	return err
+}
@@ -0,0 +1,299 @@
+package store
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoVehicles struct {
+	coll *mongo.Collection
+}
+
+func (f VehicleFilter) bson() bson.M {
+	filter := bson.M{}
+
+	id := bson.M{}
+	if !f.ID.IsZero() {
+		id["$eq"] = f.ID
+	}
+	if f.IDs != nil {
+		id["$in"] = f.IDs
+	}
+	if !f.NotID.IsZero() {
+		id["$ne"] = f.NotID
+	}
+	if len(id) > 0 {
+		filter["_id"] = id
+	}
+
+	owner := bson.M{}
+	if !f.OwnerID.IsZero() {
+		owner["$eq"] = f.OwnerID
+	}
+	if f.OwnerIDs != nil {
+		owner["$in"] = f.OwnerIDs
+	}
+	if !f.NotOwnerID.IsZero() {
+		owner["$ne"] = f.NotOwnerID
+	}
+	if len(owner) > 0 {
+		filter["owner_id"] = owner
+	}
+
+	if f.NormalizedReg != "" {
+		filter["normalized_registration"] = f.NormalizedReg
+	} else if f.RegContains != "" {
+		filter["normalized_registration"] = bson.M{"$regex": regexp.QuoteMeta(f.RegContains)}
+	}
+	if f.Make != "" {
+		filter["make"] = bson.M{"$regex": regexp.QuoteMeta(f.Make), "$options": "i"}
+	}
+	if f.Model != "" {
+		filter["model"] = bson.M{"$regex": regexp.QuoteMeta(f.Model), "$options": "i"}
+	}
+	if f.Color != "" {
+		filter["color"] = bson.M{"$regex": regexp.QuoteMeta(f.Color), "$options": "i"}
+	}
+	if f.Photo != "" {
+		filter["photos"] = f.Photo
+	}
+
+	if f.SharedWith != "" {
+		share := bson.M{"phone": f.SharedWith}
+		if f.SharePermission != "" {
+			share["permissions"] = f.SharePermission
+		}
+		filter["shared_with"] = bson.M{"$elemMatch": share}
+	}
+
+	if f.Active {
+		filter["archived_at"] = bson.M{"$exists": false}
+	}
+	if f.Unresolved {
+		filter["ownership.status"] = bson.M{"$nin": []models.OwnershipStatus{models.OwnershipVerified, models.OwnershipRejected}}
+	} else if f.NotRejected {
+		filter["ownership.status"] = bson.M{"$ne": models.OwnershipRejected}
+	}
+	return filter
+}
+
+func (u VehicleUpdate) bson() bson.M {
+	set := bson.M{}
+	if u.RegistrationNumber != "" {
+		set["registration_number"] = u.RegistrationNumber
+	}
+	if u.NormalizedReg != "" {
+		set["normalized_registration"] = u.NormalizedReg
+	}
+	if u.Make != "" {
+		set["make"] = u.Make
+	}
+	if u.Model != "" {
+		set["model"] = u.Model
+	}
+	if u.Color != "" {
+		set["color"] = u.Color
+	}
+	if u.VehicleType != "" {
+		set["vehicle_type"] = u.VehicleType
+	}
+	if u.Photos != nil {
+		set["photos"] = *u.Photos
+	}
+	if u.Ownership != nil {
+		set["ownership"] = *u.Ownership
+	}
+	if u.OwnershipStatus != "" {
+		set["ownership.status"] = u.OwnershipStatus
+	}
+	if u.OwnershipNote != "" {
+		set["ownership.note"] = u.OwnershipNote
+	}
+	if u.IsDefault != nil {
+		set["is_default"] = *u.IsDefault
+	}
+	if u.UpdatedAt != nil {
+		set["updated_at"] = *u.UpdatedAt
+	}
+	if u.ArchivedAt != nil {
+		set["archived_at"] = *u.ArchivedAt
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if u.Restore {
+		update["$unset"] = bson.M{"archived_at": ""}
+	}
+	return update
+}
+
+func (r *mongoVehicles) Insert(ctx context.Context, vehicle models.Vehicle) error {
+	_, err := r.coll.InsertOne(ctx, vehicle)
+	return err
+}
+
+func (r *mongoVehicles) FindByID(ctx context.Context, id primitive.ObjectID) (models.Vehicle, error) {
+	return findOne[models.Vehicle](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoVehicles) FindOne(ctx context.Context, filter VehicleFilter) (models.Vehicle, error) {
+	return findOne[models.Vehicle](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoVehicles) Find(ctx context.Context, filter VehicleFilter, order VehicleOrder, limit int64) ([]models.Vehicle, error) {
+	opts := options.Find()
+	switch order {
+	case VehicleOrderDefaultFirst:
+		opts.SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: -1}})
+	case VehicleOrderOldestFirst:
+		opts.SetSort(bson.D{{Key: "created_at", Value: 1}})
+	}
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	return findAll[models.Vehicle](ctx, r.coll, filter.bson(), opts)
+}
+
+func (r *mongoVehicles) Count(ctx context.Context, filter VehicleFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+// bson builds an aggregation expression computing the VehicleRank score
+func (rank VehicleRank) bson() bson.M {
+	terms := []interface{}{0}
+	if rank.Plate != "" {
+		terms = append(terms, bson.M{"$switch": bson.M{
+			"branches": []bson.M{
+				{"case": bson.M{"$eq": []interface{}{"$normalized_registration", rank.Plate}}, "then": 100},
+				{"case": bson.M{"$regexMatch": bson.M{"input": "$normalized_registration", "regex": regexp.QuoteMeta(rank.Plate) + "$"}}, "then": 70},
+			},
+			"default": 40,
+		}})
+	}
+	for field, value := range map[string]string{"make": rank.Make, "model": rank.Model, "color": rank.Color} {
+		if value != "" {
+			terms = append(terms, bson.M{"$cond": []interface{}{
+				bson.M{"$eq": []interface{}{bson.M{"$toLower": "$" + field}, strings.ToLower(value)}}, 10, 0,
+			}})
+		}
+	}
+
+	// Owners with the same visit bonus share one $in test
+	byBonus := make(map[int][]primitive.ObjectID)
+	for owner, visits := range rank.Visits {
+		if bonus := visitBonus(visits); bonus > 0 {
+			byBonus[bonus] = append(byBonus[bonus], owner)
+		}
+	}
+	for bonus, owners := range byBonus {
+		terms = append(terms, bson.M{"$cond": []interface{}{
+			bson.M{"$in": []interface{}{"$owner_id", owners}}, bonus, 0,
+		}})
+	}
+	return bson.M{"$add": terms}
+}
+
+func (r *mongoVehicles) Search(ctx context.Context, filter VehicleFilter, rank VehicleRank, skip, limit int64) ([]RankedVehicle, error) {
+	pipeline := []bson.M{
+		{"$match": filter.bson()},
+		{"$addFields": bson.M{"score": rank.bson()}},
+		{"$sort": bson.D{{Key: "score", Value: -1}, {Key: "normalized_registration", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+	if skip > 0 {
+		pipeline = append(pipeline, bson.M{"$skip": skip})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+	return aggregate[RankedVehicle](ctx, r.coll, pipeline)
+}
+
+func (r *mongoVehicles) Update(ctx context.Context, id primitive.ObjectID, update VehicleUpdate) error {
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, update.bson())
+	return err
+}
+
+func (r *mongoVehicles) UpdateMany(ctx context.Context, filter VehicleFilter, update VehicleUpdate) error {
+	_, err := r.coll.UpdateMany(ctx, filter.bson(), update.bson())
+	return err
+}
+
+func (r *mongoVehicles) PutShare(ctx context.Context, id primitive.ObjectID, share models.VehicleShare) error {
+	// Drop any existing share for this phone, then add the new one
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$pull": bson.M{"shared_with": bson.M{"phone": share.Phone}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = r.coll.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$push": bson.M{"shared_with": share}},
+	)
+	return err
+}
+
+func (r *mongoVehicles) RemoveShare(ctx context.Context, id primitive.ObjectID, phone string) (bool, error) {
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$pull": bson.M{"shared_with": bson.M{"phone": phone}}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+type mongoPlateDisputes struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoPlateDisputes) AddClaims(ctx context.Context, normalizedReg string, vehicleIDs []primitive.ObjectID) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{
+			"normalized_registration": normalizedReg,
+			"status":                  models.DisputeOpen,
+		},
+		bson.M{
+			"$addToSet":    bson.M{"vehicle_ids": bson.M{"$each": vehicleIDs}},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *mongoPlateDisputes) Resolve(ctx context.Context, normalizedReg string, vehicleID, by primitive.ObjectID, at time.Time) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{
+			"normalized_registration": normalizedReg,
+			"status":                  models.DisputeOpen,
+		},
+		bson.M{"$set": bson.M{
+			"status":              models.DisputeResolved,
+			"resolved_vehicle_id": vehicleID,
+			"resolved_by":         by,
+			"resolved_at":         at,
+		}},
+	)
+	return err
+}
+
+func (r *mongoPlateDisputes) Find(ctx context.Context, status models.DisputeStatus, limit int64) ([]models.PlateDispute, error) {
+	return findAll[models.PlateDispute](ctx, r.coll,
+		bson.M{"status": status},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit),
+	)
+}
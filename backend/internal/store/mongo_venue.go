@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var newestFirst = bson.D{{Key: "created_at", Value: -1}}
+
+type mongoVenues struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoVenues) FindByName(ctx context.Context, name string) (models.Venue, error) {
+	return findOne[models.Venue](ctx, r.coll, bson.M{"name": name})
+}
+
+func (r *mongoVenues) SaveSettings(ctx context.Context, venue models.Venue) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"name": venue.Name},
+		bson.M{
+			"$set": bson.M{
+				"pickup_otp_ttl_minutes": venue.PickupOTPTTLMinutes,
+				"otp_rollover_policy":    venue.OTPRolloverPolicy,
+				"max_otp_rollovers":      venue.MaxOTPRollovers,
+				"currency":               venue.Currency,
+				"lost_ticket_fee":        venue.LostTicketFee,
+				"low_rating_threshold":   venue.LowRatingThreshold,
+				"tip_parking_share":      venue.TipParkingShare,
+				"require_key_tag":        venue.RequireKeyTag,
+				"updated_at":             venue.UpdatedAt,
+			},
+			"$setOnInsert": bson.M{"name": venue.Name},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+type mongoKeyTags struct {
+	coll *mongo.Collection
+}
+
+func (f KeyTagFilter) bson() bson.M {
+	filter := bson.M{}
+	if !f.ID.IsZero() {
+		filter["_id"] = f.ID
+	}
+	if f.VenueName != "" {
+		filter["venue_name"] = f.VenueName
+	}
+	if f.Number != "" {
+		filter["number"] = f.Number
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	return filter
+}
+
+func (r *mongoKeyTags) Insert(ctx context.Context, tag models.KeyTag) error {
+	_, err := r.coll.InsertOne(ctx, tag)
+	return err
+}
+
+func (r *mongoKeyTags) FindOne(ctx context.Context, filter KeyTagFilter) (models.KeyTag, error) {
+	return findOne[models.KeyTag](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoKeyTags) Find(ctx context.Context, filter KeyTagFilter) ([]models.KeyTag, error) {
+	return findAll[models.KeyTag](ctx, r.coll, filter.bson(), options.Find().SetSort(bson.D{{Key: "number", Value: 1}}))
+}
+
+func (r *mongoKeyTags) Count(ctx context.Context, filter KeyTagFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoKeyTags) Claim(ctx context.Context, id, sessionID primitive.ObjectID) (bool, error) {
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.KeyTagAvailable},
+		bson.M{"$set": bson.M{
+			"status":     models.KeyTagInUse,
+			"session_id": sessionID,
+			"updated_at": time.Now(),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoKeyTags) Free(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.KeyTagInUse},
+		bson.M{
+			"$set":   bson.M{"status": models.KeyTagAvailable, "updated_at": time.Now()},
+			"$unset": bson.M{"session_id": ""},
+		},
+	)
+	return err
+}
+
+func (r *mongoKeyTags) UpdateDetails(ctx context.Context, id primitive.ObjectID, location string, status models.KeyTagStatus) (bool, error) {
+	set := bson.M{"updated_at": time.Now()}
+	if location != "" {
+		set["location"] = location
+	}
+	if status != "" {
+		set["status"] = status
+	}
+
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$ne": models.KeyTagInUse}},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+type mongoAuditLogs struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoAuditLogs) Insert(ctx context.Context, entry models.AuditLog) error {
+	_, err := r.coll.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *mongoAuditLogs) Find(ctx context.Context, filter AuditLogFilter, limit int64) ([]models.AuditLog, error) {
+	query := bson.M{}
+	if filter.VenueName != "" {
+		query["venue_name"] = filter.VenueName
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if !filter.SessionID.IsZero() {
+		query["session_id"] = filter.SessionID
+	}
+	return findAll[models.AuditLog](ctx, r.coll, query, options.Find().SetSort(newestFirst).SetLimit(limit))
+}
+
+type mongoAlerts struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoAlerts) Insert(ctx context.Context, alert models.Alert) error {
+	_, err := r.coll.InsertOne(ctx, alert)
+	return err
+}
+
+func (r *mongoAlerts) Find(ctx context.Context, filter AlertFilter, limit int64) ([]models.Alert, error) {
+	query := bson.M{}
+	if filter.VenueName != "" {
+		query["venue_name"] = filter.VenueName
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.OpenOnly {
+		query["acknowledged_at"] = bson.M{"$exists": false}
+	}
+	return findAll[models.Alert](ctx, r.coll, query, options.Find().SetSort(newestFirst).SetLimit(limit))
+}
+
+func (r *mongoAlerts) Acknowledge(ctx context.Context, id primitive.ObjectID, venueName string, by primitive.ObjectID, at time.Time) (bool, error) {
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{
+			"_id":             id,
+			"venue_name":      venueName,
+			"acknowledged_at": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{
+			"acknowledged_at": at,
+			"acknowledged_by": by,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
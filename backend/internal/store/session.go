@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CustomerAccess limits sessions to those a customer owns or that are for a
+// vehicle shared with them
+type CustomerAccess struct {
+	CustomerID primitive.ObjectID
+	VehicleIDs []primitive.ObjectID
+}
+
+// SessionCursor marks a position in the newest-parked-first session order
+type SessionCursor struct {
+	ParkedAt time.Time
+	ID       primitive.ObjectID
+}
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded
+var ErrInvalidCursor = errors.New("store: invalid cursor")
+
+// CursorAfter returns the cursor that continues a listing after session
+func CursorAfter(session models.ParkingSession) SessionCursor {
+	return SessionCursor{ParkedAt: session.ParkedAt, ID: session.ID}
+}
+
+// String encodes the cursor as an opaque token for clients
+func (c SessionCursor) String() string {
+	raw := strconv.FormatInt(c.ParkedAt.UnixMilli(), 10) + "_" + c.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseSessionCursor decodes a token made by SessionCursor.String
+func ParseSessionCursor(token string) (SessionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return SessionCursor{}, ErrInvalidCursor
+	}
+	millis, hex, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return SessionCursor{}, ErrInvalidCursor
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return SessionCursor{}, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return SessionCursor{}, ErrInvalidCursor
+	}
+	return SessionCursor{ParkedAt: time.UnixMilli(ms), ID: id}, nil
+}
+
+// SessionFilter selects parking sessions. Zero-valued fields are ignored.
+type SessionFilter struct {
+	ID           primitive.ObjectID
+	IDs          []primitive.ObjectID
+	VehicleID    primitive.ObjectID
+	CustomerID   primitive.ObjectID
+	ValetID      primitive.ObjectID
+	DeliveredBy  primitive.ObjectID
+	VenueName    string
+	TicketNumber string
+	Statuses     []models.SessionStatus // Status is one of these
+	NotStatuses  []models.SessionStatus // Status is none of these
+	Access       *CustomerAccess
+	PickupOTP    string
+	ParkedAt     *TimeRange
+	DeliveredAt  *TimeRange
+	NoKeyTag     bool               // No key tag was ever assigned
+	KeyOut       primitive.ObjectID // Keys are on this tag and not yet returned
+	NotRated     bool
+	After        *SessionCursor // Only sessions that come after the cursor
+	Version      *int64         // Only this version of the session
+	Versions     []int64        // Version is one of these
+}
+
+// versions merges Version and Versions into the versions a matching session
+// may have, reporting false if neither is set
+func (f SessionFilter) versions() ([]int64, bool) {
+	if f.Version == nil {
+		return f.Versions, f.Versions != nil
+	}
+	if f.Versions != nil && !slices.Contains(f.Versions, *f.Version) {
+		return []int64{}, true
+	}
+	return []int64{*f.Version}, true
+}
+
+// SessionUpdate describes changes to a session. Zero-valued fields are left
+// alone; the Clear flags unset groups of fields before anything is set. Every
+// update bumps the session's version.
+type SessionUpdate struct {
+	Status            models.SessionStatus
+	ParkingSpot       string
+	RequestedAt       *time.Time
+	RequestedBy       *primitive.ObjectID
+	PickupOTP         string
+	OTPExpiresAt      *time.Time
+	IncOTPRollovers   bool
+	ResetOTPRollovers bool
+	ClearPickup       bool // Request details, pickup OTP and rollovers
+	ClearOTP          bool // Pickup OTP and rollovers
+	DeliveredAt       *time.Time
+	DeliveredBy       *primitive.ObjectID
+	KeyTag            *models.SessionKey
+	KeyHolder         *primitive.ObjectID
+	ClearKeyHolder    bool
+	KeyLocation       string
+	KeyReturnedAt     *time.Time
+	Custody           *models.KeyCustodyEntry // Appended to the key custody log
+	Release           *models.ManualRelease
+	Rejection         *models.Rejection
+	RatedAt           *time.Time
+	ClearRatedAt      bool
+}
+
+// ValetSessionStats counts the sessions a valet created and how many were rejected
+type ValetSessionStats struct {
+	ValetID  primitive.ObjectID
+	Total    int
+	Rejected int
+	Reasons  map[models.RejectionReason]int
+}
+
+type SessionRepository interface {
+	Insert(ctx context.Context, session models.ParkingSession) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.ParkingSession, error)
+	// FindOne returns the most recently parked matching session
+	FindOne(ctx context.Context, filter SessionFilter) (models.ParkingSession, error)
+	// Find returns matching sessions, most recently parked first with ties
+	// broken by ID; a limit of 0 means no limit
+	Find(ctx context.Context, filter SessionFilter, limit int64) ([]models.ParkingSession, error)
+	// FindWithDetails is Find with each session's vehicle, customer and valet joined in
+	FindWithDetails(ctx context.Context, filter SessionFilter, limit int64) ([]models.SessionWithDetails, error)
+	Count(ctx context.Context, filter SessionFilter) (int64, error)
+	// Update applies an update to one matching session and reports whether any matched
+	Update(ctx context.Context, filter SessionFilter, update SessionUpdate) (bool, error)
+	// Apply is Update returning the session as updated, or ErrNotFound if none matched
+	Apply(ctx context.Context, filter SessionFilter, update SessionUpdate) (models.ParkingSession, error)
+	// CustomerVisits counts sessions per customer at a venue
+	CustomerVisits(ctx context.Context, venueName string) (map[primitive.ObjectID]int, error)
+	// ValetStats groups a venue's sessions parked in a range by valet
+	ValetStats(ctx context.Context, venueName string, parked TimeRange) ([]ValetSessionStats, error)
+}
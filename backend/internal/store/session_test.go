@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValetStatsCountsRejectionsWithoutReason(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemory().Sessions()
+
+	valet := primitive.NewObjectID()
+	parked := time.Now()
+	add := func(status models.SessionStatus, rejection *models.Rejection) {
+		t.Helper()
+		err := sessions.Insert(ctx, models.ParkingSession{
+			ID:        primitive.NewObjectID(),
+			VehicleID: primitive.NewObjectID(),
+			ValetID:   valet,
+			VenueName: "Grand Hotel",
+			Status:    status,
+			ParkedAt:  parked,
+			Rejection: rejection,
+		})
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	add(models.StatusParked, nil)
+	add(models.StatusRejected, &models.Rejection{Reason: models.RejectNotMyVehicle})
+	// Rejected before reasons were recorded
+	add(models.StatusRejected, &models.Rejection{})
+	add(models.StatusRejected, nil)
+
+	stats, err := sessions.ValetStats(ctx, "Grand Hotel", TimeRange{From: parked.Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("valet stats: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("got %d valets, want 1", len(stats))
+	}
+	got := stats[0]
+	if got.Total != 4 || got.Rejected != 3 {
+		t.Fatalf("got %d sessions, %d rejected; want 4, 3", got.Total, got.Rejected)
+	}
+	if len(got.Reasons) != 2 || got.Reasons[models.RejectNotMyVehicle] != 1 || got.Reasons[models.RejectOther] != 2 {
+		t.Fatalf("unexpected reasons: %v", got.Reasons)
+	}
+}
@@ -0,0 +1,74 @@
+// Package store is the data access layer used by the HTTP handlers. Each
+// collection is reached through a repository interface with typed filters and
+// updates, so handlers never build MongoDB queries themselves. NewMongo backs
+// the repositories with a MongoDB database; NewMemory keeps everything in
+// process for hermetic tests.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when no document matches a lookup
+var ErrNotFound = errors.New("store: not found")
+
+// ErrDuplicate is returned when a write would break a uniqueness rule, such
+// as a second active session for one vehicle
+var ErrDuplicate = errors.New("store: duplicate")
+
+// TimeRange matches times from From (inclusive) up to To (exclusive). A
+// zero To leaves the range open-ended.
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r TimeRange) contains(t time.Time) bool {
+	return !t.Before(r.From) && (r.To.IsZero() || t.Before(r.To))
+}
+
+// Store bundles the repositories of every collection
+type Store struct {
+	users         UserRepository
+	otps          OTPRepository
+	vehicles      VehicleRepository
+	plateDisputes PlateDisputeRepository
+	sessions      SessionRepository
+	venues        VenueRepository
+	inspections   InspectionRepository
+	media         MediaRepository
+	keyTags       KeyTagRepository
+	auditLogs     AuditLogRepository
+	ratings       RatingRepository
+	alerts        AlertRepository
+	tips          TipRepository
+	incidents     IncidentRepository
+	idempotency   IdempotencyRepository
+
+	transact func(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Transaction runs fn so that the writes it makes through ctx apply together
+// or not at all. fn may be retried on transient conflicts, so it must not
+// have side effects outside the store.
+func (s *Store) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.transact(ctx, fn)
+}
+
+func (s *Store) Users() UserRepository                 { return s.users }
+func (s *Store) OTPs() OTPRepository                   { return s.otps }
+func (s *Store) Vehicles() VehicleRepository           { return s.vehicles }
+func (s *Store) PlateDisputes() PlateDisputeRepository { return s.plateDisputes }
+func (s *Store) Sessions() SessionRepository           { return s.sessions }
+func (s *Store) Venues() VenueRepository               { return s.venues }
+func (s *Store) Inspections() InspectionRepository     { return s.inspections }
+func (s *Store) Media() MediaRepository                { return s.media }
+func (s *Store) KeyTags() KeyTagRepository             { return s.keyTags }
+func (s *Store) AuditLogs() AuditLogRepository         { return s.auditLogs }
+func (s *Store) Ratings() RatingRepository             { return s.ratings }
+func (s *Store) Alerts() AlertRepository               { return s.alerts }
+func (s *Store) Tips() TipRepository                   { return s.tips }
+func (s *Store) Incidents() IncidentRepository         { return s.incidents }
+func (s *Store) Idempotency() IdempotencyRepository    { return s.idempotency }
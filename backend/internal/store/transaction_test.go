@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// failingKeyTags is a key tag repository whose Free always fails
+type failingKeyTags struct {
+	KeyTagRepository
+}
+
+var errFreeFailed = errors.New("free failed")
+
+func (failingKeyTags) Free(ctx context.Context, id primitive.ObjectID) error {
+	return errFreeFailed
+}
+
+func TestMemoryTransactionRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+	s.keyTags = failingKeyTags{s.keyTags}
+
+	session := models.ParkingSession{
+		ID:        primitive.NewObjectID(),
+		VehicleID: primitive.NewObjectID(),
+		VenueName: "Grand Hotel",
+		Status:    models.StatusAvailable,
+		ParkedAt:  time.Now(),
+		Version:   1,
+		Active:    true,
+	}
+	if err := s.Sessions().Insert(ctx, session); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+	before, _ := s.Sessions().FindByID(ctx, session.ID)
+
+	// The delivery is written, then freeing the key tag fails
+	now := time.Now()
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := s.Sessions().Apply(ctx,
+			SessionFilter{ID: session.ID, Version: &session.Version},
+			SessionUpdate{Status: models.StatusDelivered, DeliveredAt: &now, ClearOTP: true},
+		); err != nil {
+			return err
+		}
+		if err := s.AuditLogs().Insert(ctx, models.AuditLog{Action: models.AuditManualRelease, VenueName: session.VenueName}); err != nil {
+			return err
+		}
+		return s.KeyTags().Free(ctx, primitive.NewObjectID())
+	})
+	if !errors.Is(err, errFreeFailed) {
+		t.Fatalf("transaction returned %v, want %v", err, errFreeFailed)
+	}
+
+	after, err := s.Sessions().FindByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("find session: %v", err)
+	}
+	if after.Status != before.Status || after.Version != before.Version || after.DeliveredAt != nil {
+		t.Fatalf("session changed by failed transaction: status %s, version %d, delivered %v",
+			after.Status, after.Version, after.DeliveredAt)
+	}
+	if logs, _ := s.AuditLogs().Find(ctx, AuditLogFilter{VenueName: session.VenueName}, 0); len(logs) != 0 {
+		t.Fatalf("failed transaction left %d audit entries", len(logs))
+	}
+
+	// A transaction that succeeds keeps its writes
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		_, err := s.Sessions().Apply(ctx,
+			SessionFilter{ID: session.ID, Version: &session.Version},
+			SessionUpdate{Status: models.StatusDelivered, DeliveredAt: &now, ClearOTP: true},
+		)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("transaction: %v", err)
+	}
+	if after, _ := s.Sessions().FindByID(ctx, session.ID); after.Status != models.StatusDelivered {
+		t.Fatalf("session status %s after commit, want delivered", after.Status)
+	}
+}
+
+func TestMemoryTransactionKeepsOutsideWrites(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+
+	otp := models.OTPStore{ID: primitive.NewObjectID(), Phone: "+919800000001", OTP: "123456"}
+	if err := s.OTPs().Insert(ctx, otp); err != nil {
+		t.Fatalf("insert otp: %v", err)
+	}
+
+	// Another request writes through its own ctx while the transaction runs
+	errFailed := errors.New("failed")
+	err := s.Transaction(ctx, func(txCtx context.Context) error {
+		if err := s.OTPs().Delete(txCtx, otp.ID); err != nil {
+			return err
+		}
+		if err := s.AuditLogs().Insert(txCtx, models.AuditLog{Action: models.AuditManualRelease, VenueName: "Grand Hotel"}); err != nil {
+			return err
+		}
+		if err := s.AuditLogs().Insert(ctx, models.AuditLog{Action: models.AuditCashTipRecorded, VenueName: "Grand Hotel"}); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("transaction returned %v, want %v", err, errFailed)
+	}
+
+	if _, err := s.OTPs().FindByCode(ctx, otp.Phone, otp.OTP); err != nil {
+		t.Fatalf("otp deleted by failed transaction: %v", err)
+	}
+	logs, _ := s.AuditLogs().Find(ctx, AuditLogFilter{VenueName: "Grand Hotel"}, 0)
+	if len(logs) != 1 || logs[0].Action != models.AuditCashTipRecorded {
+		t.Fatalf("want only the outside audit entry, got %v", logs)
+	}
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserFilter selects users. Zero-valued fields are ignored.
+type UserFilter struct {
+	ID        primitive.ObjectID
+	IDs       []primitive.ObjectID
+	Phone     string
+	Role      models.Role
+	VenueName string
+}
+
+type UserRepository interface {
+	Insert(ctx context.Context, user models.User) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.User, error)
+	FindOne(ctx context.Context, filter UserFilter) (models.User, error)
+	Find(ctx context.Context, filter UserFilter) ([]models.User, error)
+	Count(ctx context.Context, filter UserFilter) (int64, error)
+	// UpdateProfile sets the user's name, and their preferred language unless
+	// it is empty. The venue is never changed.
+	UpdateProfile(ctx context.Context, id primitive.ObjectID, name, language string) error
+	// SetVenue assigns the user to a venue, or takes them off theirs when
+	// venueName is empty
+	SetVenue(ctx context.Context, id primitive.ObjectID, venueName string) error
+}
+
+// OTPRepository stores login OTPs until they are used or replaced
+type OTPRepository interface {
+	Insert(ctx context.Context, otp models.OTPStore) error
+	FindByCode(ctx context.Context, phone, code string) (models.OTPStore, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByPhone(ctx context.Context, phone string) error
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VehicleFilter selects vehicles. Zero-valued fields are ignored.
+type VehicleFilter struct {
+	ID         primitive.ObjectID
+	IDs        []primitive.ObjectID
+	NotID      primitive.ObjectID
+	OwnerID    primitive.ObjectID
+	NotOwnerID primitive.ObjectID
+	// OwnerIDs restricts to these owners when non-nil; an empty slice matches nothing
+	OwnerIDs      []primitive.ObjectID
+	NormalizedReg string
+	RegContains   string // Substring of the normalized registration
+	Make          string // Case-insensitive substrings
+	Model         string
+	Color         string
+	Photo         string // Media ID among the vehicle photos
+	// SharedWith matches vehicles shared with a phone, with SharePermission if set
+	SharedWith      string
+	SharePermission models.SharePermission
+	Active          bool // Not archived
+	NotRejected     bool // Ownership claim not rejected
+	Unresolved      bool // Ownership neither verified nor rejected
+}
+
+// VehicleOrder is the order vehicles are returned in
+type VehicleOrder int
+
+const (
+	VehicleOrderNone         VehicleOrder = iota
+	VehicleOrderDefaultFirst              // Default vehicle first, then newest
+	VehicleOrderOldestFirst
+)
+
+// VehicleRank scores vehicle search candidates. A plate query scores 100 for
+// an exact match, 70 for a suffix (the digits a valet reads first) and 40
+// anywhere else; each of make, model and colour adds 10 when it matches
+// exactly, ignoring case; an owner with sessions at the venue adds 20, plus 2
+// per visit up to five.
+type VehicleRank struct {
+	Plate  string // Normalized
+	Make   string
+	Model  string
+	Color  string
+	Visits map[primitive.ObjectID]int // Sessions per owner at the venue
+}
+
+// visitBonus is the score an owner's visits add
+func visitBonus(visits int) int {
+	if visits <= 0 {
+		return 0
+	}
+	return 20 + 2*min(visits, 5)
+}
+
+// RankedVehicle is a search candidate and its VehicleRank score
+type RankedVehicle struct {
+	models.Vehicle `bson:",inline"`
+	Score          int `bson:"score"`
+}
+
+// VehicleUpdate describes changes to vehicles. Zero-valued fields are left
+// alone. Ownership replaces the whole ownership record and must not be
+// combined with OwnershipStatus or OwnershipNote.
+type VehicleUpdate struct {
+	RegistrationNumber string
+	NormalizedReg      string
+	Make               string
+	Model              string
+	Color              string
+	VehicleType        models.VehicleType
+	Photos             *[]string
+	Ownership          *models.Ownership
+	OwnershipStatus    models.OwnershipStatus
+	OwnershipNote      string
+	IsDefault          *bool
+	UpdatedAt          *time.Time
+	ArchivedAt         *time.Time
+	Restore            bool // Clears archived_at
+}
+
+type VehicleRepository interface {
+	Insert(ctx context.Context, vehicle models.Vehicle) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Vehicle, error)
+	FindOne(ctx context.Context, filter VehicleFilter) (models.Vehicle, error)
+	// Find returns matching vehicles; a limit of 0 means no limit
+	Find(ctx context.Context, filter VehicleFilter, order VehicleOrder, limit int64) ([]models.Vehicle, error)
+	Count(ctx context.Context, filter VehicleFilter) (int64, error)
+	// Search returns matching vehicles best score first, ties in registration
+	// order, ranking every match before skip and limit apply
+	Search(ctx context.Context, filter VehicleFilter, rank VehicleRank, skip, limit int64) ([]RankedVehicle, error)
+	Update(ctx context.Context, id primitive.ObjectID, update VehicleUpdate) error
+	UpdateMany(ctx context.Context, filter VehicleFilter, update VehicleUpdate) error
+	// PutShare adds a share, replacing any existing share for the same phone
+	PutShare(ctx context.Context, id primitive.ObjectID, share models.VehicleShare) error
+	// RemoveShare drops the share for a phone and reports whether there was one
+	RemoveShare(ctx context.Context, id primitive.ObjectID, phone string) (bool, error)
+}
+
+// PlateDisputeRepository tracks plates claimed by more than one customer
+type PlateDisputeRepository interface {
+	// AddClaims adds vehicles to the open dispute for a plate, opening one if needed
+	AddClaims(ctx context.Context, normalizedReg string, vehicleIDs []primitive.ObjectID) error
+	// Resolve closes the open disputes for a plate in favour of one vehicle
+	Resolve(ctx context.Context, normalizedReg string, vehicleID, by primitive.ObjectID, at time.Time) error
+	// Find returns disputes with a status, newest first
+	Find(ctx context.Context, status models.DisputeStatus, limit int64) ([]models.PlateDispute, error)
+}
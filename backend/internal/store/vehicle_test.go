@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVehicleSearchRanksBeforePaging(t *testing.T) {
+	ctx := context.Background()
+	vehicles := NewMemory().Vehicles()
+
+	regular, stranger := primitive.NewObjectID(), primitive.NewObjectID()
+	add := func(reg, color string, owner primitive.ObjectID) {
+		t.Helper()
+		err := vehicles.Insert(ctx, models.Vehicle{ID: primitive.NewObjectID(), OwnerID: owner, NormalizedReg: reg, Color: color})
+		if err != nil {
+			t.Fatalf("insert %s: %v", reg, err)
+		}
+	}
+	// Inserted worst first, so insertion order cannot pass for ranking
+	add("MH121234AB", "Red", stranger)
+	add("MH12AB1234", "Red", stranger)
+	add("KA01XY1234", "White", regular)
+	add("MH12CD1234", "White", stranger)
+	add("DL01EF1234", "White", regular)
+	add("GJ011234", "Black", regular)
+
+	rank := VehicleRank{Plate: "1234", Color: "white", Visits: map[primitive.ObjectID]int{regular: 3}}
+	want := []struct {
+		reg   string
+		score int
+	}{
+		{"DL01EF1234", 70 + 10 + 26}, // Suffix, colour, regular; ties break by registration
+		{"KA01XY1234", 70 + 10 + 26},
+		{"GJ011234", 70 + 26},
+		{"MH12CD1234", 70 + 10},
+		{"MH12AB1234", 70},
+		{"MH121234AB", 40}, // Partial
+	}
+
+	all, err := vehicles.Search(ctx, VehicleFilter{RegContains: "1234"}, rank, 0, 0)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(all) != len(want) {
+		t.Fatalf("got %d results, want %d", len(all), len(want))
+	}
+	for i, w := range want {
+		if all[i].NormalizedReg != w.reg || all[i].Score != w.score {
+			t.Errorf("result %d: got %s scoring %d, want %s scoring %d", i, all[i].NormalizedReg, all[i].Score, w.reg, w.score)
+		}
+	}
+
+	page, err := vehicles.Search(ctx, VehicleFilter{RegContains: "1234"}, rank, 2, 2)
+	if err != nil {
+		t.Fatalf("search page: %v", err)
+	}
+	if len(page) != 2 || page[0].NormalizedReg != want[2].reg || page[1].NormalizedReg != want[3].reg {
+		t.Fatalf("second page is %v, want %s and %s", page, want[2].reg, want[3].reg)
+	}
+}
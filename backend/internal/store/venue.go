@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"valet-parking-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type VenueRepository interface {
+	FindByName(ctx context.Context, name string) (models.Venue, error)
+	// SaveSettings writes a venue's settings, creating the venue if needed
+	SaveSettings(ctx context.Context, venue models.Venue) error
+}
+
+// KeyTagFilter selects key tags. Zero-valued fields are ignored.
+type KeyTagFilter struct {
+	ID        primitive.ObjectID
+	VenueName string
+	Number    string
+	Status    models.KeyTagStatus
+}
+
+type KeyTagRepository interface {
+	Insert(ctx context.Context, tag models.KeyTag) error
+	FindOne(ctx context.Context, filter KeyTagFilter) (models.KeyTag, error)
+	// Find returns matching tags ordered by number
+	Find(ctx context.Context, filter KeyTagFilter) ([]models.KeyTag, error)
+	Count(ctx context.Context, filter KeyTagFilter) (int64, error)
+	// Claim marks an available tag as holding a session's keys and reports whether it was available
+	Claim(ctx context.Context, id, sessionID primitive.ObjectID) (bool, error)
+	// Free puts an in-use tag back into the available inventory
+	Free(ctx context.Context, id primitive.ObjectID) error
+	// UpdateDetails changes the location and/or status of a tag that is not in
+	// use and reports whether it was changed. Empty values are left alone.
+	UpdateDetails(ctx context.Context, id primitive.ObjectID, location string, status models.KeyTagStatus) (bool, error)
+}
+
+// AuditLogFilter selects audit entries. Zero-valued fields are ignored.
+type AuditLogFilter struct {
+	VenueName string
+	Action    models.AuditAction
+	SessionID primitive.ObjectID
+}
+
+type AuditLogRepository interface {
+	Insert(ctx context.Context, entry models.AuditLog) error
+	// Find returns matching entries, newest first
+	Find(ctx context.Context, filter AuditLogFilter, limit int64) ([]models.AuditLog, error)
+}
+
+// AlertFilter selects alerts. Zero-valued fields are ignored.
+type AlertFilter struct {
+	VenueName string
+	Type      models.AlertType
+	OpenOnly  bool // Not yet acknowledged
+}
+
+type AlertRepository interface {
+	Insert(ctx context.Context, alert models.Alert) error
+	// Find returns matching alerts, newest first
+	Find(ctx context.Context, filter AlertFilter, limit int64) ([]models.Alert, error)
+	// Acknowledge marks an open alert at a venue as handled and reports whether one was found
+	Acknowledge(ctx context.Context, id primitive.ObjectID, venueName string, by primitive.ObjectID, at time.Time) (bool, error)
+}
@@ -1,7 +1,7 @@
 import 'user.dart';
 import 'vehicle.dart';
 
-enum SessionStatus { pending, parked, requested, moving, available, delivered, cancelled, inTransit }
+enum SessionStatus { pending, parked, requested, moving, available, delivered, cancelled, rejected, inTransit }
 
 class ParkingSession {
   final String id;
@@ -52,6 +52,7 @@ class ParkingSession {
         case 'available': return SessionStatus.available;
         case 'delivered': return SessionStatus.delivered;
         case 'cancelled': return SessionStatus.cancelled;
+        case 'rejected': return SessionStatus.rejected;
         case 'in_transit': return SessionStatus.inTransit;
         default: return SessionStatus.parked;
       }
@@ -93,6 +94,7 @@ class ParkingSession {
       case SessionStatus.available: return 'Ready for Pickup';
       case SessionStatus.delivered: return 'Delivered';
       case SessionStatus.cancelled: return 'Cancelled';
+      case SessionStatus.rejected: return 'Rejected';
       case SessionStatus.inTransit: return 'In Transit';
     }
   }
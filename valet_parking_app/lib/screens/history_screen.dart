@@ -99,11 +99,11 @@ class _HistoryCard extends StatelessWidget {
                 Container(
                   padding: const EdgeInsets.symmetric(horizontal: 8, vertical: 4),
                   decoration: BoxDecoration(
-                    color: session.status == SessionStatus.cancelled ? Colors.red : Colors.green,
+                    color: session.status == SessionStatus.delivered ? Colors.green : Colors.red,
                     borderRadius: BorderRadius.circular(4),
                   ),
                   child: Text(
-                    session.status == SessionStatus.cancelled ? 'CANCELLED' : 'COMPLETED',
+                    session.status == SessionStatus.delivered ? 'COMPLETED' : session.statusText.toUpperCase(),
                     style: const TextStyle(
                       color: Colors.white,
                       fontSize: 10,
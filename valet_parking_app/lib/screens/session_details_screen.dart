@@ -246,6 +246,7 @@ class _StatusHeader extends StatelessWidget {
       case SessionStatus.delivered:
         return Colors.teal;
       case SessionStatus.cancelled:
+      case SessionStatus.rejected:
         return Colors.red;
     }
   }
@@ -267,6 +268,7 @@ class _StatusHeader extends StatelessWidget {
       case SessionStatus.delivered:
         return Icons.check_circle;
       case SessionStatus.cancelled:
+      case SessionStatus.rejected:
         return Icons.cancel;
     }
   }
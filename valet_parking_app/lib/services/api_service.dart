@@ -1,12 +1,16 @@
+import 'dart:async';
 import 'dart:convert';
+import 'dart:math';
 import 'package:http/http.dart' as http;
 import '../config/api_config.dart';
 
 class ApiException implements Exception {
   final String message;
   final int? statusCode;
+  // Stable error code from the server's problem response, e.g. SESSION_NOT_FOUND
+  final String? code;
 
-  ApiException(this.message, {this.statusCode});
+  ApiException(this.message, {this.statusCode, this.code});
 
   @override
   String toString() => message;
@@ -23,6 +27,14 @@ class ApiService {
     _token = null;
   }
 
+  // Language for server messages and SMS, e.g. 'hi'. A signed-in user's
+  // preferred language takes precedence on the server.
+  String? _language;
+
+  void setLanguage(String language) {
+    _language = language;
+  }
+
   Map<String, String> get _headers {
     final headers = {
       'Content-Type': 'application/json',
@@ -30,9 +42,34 @@ class ApiService {
     if (_token != null) {
       headers['Authorization'] = 'Bearer $_token';
     }
+    if (_language != null) {
+      headers['Accept-Language'] = _language!;
+    }
     return headers;
   }
 
+  static final _random = Random.secure();
+
+  String _newIdempotencyKey() {
+    return List.generate(16, (_) => _random.nextInt(256).toRadixString(16).padLeft(2, '0')).join();
+  }
+
+  // POSTs with an Idempotency-Key and retries on network failures. The server
+  // replays the first response to a retry, so the change is never repeated.
+  Future<http.Response> _postIdempotent(Uri uri, {Object? body}) async {
+    final headers = {..._headers, 'Idempotency-Key': _newIdempotencyKey()};
+    for (var attempt = 1;; attempt++) {
+      try {
+        return await http.post(uri, headers: headers, body: body).timeout(const Duration(seconds: 15));
+      } on TimeoutException {
+        if (attempt >= 3) rethrow;
+      } on http.ClientException {
+        if (attempt >= 3) rethrow;
+      }
+      await Future.delayed(Duration(milliseconds: 500 * attempt));
+    }
+  }
+
   dynamic _handleResponse(http.Response response) {
     final body = jsonDecode(response.body);
 
@@ -42,8 +79,9 @@ class ApiService {
 
     if (body is Map) {
       throw ApiException(
-        body['error'] ?? 'Unknown error occurred',
+        body['error'] ?? body['title'] ?? 'Unknown error occurred',
         statusCode: response.statusCode,
+        code: body['code'],
       );
     }
     throw ApiException('Unknown error occurred', statusCode: response.statusCode);
@@ -114,9 +152,8 @@ class ApiService {
     required String customerId,
     required String venueName,
   }) async {
-    final response = await http.post(
+    final response = await _postIdempotent(
       Uri.parse('${ApiConfig.baseUrl}${ApiConfig.sessions}'),
-      headers: _headers,
       body: jsonEncode({
         'vehicle_id': vehicleId,
         'customer_id': customerId,
@@ -143,9 +180,8 @@ class ApiService {
   }
 
   Future<Map<String, dynamic>> requestPickup(String sessionId) async {
-    final response = await http.post(
+    final response = await _postIdempotent(
       Uri.parse('${ApiConfig.baseUrl}${ApiConfig.requestPickup(sessionId)}'),
-      headers: _headers,
     );
     return _handleResponse(response) as Map<String, dynamic>;
   }
@@ -159,28 +195,40 @@ class ApiService {
     return _handleResponse(response) as Map<String, dynamic>;
   }
 
-  Future<List<dynamic>> getPendingPickups() async {
-    final response = await http.get(
-      Uri.parse('${ApiConfig.baseUrl}${ApiConfig.pendingPickups}'),
-      headers: _headers,
-    );
+  // Fetches one page of a session listing: {items, next_cursor, has_more}
+  Future<Map<String, dynamic>> _getSessionPage(String path, {String? cursor, int? limit}) async {
+    final query = <String, String>{
+      if (cursor != null) 'cursor': cursor,
+      if (limit != null) 'limit': '$limit',
+    };
+    final uri = Uri.parse('${ApiConfig.baseUrl}$path')
+        .replace(queryParameters: query.isEmpty ? null : query);
+    final response = await http.get(uri, headers: _headers);
     final data = _handleResponse(response);
-    if (data is List) {
+    if (data is Map<String, dynamic>) {
       return data;
     }
-    return [];
+    return {'items': [], 'next_cursor': null, 'has_more': false};
+  }
+
+  // Follows next_cursor until the whole listing has been read
+  Future<List<dynamic>> _getAllSessionPages(String path) async {
+    final items = <dynamic>[];
+    String? cursor;
+    do {
+      final page = await _getSessionPage(path, cursor: cursor, limit: 100);
+      items.addAll(page['items'] as List? ?? []);
+      cursor = page['has_more'] == true ? page['next_cursor'] as String? : null;
+    } while (cursor != null);
+    return items;
+  }
+
+  Future<List<dynamic>> getPendingPickups() async {
+    return _getAllSessionPages(ApiConfig.pendingPickups);
   }
 
   Future<List<dynamic>> getAllActiveSessions() async {
-    final response = await http.get(
-      Uri.parse('${ApiConfig.baseUrl}${ApiConfig.allActiveSessions}'),
-      headers: _headers,
-    );
-    final data = _handleResponse(response);
-    if (data is List) {
-      return data;
-    }
-    return [];
+    return _getAllSessionPages(ApiConfig.allActiveSessions);
   }
 
   Future<Map<String, dynamic>> updateSessionStatus(String sessionId, String status) async {
@@ -192,16 +240,9 @@ class ApiService {
     return _handleResponse(response) as Map<String, dynamic>;
   }
 
-  Future<List<dynamic>> getHistory() async {
-    final response = await http.get(
-      Uri.parse('${ApiConfig.baseUrl}${ApiConfig.history}'),
-      headers: _headers,
-    );
-    final data = _handleResponse(response);
-    if (data is List) {
-      return data;
-    }
-    return [];
+  Future<List<dynamic>> getHistory({String? cursor}) async {
+    final page = await _getSessionPage(ApiConfig.history, cursor: cursor, limit: 50);
+    return page['items'] as List? ?? [];
   }
 
   Future<Map<String, dynamic>> acceptParking(String sessionId) async {